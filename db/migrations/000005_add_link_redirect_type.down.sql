@@ -0,0 +1 @@
+ALTER TABLE links DROP COLUMN redirect_type;
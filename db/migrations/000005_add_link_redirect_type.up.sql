@@ -0,0 +1 @@
+ALTER TABLE links ADD COLUMN redirect_type INTEGER NOT NULL DEFAULT 302 CHECK (redirect_type IN (301, 302, 307, 308));
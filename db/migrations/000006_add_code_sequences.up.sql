@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS code_sequences;
+CREATE TABLE code_sequences (
+    name TEXT PRIMARY KEY,
+    value INTEGER NOT NULL
+);
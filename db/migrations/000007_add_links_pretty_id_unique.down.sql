@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_links_pretty_id;
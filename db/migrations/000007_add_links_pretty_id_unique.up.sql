@@ -0,0 +1,9 @@
+UPDATE links SET pretty_id = lower(trim(pretty_id));
+
+-- Earlier aliases weren't unique, only the oldest claim survives
+UPDATE links SET pretty_id = ''
+WHERE pretty_id != '' AND rowid NOT IN (
+    SELECT MIN(rowid) FROM links WHERE pretty_id != '' GROUP BY pretty_id
+);
+
+CREATE UNIQUE INDEX idx_links_pretty_id ON links(pretty_id COLLATE NOCASE) WHERE pretty_id != '';
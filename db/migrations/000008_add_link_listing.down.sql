@@ -0,0 +1,16 @@
+DROP TRIGGER IF EXISTS update_links_updated_at;
+CREATE TRIGGER update_links_updated_at
+AFTER UPDATE ON links
+FOR EACH ROW
+BEGIN
+  UPDATE links SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
+
+DROP INDEX IF EXISTS idx_link_tags_tag_id;
+DROP TABLE IF EXISTS link_tags;
+DROP TABLE IF EXISTS tags;
+DROP INDEX IF EXISTS idx_links_user_id;
+
+ALTER TABLE links DROP COLUMN click_count;
+ALTER TABLE links DROP COLUMN destination_host;
+ALTER TABLE links DROP COLUMN status;
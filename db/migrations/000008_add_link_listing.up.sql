@@ -0,0 +1,46 @@
+ALTER TABLE links ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+ALTER TABLE links ADD COLUMN destination_host TEXT NOT NULL DEFAULT '';
+ALTER TABLE links ADD COLUMN click_count INTEGER NOT NULL DEFAULT 0;
+
+-- Everything between `://` and the first `/` of the normalized destination
+UPDATE links SET destination_host = lower(
+    substr(
+        substr(original_url, instr(original_url, '://') + 3),
+        1,
+        instr(substr(original_url, instr(original_url, '://') + 3) || '/', '/') - 1
+    )
+);
+
+CREATE INDEX idx_links_user_id ON links(user_id, id);
+
+DROP TABLE IF EXISTS tags;
+CREATE TABLE tags (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    UNIQUE (user_id, name)
+);
+
+DROP TABLE IF EXISTS link_tags;
+CREATE TABLE link_tags (
+    link_id TEXT NOT NULL,
+    tag_id TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (link_id, tag_id),
+    FOREIGN KEY (link_id) REFERENCES links(id),
+    FOREIGN KEY (tag_id) REFERENCES tags(id)
+);
+
+CREATE INDEX idx_link_tags_tag_id ON link_tags(tag_id);
+
+-- Clicks shouldn't count as edits to the link
+DROP TRIGGER IF EXISTS update_links_updated_at;
+CREATE TRIGGER update_links_updated_at
+AFTER UPDATE ON links
+FOR EACH ROW
+WHEN NEW.click_count = OLD.click_count
+BEGIN
+  UPDATE links SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS visitor_salt;
+DROP INDEX IF EXISTS idx_clicks_link_id_clicked_at;
+DROP TABLE IF EXISTS clicks;
@@ -0,0 +1,25 @@
+DROP TABLE IF EXISTS clicks;
+CREATE TABLE clicks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    link_id TEXT NOT NULL,
+    clicked_at TIMESTAMP NOT NULL,
+    referrer TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    device TEXT NOT NULL DEFAULT '',
+    browser TEXT NOT NULL DEFAULT '',
+    os TEXT NOT NULL DEFAULT '',
+    visitor_hash TEXT NOT NULL,
+    FOREIGN KEY (link_id) REFERENCES links(id)
+);
+
+CREATE INDEX idx_clicks_link_id_clicked_at ON clicks(link_id, clicked_at);
+
+-- The salt visitor hashes are made with. Only today's is kept, and it's
+-- replaced at midnight UTC so yesterday's hashes can't be linked to today's.
+-- Keeping it out of memory means a restart doesn't make returning visitors
+-- look new.
+CREATE TABLE visitor_salt (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    day TEXT NOT NULL,
+    salt BLOB NOT NULL
+);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS click_dimension_rollups;
+DROP TABLE IF EXISTS click_daily_rollups;
+DROP TABLE IF EXISTS click_hourly_rollups;
+DROP INDEX IF EXISTS idx_clicks_clicked_at;
+
+ALTER TABLE clicks DROP COLUMN country;
+ALTER TABLE clicks DROP COLUMN referrer_host;
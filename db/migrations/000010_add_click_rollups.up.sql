@@ -0,0 +1,33 @@
+ALTER TABLE clicks ADD COLUMN referrer_host TEXT NOT NULL DEFAULT '';
+ALTER TABLE clicks ADD COLUMN country TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_clicks_clicked_at ON clicks(clicked_at);
+
+-- Rollups are rebuilt from raw clicks by a background job. Buckets are UTC
+-- and stored as text so they compare the same way they're written.
+DROP TABLE IF EXISTS click_hourly_rollups;
+CREATE TABLE click_hourly_rollups (
+    link_id TEXT NOT NULL,
+    hour TEXT NOT NULL,
+    clicks INTEGER NOT NULL,
+    PRIMARY KEY (link_id, hour)
+);
+
+DROP TABLE IF EXISTS click_daily_rollups;
+CREATE TABLE click_daily_rollups (
+    link_id TEXT NOT NULL,
+    day TEXT NOT NULL,
+    clicks INTEGER NOT NULL,
+    unique_visitors INTEGER NOT NULL,
+    PRIMARY KEY (link_id, day)
+);
+
+DROP TABLE IF EXISTS click_dimension_rollups;
+CREATE TABLE click_dimension_rollups (
+    link_id TEXT NOT NULL,
+    day TEXT NOT NULL,
+    dimension TEXT NOT NULL,
+    value TEXT NOT NULL,
+    clicks INTEGER NOT NULL,
+    PRIMARY KEY (link_id, day, dimension, value)
+);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_links_expires_at;
+
+ALTER TABLE links DROP COLUMN fallback_url;
+ALTER TABLE links DROP COLUMN max_clicks;
+ALTER TABLE links DROP COLUMN expires_at;
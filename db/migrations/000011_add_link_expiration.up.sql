@@ -0,0 +1,5 @@
+ALTER TABLE links ADD COLUMN expires_at TIMESTAMP;
+ALTER TABLE links ADD COLUMN max_clicks INTEGER;
+ALTER TABLE links ADD COLUMN fallback_url TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_links_expires_at ON links(expires_at) WHERE expires_at IS NOT NULL;
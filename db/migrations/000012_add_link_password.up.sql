@@ -0,0 +1 @@
+ALTER TABLE links ADD COLUMN password_hash TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_link_revisions_link_id;
+DROP TABLE IF EXISTS link_revisions;
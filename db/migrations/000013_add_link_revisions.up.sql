@@ -0,0 +1,33 @@
+-- Every change to a link's editable fields. `old_values` and `new_values`
+-- hold the full set of editable fields before and after the change, so any
+-- revision can be restored on its own.
+CREATE TABLE link_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    link_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('create', 'update', 'rollback')),
+    old_values TEXT NOT NULL DEFAULT '{}',
+    new_values TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (link_id) REFERENCES links(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_link_revisions_link_id ON link_revisions(link_id, id);
+
+-- Existing links start their history at their current state
+INSERT INTO link_revisions (link_id, user_id, action, new_values, created_at)
+SELECT
+    id,
+    user_id,
+    'create',
+    json_object(
+        'original_url', original_url,
+        'pretty_id', pretty_id,
+        'redirect_type', redirect_type,
+        'expires_at', strftime('%Y-%m-%dT%H:%M:%SZ', expires_at),
+        'max_clicks', max_clicks
+    ),
+    created_at
+FROM links
+ORDER BY id;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS retired_codes;
+DROP INDEX IF EXISTS idx_links_deleted_at;
+
+ALTER TABLE links DROP COLUMN deleted_at;
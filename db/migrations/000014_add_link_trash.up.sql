@@ -0,0 +1,13 @@
+ALTER TABLE links ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_links_deleted_at ON links(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Short codes of purged links are never handed out again on the domain they
+-- were on, so old copies of a link can't start pointing somewhere else. The
+-- shared domain is ''.
+CREATE TABLE retired_codes (
+    domain_id TEXT NOT NULL DEFAULT '',
+    code TEXT NOT NULL COLLATE NOCASE,
+    retired_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (domain_id, code)
+);
@@ -0,0 +1,23 @@
+-- Long-running work queued on behalf of a user. `payload` holds the job's
+-- input and is cleared once it finishes; `result` holds its JSON output.
+CREATE TABLE jobs (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'completed', 'failed')),
+    payload BLOB NOT NULL DEFAULT x'',
+    progress INTEGER NOT NULL DEFAULT 0,
+    total INTEGER NOT NULL DEFAULT 0,
+    result TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP,
+    -- Where an interrupted job picks up again, in whatever form its handler
+    -- saved it
+    checkpoint TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_jobs_status ON jobs(status, created_at);
+CREATE INDEX idx_jobs_user_id ON jobs(user_id, created_at);
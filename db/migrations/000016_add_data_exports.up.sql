@@ -0,0 +1,14 @@
+-- Finished account exports, kept until their download links run out. The
+-- archives are files, so big ones don't have to fit in memory.
+CREATE TABLE data_exports (
+    job_id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    path TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (job_id) REFERENCES jobs(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_data_exports_expires_at ON data_exports(expires_at);
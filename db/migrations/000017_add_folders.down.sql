@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_links_folder_id;
+ALTER TABLE links DROP COLUMN folder_id;
+
+DROP TRIGGER IF EXISTS update_folders_updated_at;
+DROP INDEX IF EXISTS idx_folders_parent_id;
+DROP INDEX IF EXISTS idx_folders_user_parent_name;
+DROP TABLE IF EXISTS folders;
@@ -0,0 +1,29 @@
+-- Folders nest, but every folder and every link has at most one parent.
+CREATE TABLE folders (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    parent_id TEXT,
+    name TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (parent_id) REFERENCES folders(id)
+);
+
+-- Sibling folders can't share a name. Top-level folders have no parent, and
+-- NULLs never clash in a unique index, hence the COALESCE.
+CREATE UNIQUE INDEX idx_folders_user_parent_name ON folders(user_id, COALESCE(parent_id, ''), name COLLATE NOCASE);
+CREATE INDEX idx_folders_parent_id ON folders(parent_id);
+
+CREATE TRIGGER update_folders_updated_at
+AFTER UPDATE ON folders
+FOR EACH ROW
+BEGIN
+  UPDATE folders SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
+
+-- No REFERENCES here: SQLite can't drop a column that has one, which the
+-- down migration needs to do
+ALTER TABLE links ADD COLUMN folder_id TEXT;
+
+CREATE INDEX idx_links_folder_id ON links(folder_id) WHERE folder_id IS NOT NULL;
@@ -0,0 +1,11 @@
+-- The search triggers read links.title, which would stop the column from
+-- being dropped
+DROP TRIGGER IF EXISTS links_search_after_insert;
+DROP TRIGGER IF EXISTS links_search_after_update;
+DROP TRIGGER IF EXISTS links_search_after_delete;
+DROP TRIGGER IF EXISTS links_search_after_tag;
+DROP TRIGGER IF EXISTS links_search_after_untag;
+DROP TRIGGER IF EXISTS links_search_after_tag_rename;
+DROP TABLE IF EXISTS links_search;
+
+ALTER TABLE links DROP COLUMN title;
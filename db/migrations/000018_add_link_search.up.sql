@@ -0,0 +1,74 @@
+ALTER TABLE links ADD COLUMN title TEXT;
+
+-- The full-text index over links. FTS5 is only compiled into builds with the
+-- sqlite_fts5 tag, and the server won't start without it.
+CREATE VIRTUAL TABLE links_search USING fts5(
+    link_id UNINDEXED, url, alias, title, tags,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO links_search (link_id, url, alias, title, tags)
+SELECT l.id, l.original_url, l.short_url_id || ' ' || l.pretty_id, COALESCE(l.title, ''), COALESCE((
+    SELECT group_concat(t.name, ' ') FROM link_tags lt JOIN tags t ON t.id = lt.tag_id
+    WHERE lt.link_id = l.id
+), '')
+FROM links l;
+
+-- Clicks don't touch any indexed column, so the triggers only fire on real
+-- edits
+CREATE TRIGGER links_search_after_insert
+AFTER INSERT ON links
+FOR EACH ROW
+BEGIN
+  INSERT INTO links_search (link_id, url, alias, title, tags)
+  VALUES (NEW.id, NEW.original_url, NEW.short_url_id || ' ' || NEW.pretty_id, COALESCE(NEW.title, ''), '');
+END;
+
+CREATE TRIGGER links_search_after_update
+AFTER UPDATE OF original_url, short_url_id, pretty_id, title ON links
+FOR EACH ROW
+BEGIN
+  UPDATE links_search
+  SET url = NEW.original_url, alias = NEW.short_url_id || ' ' || NEW.pretty_id, title = COALESCE(NEW.title, '')
+  WHERE link_id = NEW.id;
+END;
+
+CREATE TRIGGER links_search_after_delete
+AFTER DELETE ON links
+FOR EACH ROW
+BEGIN
+  DELETE FROM links_search WHERE link_id = OLD.id;
+END;
+
+CREATE TRIGGER links_search_after_tag
+AFTER INSERT ON link_tags
+FOR EACH ROW
+BEGIN
+  UPDATE links_search SET tags = COALESCE((
+      SELECT group_concat(t.name, ' ') FROM link_tags lt JOIN tags t ON t.id = lt.tag_id
+      WHERE lt.link_id = NEW.link_id
+  ), '')
+  WHERE link_id = NEW.link_id;
+END;
+
+CREATE TRIGGER links_search_after_untag
+AFTER DELETE ON link_tags
+FOR EACH ROW
+BEGIN
+  UPDATE links_search SET tags = COALESCE((
+      SELECT group_concat(t.name, ' ') FROM link_tags lt JOIN tags t ON t.id = lt.tag_id
+      WHERE lt.link_id = OLD.link_id
+  ), '')
+  WHERE link_id = OLD.link_id;
+END;
+
+CREATE TRIGGER links_search_after_tag_rename
+AFTER UPDATE OF name ON tags
+FOR EACH ROW
+BEGIN
+  UPDATE links_search SET tags = COALESCE((
+      SELECT group_concat(t.name, ' ') FROM link_tags lt JOIN tags t ON t.id = lt.tag_id
+      WHERE lt.link_id = links_search.link_id
+  ), '')
+  WHERE link_id IN (SELECT link_id FROM link_tags WHERE tag_id = NEW.id);
+END;
@@ -0,0 +1,16 @@
+DROP TRIGGER IF EXISTS update_links_updated_at;
+CREATE TRIGGER update_links_updated_at
+AFTER UPDATE ON links
+FOR EACH ROW
+WHEN NEW.click_count = OLD.click_count
+BEGIN
+  UPDATE links SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
+
+DROP TRIGGER IF EXISTS reset_links_metadata;
+DROP INDEX IF EXISTS idx_links_metadata_pending;
+
+ALTER TABLE links DROP COLUMN metadata_fetched_at;
+ALTER TABLE links DROP COLUMN image_url;
+ALTER TABLE links DROP COLUMN favicon_url;
+ALTER TABLE links DROP COLUMN description;
@@ -0,0 +1,25 @@
+ALTER TABLE links ADD COLUMN description TEXT;
+ALTER TABLE links ADD COLUMN favicon_url TEXT;
+ALTER TABLE links ADD COLUMN image_url TEXT;
+-- NULL until the destination page has been fetched, successfully or not
+ALTER TABLE links ADD COLUMN metadata_fetched_at TIMESTAMP;
+
+CREATE INDEX idx_links_metadata_pending ON links(created_at) WHERE metadata_fetched_at IS NULL;
+
+CREATE TRIGGER reset_links_metadata
+AFTER UPDATE OF original_url ON links
+FOR EACH ROW
+WHEN NEW.original_url != OLD.original_url
+BEGIN
+  UPDATE links SET metadata_fetched_at = NULL WHERE id = NEW.id;
+END;
+
+-- Fetching metadata isn't an edit to the link either
+DROP TRIGGER IF EXISTS update_links_updated_at;
+CREATE TRIGGER update_links_updated_at
+AFTER UPDATE ON links
+FOR EACH ROW
+WHEN NEW.click_count = OLD.click_count AND NEW.metadata_fetched_at IS OLD.metadata_fetched_at
+BEGIN
+  UPDATE links SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
@@ -0,0 +1,3 @@
+ALTER TABLE links DROP COLUMN preview_image_url;
+ALTER TABLE links DROP COLUMN preview_description;
+ALTER TABLE links DROP COLUMN preview_title;
@@ -0,0 +1,5 @@
+-- Owner overrides for the social preview crawlers are shown. Unset fields
+-- fall back to the destination page's metadata.
+ALTER TABLE links ADD COLUMN preview_title TEXT;
+ALTER TABLE links ADD COLUMN preview_description TEXT;
+ALTER TABLE links ADD COLUMN preview_image_url TEXT;
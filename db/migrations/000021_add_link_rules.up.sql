@@ -0,0 +1,15 @@
+-- Targeting rules send some visitors of a link somewhere other than its
+-- destination. Rules are tried in position order and the first one whose
+-- conditions all match wins; visitors no rule matches get the destination.
+CREATE TABLE link_rules (
+    id TEXT PRIMARY KEY,
+    link_id TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    -- Comma separated values, any of which matches. NULL matches everyone.
+    os TEXT,
+    device TEXT,
+    destination_url TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (link_id) REFERENCES links(id),
+    UNIQUE (link_id, position)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE clicks DROP COLUMN city;
+
+ALTER TABLE link_rules DROP COLUMN region;
+ALTER TABLE link_rules DROP COLUMN country;
@@ -0,0 +1,6 @@
+-- ISO 3166-1 country codes and ISO 3166-2 region codes, comma separated
+-- like the other rule conditions
+ALTER TABLE link_rules ADD COLUMN country TEXT;
+ALTER TABLE link_rules ADD COLUMN region TEXT;
+
+ALTER TABLE clicks ADD COLUMN city TEXT NOT NULL DEFAULT '';
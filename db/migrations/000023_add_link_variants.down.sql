@@ -0,0 +1,4 @@
+ALTER TABLE clicks DROP COLUMN variant_id;
+
+DROP INDEX IF EXISTS idx_link_variants_link_id;
+DROP TABLE IF EXISTS link_variants;
@@ -0,0 +1,16 @@
+-- Variants split a link's visitors between destinations by weight, for A/B
+-- tests. Visitors a targeting rule doesn't send elsewhere are split between
+-- the variants, and each of their clicks records which one they got.
+CREATE TABLE link_variants (
+    id TEXT PRIMARY KEY,
+    link_id TEXT NOT NULL,
+    destination_url TEXT NOT NULL,
+    -- Share of visitors relative to the other variants. 0 pauses it.
+    weight INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (link_id) REFERENCES links(id)
+);
+
+CREATE INDEX idx_link_variants_link_id ON link_variants(link_id);
+
+ALTER TABLE clicks ADD COLUMN variant_id TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+ALTER TABLE link_rules DROP COLUMN daily_end;
+ALTER TABLE link_rules DROP COLUMN daily_start;
+ALTER TABLE link_rules DROP COLUMN days;
+ALTER TABLE link_rules DROP COLUMN ends_at;
+ALTER TABLE link_rules DROP COLUMN starts_at;
+ALTER TABLE link_rules DROP COLUMN timezone;
+
+ALTER TABLE links DROP COLUMN activates_at;
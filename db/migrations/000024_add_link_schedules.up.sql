@@ -0,0 +1,12 @@
+-- Links don't redirect before they activate
+ALTER TABLE links ADD COLUMN activates_at TIMESTAMP;
+
+-- Time conditions of rules. Windows are stored in UTC, while days and daily
+-- hours are read in the rule's timezone. Days are comma separated like the
+-- other conditions and hours are HH:MM.
+ALTER TABLE link_rules ADD COLUMN timezone TEXT;
+ALTER TABLE link_rules ADD COLUMN starts_at TIMESTAMP;
+ALTER TABLE link_rules ADD COLUMN ends_at TIMESTAMP;
+ALTER TABLE link_rules ADD COLUMN days TEXT;
+ALTER TABLE link_rules ADD COLUMN daily_start TEXT;
+ALTER TABLE link_rules ADD COLUMN daily_end TEXT;
@@ -0,0 +1,6 @@
+ALTER TABLE links DROP COLUMN query_passthrough;
+ALTER TABLE links DROP COLUMN utm_content;
+ALTER TABLE links DROP COLUMN utm_term;
+ALTER TABLE links DROP COLUMN utm_campaign;
+ALTER TABLE links DROP COLUMN utm_medium;
+ALTER TABLE links DROP COLUMN utm_source;
@@ -0,0 +1,10 @@
+-- UTM parameters added to the destination on every redirect
+ALTER TABLE links ADD COLUMN utm_source TEXT;
+ALTER TABLE links ADD COLUMN utm_medium TEXT;
+ALTER TABLE links ADD COLUMN utm_campaign TEXT;
+ALTER TABLE links ADD COLUMN utm_term TEXT;
+ALTER TABLE links ADD COLUMN utm_content TEXT;
+
+-- What happens to the query string visitors come with: dropped, merged into
+-- the destination's without replacing its parameters, or merged over them
+ALTER TABLE links ADD COLUMN query_passthrough TEXT NOT NULL DEFAULT 'drop' CHECK (query_passthrough IN ('drop', 'merge', 'override'));
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_links_campaign_id;
+ALTER TABLE links DROP COLUMN campaign_id;
+
+DROP TRIGGER IF EXISTS update_campaigns_updated_at;
+DROP INDEX IF EXISTS idx_campaigns_user_name;
+DROP TABLE IF EXISTS campaigns;
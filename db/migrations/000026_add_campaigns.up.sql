@@ -0,0 +1,31 @@
+-- Campaigns group links for reporting. Their UTM values fill in whatever
+-- their links don't set themselves.
+CREATE TABLE campaigns (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    starts_at TIMESTAMP,
+    ends_at TIMESTAMP,
+    utm_source TEXT,
+    utm_medium TEXT,
+    utm_campaign TEXT,
+    utm_term TEXT,
+    utm_content TEXT,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE UNIQUE INDEX idx_campaigns_user_name ON campaigns(user_id, name COLLATE NOCASE);
+
+CREATE TRIGGER update_campaigns_updated_at
+AFTER UPDATE ON campaigns
+FOR EACH ROW
+BEGIN
+  UPDATE campaigns SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
+
+-- No REFERENCES for the same reason as folder_id
+ALTER TABLE links ADD COLUMN campaign_id TEXT;
+
+CREATE INDEX idx_links_campaign_id ON links(campaign_id) WHERE campaign_id IS NOT NULL;
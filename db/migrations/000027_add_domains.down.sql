@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_links_domain_pretty_id;
+CREATE UNIQUE INDEX idx_links_pretty_id ON links(pretty_id COLLATE NOCASE) WHERE pretty_id != '';
+
+ALTER TABLE links DROP COLUMN domain_id;
+
+DROP TRIGGER IF EXISTS update_domains_updated_at;
+DROP INDEX IF EXISTS idx_domains_verified_hostname;
+DROP INDEX IF EXISTS idx_domains_user_hostname;
+DROP TABLE IF EXISTS domains;
@@ -0,0 +1,31 @@
+-- Branded domains links can be served on instead of the shared one. A
+-- domain only routes once its owner has proven control of it with a DNS
+-- TXT record, so unverified claims don't block anyone else.
+CREATE TABLE domains (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    hostname TEXT NOT NULL,
+    verification_token TEXT NOT NULL,
+    verified_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE UNIQUE INDEX idx_domains_user_hostname ON domains(user_id, hostname);
+CREATE UNIQUE INDEX idx_domains_verified_hostname ON domains(hostname) WHERE verified_at IS NOT NULL;
+
+CREATE TRIGGER update_domains_updated_at
+AFTER UPDATE ON domains
+FOR EACH ROW
+BEGIN
+  UPDATE domains SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
+
+-- NULL is the shared domain. No REFERENCES for the same reason as folder_id.
+ALTER TABLE links ADD COLUMN domain_id TEXT;
+
+-- Aliases only have to be unique on their own domain. Generated codes stay
+-- unique everywhere through the short_url_id constraint.
+DROP INDEX IF EXISTS idx_links_pretty_id;
+CREATE UNIQUE INDEX idx_links_domain_pretty_id ON links(COALESCE(domain_id, ''), pretty_id COLLATE NOCASE) WHERE pretty_id != '';
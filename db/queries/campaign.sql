@@ -0,0 +1,82 @@
+-- name: CreateCampaign :one
+INSERT INTO campaigns (id, user_id, name, starts_at, ends_at, utm_source, utm_medium, utm_campaign, utm_term, utm_content)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: GetCampaign :one
+SELECT * FROM campaigns WHERE id = ? AND user_id = ? LIMIT 1;
+
+-- name: GetCampaignUTM :one
+SELECT utm_source, utm_medium, utm_campaign, utm_term, utm_content FROM campaigns WHERE id = ? LIMIT 1;
+
+-- name: ListCampaigns :many
+SELECT campaigns.*,
+    (SELECT COUNT(*) FROM links WHERE links.campaign_id = campaigns.id AND links.deleted_at IS NULL) AS link_count
+FROM campaigns
+WHERE campaigns.user_id = ?
+ORDER BY campaigns.created_at DESC, campaigns.id DESC;
+
+-- name: UpdateCampaign :one
+UPDATE campaigns SET name = ?, starts_at = ?, ends_at = ?, utm_source = ?, utm_medium = ?, utm_campaign = ?, utm_term = ?, utm_content = ?
+WHERE id = ? AND user_id = ? RETURNING *;
+
+-- name: DeleteCampaign :exec
+DELETE FROM campaigns WHERE id = ? AND user_id = ?;
+
+-- name: SetLinkCampaign :execrows
+UPDATE links SET campaign_id = ? WHERE id = ? AND user_id = ?;
+
+-- name: DetachCampaignLink :execrows
+UPDATE links SET campaign_id = NULL WHERE id = ? AND campaign_id = ?;
+
+-- name: DetachCampaignLinks :exec
+UPDATE links SET campaign_id = NULL WHERE campaign_id = ?;
+
+-- name: ListCampaignLinks :many
+SELECT * FROM links WHERE campaign_id = ? AND deleted_at IS NULL ORDER BY created_at DESC, id DESC;
+
+-- name: ListCampaignHourlyClicks :many
+SELECT click_hourly_rollups.hour, CAST(SUM(click_hourly_rollups.clicks) AS INTEGER) AS clicks
+FROM click_hourly_rollups
+JOIN links ON links.id = click_hourly_rollups.link_id
+WHERE links.campaign_id = sqlc.arg(campaign_id) AND links.deleted_at IS NULL
+  AND click_hourly_rollups.hour >= sqlc.arg(from_hour)
+  AND click_hourly_rollups.hour <= sqlc.arg(to_hour)
+GROUP BY click_hourly_rollups.hour
+ORDER BY click_hourly_rollups.hour;
+
+-- name: GetCampaignDailyUniqueVisitors :one
+-- Read from raw clicks rather than the rollups, so someone who clicks
+-- several of the campaign's links on the same day is counted once. Each link
+-- is read through idx_clicks_link_id_clicked_at over the stats range only.
+SELECT COUNT(*) AS unique_visitors
+FROM (
+    SELECT DISTINCT strftime('%Y-%m-%d', clicks.clicked_at), clicks.visitor_hash
+    FROM clicks
+    JOIN links ON links.id = clicks.link_id
+    WHERE links.campaign_id = sqlc.arg(campaign_id) AND links.deleted_at IS NULL
+      AND clicks.clicked_at >= CAST(sqlc.arg(from_day) AS TEXT)
+      AND clicks.clicked_at < date(CAST(sqlc.arg(to_day) AS TEXT), '+1 day')
+);
+
+-- name: ListCampaignTopDimensionValues :many
+SELECT click_dimension_rollups.value, CAST(SUM(click_dimension_rollups.clicks) AS INTEGER) AS clicks
+FROM click_dimension_rollups
+JOIN links ON links.id = click_dimension_rollups.link_id
+WHERE links.campaign_id = sqlc.arg(campaign_id) AND links.deleted_at IS NULL
+  AND click_dimension_rollups.dimension = sqlc.arg(dimension)
+  AND click_dimension_rollups.day >= sqlc.arg(from_day)
+  AND click_dimension_rollups.day <= sqlc.arg(to_day)
+  AND click_dimension_rollups.value != ''
+GROUP BY click_dimension_rollups.value
+ORDER BY clicks DESC
+LIMIT sqlc.arg(max_values);
+
+-- name: ListCampaignLinkClicks :many
+SELECT links.id AS link_id, CAST(COALESCE(SUM(click_daily_rollups.clicks), 0) AS INTEGER) AS clicks
+FROM links
+LEFT JOIN click_daily_rollups ON click_daily_rollups.link_id = links.id
+  AND click_daily_rollups.day >= sqlc.arg(from_day)
+  AND click_daily_rollups.day <= sqlc.arg(to_day)
+WHERE links.campaign_id = sqlc.arg(campaign_id) AND links.deleted_at IS NULL
+GROUP BY links.id
+ORDER BY clicks DESC, links.id;
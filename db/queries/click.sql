@@ -0,0 +1,36 @@
+-- name: CreateClick :exec
+INSERT INTO clicks (
+    link_id,
+    clicked_at,
+    referrer,
+    referrer_host,
+    user_agent,
+    device,
+    browser,
+    os,
+    country,
+    city,
+    variant_id,
+    visitor_hash
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+);
+
+-- name: DeleteLinkClicks :exec
+DELETE FROM clicks WHERE link_id = ?;
+
+-- name: ListUserClicks :many
+SELECT clicks.* FROM clicks
+JOIN links ON links.id = clicks.link_id
+WHERE links.user_id = sqlc.arg(user_id) AND clicks.id > sqlc.arg(after_id)
+ORDER BY clicks.id
+LIMIT sqlc.arg(max_clicks);
+
+-- name: GetVisitorSalt :one
+SELECT day, salt FROM visitor_salt WHERE id = 1;
+
+-- name: RotateVisitorSalt :exec
+-- The salt only ever moves on to a later day, in case it already has.
+INSERT INTO visitor_salt (id, day, salt) VALUES (1, sqlc.arg(day), sqlc.arg(salt))
+ON CONFLICT (id) DO UPDATE SET day = excluded.day, salt = excluded.salt
+WHERE excluded.day > visitor_salt.day;
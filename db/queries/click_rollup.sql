@@ -0,0 +1,75 @@
+-- name: GetLatestRollupHour :one
+SELECT CAST(COALESCE(MAX(hour), '') AS TEXT) AS hour FROM click_hourly_rollups;
+
+-- name: RollupHourlyClicks :exec
+INSERT INTO click_hourly_rollups (link_id, hour, clicks)
+SELECT link_id, strftime('%Y-%m-%d %H:00:00', clicked_at) AS bucket, COUNT(*)
+FROM clicks
+WHERE clicked_at >= sqlc.arg(since)
+GROUP BY link_id, bucket
+ON CONFLICT (link_id, hour) DO UPDATE SET clicks = excluded.clicks;
+
+-- name: RollupDailyClicks :exec
+INSERT INTO click_daily_rollups (link_id, day, clicks, unique_visitors)
+SELECT link_id, strftime('%Y-%m-%d', clicked_at) AS bucket, COUNT(*), COUNT(DISTINCT visitor_hash)
+FROM clicks
+WHERE clicked_at >= sqlc.arg(since)
+GROUP BY link_id, bucket
+ON CONFLICT (link_id, day) DO UPDATE SET
+    clicks = excluded.clicks,
+    unique_visitors = excluded.unique_visitors;
+
+-- name: RollupClickDimensions :exec
+INSERT INTO click_dimension_rollups (link_id, day, dimension, value, clicks)
+SELECT * FROM (
+    SELECT link_id, strftime('%Y-%m-%d', clicked_at) AS bucket, 'referrer', referrer_host, COUNT(*)
+    FROM clicks WHERE clicked_at >= sqlc.arg(since) GROUP BY link_id, bucket, referrer_host
+    UNION ALL
+    SELECT link_id, strftime('%Y-%m-%d', clicked_at) AS bucket, 'country', country, COUNT(*)
+    FROM clicks WHERE clicked_at >= sqlc.arg(since) GROUP BY link_id, bucket, country
+    UNION ALL
+    SELECT link_id, strftime('%Y-%m-%d', clicked_at) AS bucket, 'city', city, COUNT(*)
+    FROM clicks WHERE clicked_at >= sqlc.arg(since) GROUP BY link_id, bucket, city
+    UNION ALL
+    SELECT link_id, strftime('%Y-%m-%d', clicked_at) AS bucket, 'device', device, COUNT(*)
+    FROM clicks WHERE clicked_at >= sqlc.arg(since) GROUP BY link_id, bucket, device
+    UNION ALL
+    SELECT link_id, strftime('%Y-%m-%d', clicked_at) AS bucket, 'browser', browser, COUNT(*)
+    FROM clicks WHERE clicked_at >= sqlc.arg(since) GROUP BY link_id, bucket, browser
+    UNION ALL
+    SELECT link_id, strftime('%Y-%m-%d', clicked_at) AS bucket, 'os', os, COUNT(*)
+    FROM clicks WHERE clicked_at >= sqlc.arg(since) GROUP BY link_id, bucket, os
+    UNION ALL
+    SELECT link_id, strftime('%Y-%m-%d', clicked_at) AS bucket, 'variant', variant_id, COUNT(*)
+    FROM clicks WHERE clicked_at >= sqlc.arg(since) GROUP BY link_id, bucket, variant_id
+) WHERE true
+ON CONFLICT (link_id, day, dimension, value) DO UPDATE SET clicks = excluded.clicks;
+
+-- name: ListHourlyClicks :many
+SELECT hour, clicks FROM click_hourly_rollups
+WHERE link_id = sqlc.arg(link_id) AND hour >= sqlc.arg(from_hour) AND hour <= sqlc.arg(to_hour)
+ORDER BY hour;
+
+-- name: GetDailyUniqueVisitors :one
+SELECT CAST(COALESCE(SUM(unique_visitors), 0) AS INTEGER) AS daily_unique_visitors FROM click_daily_rollups
+WHERE link_id = sqlc.arg(link_id) AND day >= sqlc.arg(from_day) AND day <= sqlc.arg(to_day);
+
+-- name: ListTopDimensionValues :many
+SELECT value, CAST(SUM(clicks) AS INTEGER) AS clicks FROM click_dimension_rollups
+WHERE link_id = sqlc.arg(link_id)
+  AND dimension = sqlc.arg(dimension)
+  AND day >= sqlc.arg(from_day)
+  AND day <= sqlc.arg(to_day)
+  AND value != ''
+GROUP BY value
+ORDER BY clicks DESC
+LIMIT sqlc.arg(max_values);
+
+-- name: DeleteLinkHourlyRollups :exec
+DELETE FROM click_hourly_rollups WHERE link_id = ?;
+
+-- name: DeleteLinkDailyRollups :exec
+DELETE FROM click_daily_rollups WHERE link_id = ?;
+
+-- name: DeleteLinkDimensionRollups :exec
+DELETE FROM click_dimension_rollups WHERE link_id = ?;
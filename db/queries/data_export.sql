@@ -0,0 +1,14 @@
+-- name: CreateDataExport :exec
+-- A job that's run again after a restart replaces the export it may have
+-- stored already.
+INSERT INTO data_exports (job_id, user_id, path, size, expires_at) VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (job_id) DO UPDATE SET
+    path = excluded.path,
+    size = excluded.size,
+    expires_at = excluded.expires_at;
+
+-- name: GetDataExport :one
+SELECT * FROM data_exports WHERE job_id = ? LIMIT 1;
+
+-- name: DeleteExpiredDataExports :many
+DELETE FROM data_exports WHERE expires_at <= ? RETURNING path;
@@ -0,0 +1,21 @@
+-- name: CreateDomain :one
+INSERT INTO domains (id, user_id, hostname, verification_token) VALUES (?, ?, ?, ?) RETURNING *;
+
+-- name: GetDomain :one
+SELECT * FROM domains WHERE id = ? AND user_id = ? LIMIT 1;
+
+-- name: GetVerifiedDomainByHostname :one
+SELECT * FROM domains WHERE hostname = ? AND verified_at IS NOT NULL LIMIT 1;
+
+-- name: ListDomains :many
+SELECT * FROM domains WHERE user_id = ? ORDER BY hostname;
+
+-- name: VerifyDomain :one
+UPDATE domains SET verified_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ? RETURNING *;
+
+-- name: DeleteDomain :exec
+DELETE FROM domains WHERE id = ? AND user_id = ?;
+
+-- name: CountDomainLinks :one
+-- Trashed links count too, since they can still be restored.
+SELECT COUNT(*) FROM links WHERE domain_id = ?;
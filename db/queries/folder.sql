@@ -0,0 +1,38 @@
+-- name: CreateFolder :one
+INSERT INTO folders (id, user_id, parent_id, name) VALUES (?, ?, ?, ?) RETURNING *;
+
+-- name: GetFolder :one
+SELECT * FROM folders WHERE id = ? AND user_id = ? LIMIT 1;
+
+-- name: ListFolders :many
+SELECT folders.id, folders.parent_id, folders.name, folders.updated_at, folders.created_at,
+    (SELECT COUNT(*) FROM links WHERE links.folder_id = folders.id AND links.deleted_at IS NULL) AS link_count
+FROM folders
+WHERE folders.user_id = ?
+ORDER BY folders.name COLLATE NOCASE, folders.id;
+
+-- name: UpdateFolder :one
+UPDATE folders SET name = ?, parent_id = ? WHERE id = ? AND user_id = ? RETURNING *;
+
+-- name: DeleteFolder :exec
+DELETE FROM folders WHERE id = ? AND user_id = ?;
+
+-- name: ReparentFolders :exec
+UPDATE folders SET parent_id = sqlc.narg(new_parent_id) WHERE parent_id = sqlc.arg(parent_id);
+
+-- name: MoveFolderLinks :exec
+UPDATE links SET folder_id = sqlc.narg(new_folder_id) WHERE folder_id = sqlc.arg(folder_id);
+
+-- name: IsFolderAncestor :one
+-- Whether ancestor_id is folder_id itself or any folder above it.
+WITH RECURSIVE ancestors(id) AS (
+    SELECT sqlc.arg(folder_id)
+    UNION
+    SELECT folders.parent_id FROM folders
+    JOIN ancestors ON folders.id = ancestors.id
+    WHERE folders.parent_id IS NOT NULL
+)
+SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = sqlc.arg(ancestor_id)) AS is_ancestor;
+
+-- name: SetLinkFolder :execrows
+UPDATE links SET folder_id = ? WHERE id = ? AND user_id = ?;
@@ -0,0 +1,25 @@
+-- name: CreateJob :one
+INSERT INTO jobs (id, user_id, kind, payload) VALUES (?, ?, ?, ?) RETURNING *;
+
+-- name: GetJob :one
+SELECT * FROM jobs WHERE id = ? AND user_id = ? LIMIT 1;
+
+-- name: ClaimNextJob :one
+UPDATE jobs SET status = 'running', updated_at = CURRENT_TIMESTAMP
+WHERE id = (SELECT id FROM jobs WHERE status = 'queued' ORDER BY created_at, id LIMIT 1)
+RETURNING *;
+
+-- name: UpdateJobProgress :exec
+UPDATE jobs SET progress = ?, total = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: SetJobCheckpoint :exec
+UPDATE jobs SET checkpoint = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: CompleteJob :exec
+UPDATE jobs SET status = 'completed', result = ?, payload = x'', checkpoint = '', updated_at = CURRENT_TIMESTAMP, finished_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: FailJob :exec
+UPDATE jobs SET status = 'failed', error = ?, payload = x'', checkpoint = '', updated_at = CURRENT_TIMESTAMP, finished_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: RequeueRunningJobs :execrows
+UPDATE jobs SET status = 'queued', updated_at = CURRENT_TIMESTAMP WHERE status = 'running';
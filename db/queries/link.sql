@@ -1,9 +1,65 @@
 -- name: CreateShortLink :one
-INSERT INTO links (id, user_id, original_url, short_url_id, pretty_id) VALUES (?, ?, ?, ?, ?) RETURNING *;
+INSERT INTO links (id, user_id, original_url, short_url_id, pretty_id, redirect_type, destination_host, expires_at, max_clicks, fallback_url, activates_at, domain_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
 
 -- name: PrettifyShortLink :one
 UPDATE links SET pretty_id = ? WHERE id = ? AND user_id = ? RETURNING *;
 
+-- name: UpdateLink :one
+UPDATE links SET
+    original_url = ?,
+    destination_host = ?,
+    pretty_id = ?,
+    redirect_type = ?,
+    expires_at = ?,
+    max_clicks = ?,
+    activates_at = ?,
+    status = ?
+WHERE id = ? AND user_id = ?
+RETURNING *;
+
+-- name: TrashLink :one
+UPDATE links SET status = 'trashed', deleted_at = sqlc.arg(deleted_at)
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id) AND deleted_at IS NULL
+RETURNING *;
+
+-- name: RestoreLink :one
+UPDATE links SET status = sqlc.arg(status), deleted_at = NULL
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id) AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: PurgeLink :exec
+DELETE FROM links WHERE id = ? AND deleted_at IS NOT NULL;
+
+-- name: ListLinksMissingMetadata :many
+SELECT id, original_url FROM links
+WHERE metadata_fetched_at IS NULL AND deleted_at IS NULL
+ORDER BY created_at
+LIMIT sqlc.arg(max_links);
+
+-- name: ListPurgeableLinks :many
+SELECT id, short_url_id, pretty_id, domain_id FROM links
+WHERE deleted_at IS NOT NULL AND deleted_at <= sqlc.arg(deleted_before)
+ORDER BY deleted_at
+LIMIT sqlc.arg(max_links);
+
+-- name: SetLinkMetadata :execrows
+-- Matching the destination too means metadata fetched for a URL that has
+-- since been changed is dropped, and the new one gets fetched instead.
+UPDATE links
+SET title = ?, description = ?, favicon_url = ?, image_url = ?, metadata_fetched_at = CURRENT_TIMESTAMP
+WHERE id = ? AND original_url = ?;
+
+-- name: SetLinkPassword :one
+UPDATE links SET password_hash = ? WHERE id = ? AND user_id = ? RETURNING *;
+
+-- name: SetLinkPreview :one
+UPDATE links SET preview_title = ?, preview_description = ?, preview_image_url = ?
+WHERE id = ? AND user_id = ? RETURNING *;
+
+-- name: SetLinkQueryParams :one
+UPDATE links SET utm_source = ?, utm_medium = ?, utm_campaign = ?, utm_term = ?, utm_content = ?, query_passthrough = ?
+WHERE id = ? AND user_id = ? RETURNING *;
+
 -- name: DeleteShortLink :exec
 DELETE FROM links WHERE user_id = ? AND id = ?;
 
@@ -15,3 +71,110 @@ SELECT * FROM links WHERE user_id = ? AND id = ? LIMIT 1;
 
 -- name: GetShortLinkByShortUrlId :one
 SELECT * FROM links WHERE user_id = ? AND short_url_id = ? LIMIT 1;
+
+-- name: GetLinkByCode :one
+-- A NULL domain_id is the shared domain. The filter matches the expression
+-- idx_links_domain_pretty_id is built on, so aliases are looked up by index.
+SELECT * FROM links
+WHERE COALESCE(domain_id, '') = COALESCE(CAST(sqlc.narg(domain_id) AS TEXT), '')
+  AND (short_url_id = sqlc.arg(code) OR (pretty_id != '' AND pretty_id = sqlc.arg(code) COLLATE NOCASE))
+ORDER BY short_url_id = sqlc.arg(code) DESC
+LIMIT 1;
+
+-- name: IsCodeTaken :one
+-- Codes are only taken, and only stay retired, on the domain they're on.
+SELECT EXISTS(
+    SELECT 1 FROM links
+    WHERE COALESCE(domain_id, '') = COALESCE(CAST(sqlc.narg(domain_id) AS TEXT), '')
+      AND (short_url_id = sqlc.arg(code) OR (pretty_id != '' AND pretty_id = sqlc.arg(code) COLLATE NOCASE))
+    UNION ALL
+    SELECT 1 FROM retired_codes
+    WHERE domain_id = COALESCE(CAST(sqlc.narg(domain_id) AS TEXT), '') AND code = sqlc.arg(code)
+) AS is_taken;
+
+-- name: RetireCode :exec
+INSERT OR IGNORE INTO retired_codes (domain_id, code)
+VALUES (COALESCE(CAST(sqlc.narg(domain_id) AS TEXT), ''), sqlc.arg(code));
+
+-- name: NextCodeSequence :one
+INSERT INTO code_sequences (name, value) VALUES (?, 1)
+ON CONFLICT (name) DO UPDATE SET value = value + 1
+RETURNING value;
+
+-- name: AddLinkClicks :exec
+UPDATE links SET click_count = click_count + sqlc.arg(clicks) WHERE id = sqlc.arg(id);
+
+-- name: SpendLinkClick :execrows
+-- Nothing changes once the link's click budget is used up.
+UPDATE links SET click_count = click_count + 1 WHERE id = ? AND click_count < max_clicks;
+
+-- name: ExpireLinks :execrows
+UPDATE links SET status = 'expired'
+WHERE status = 'active'
+  AND (
+    (expires_at IS NOT NULL AND expires_at <= sqlc.arg(now))
+    OR (max_clicks IS NOT NULL AND click_count >= max_clicks)
+  );
+
+-- Listing queries share their filters and only differ in the sort key.
+-- `created_after`/`created_before` are ULID bounds since IDs sort by creation time.
+-- `tags` is a JSON array of tag names, all of which a link must have.
+
+-- name: ListLinksByCreated :many
+SELECT * FROM links
+WHERE user_id = sqlc.arg(user_id)
+  AND (CASE WHEN sqlc.narg(status) IS NULL THEN status != 'trashed' ELSE status = sqlc.narg(status) END)
+  AND (sqlc.narg(domain) IS NULL OR destination_host = sqlc.narg(domain) OR destination_host LIKE '%.' || sqlc.narg(domain))
+  AND (sqlc.narg(folder_id) IS NULL OR folder_id = sqlc.narg(folder_id))
+  AND (sqlc.narg(tags) IS NULL OR (
+    SELECT COUNT(*) FROM link_tags
+    JOIN tags ON tags.id = link_tags.tag_id
+    WHERE link_tags.link_id = links.id AND tags.name IN (SELECT value FROM json_each(sqlc.narg(tags)))
+  ) = json_array_length(sqlc.narg(tags)))
+  AND (sqlc.narg(created_after) IS NULL OR id >= sqlc.narg(created_after))
+  AND (sqlc.narg(created_before) IS NULL OR id < sqlc.narg(created_before))
+  AND (sqlc.narg(cursor_id) IS NULL OR id < sqlc.narg(cursor_id))
+ORDER BY id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: ListLinksByUpdated :many
+SELECT * FROM links
+WHERE user_id = sqlc.arg(user_id)
+  AND (CASE WHEN sqlc.narg(status) IS NULL THEN status != 'trashed' ELSE status = sqlc.narg(status) END)
+  AND (sqlc.narg(domain) IS NULL OR destination_host = sqlc.narg(domain) OR destination_host LIKE '%.' || sqlc.narg(domain))
+  AND (sqlc.narg(folder_id) IS NULL OR folder_id = sqlc.narg(folder_id))
+  AND (sqlc.narg(tags) IS NULL OR (
+    SELECT COUNT(*) FROM link_tags
+    JOIN tags ON tags.id = link_tags.tag_id
+    WHERE link_tags.link_id = links.id AND tags.name IN (SELECT value FROM json_each(sqlc.narg(tags)))
+  ) = json_array_length(sqlc.narg(tags)))
+  AND (sqlc.narg(created_after) IS NULL OR id >= sqlc.narg(created_after))
+  AND (sqlc.narg(created_before) IS NULL OR id < sqlc.narg(created_before))
+  AND (
+    sqlc.narg(cursor_id) IS NULL
+    OR updated_at < CAST(sqlc.narg(cursor_updated_at) AS TEXT)
+    OR (updated_at = CAST(sqlc.narg(cursor_updated_at) AS TEXT) AND id < sqlc.narg(cursor_id))
+  )
+ORDER BY updated_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: ListLinksByClicks :many
+SELECT * FROM links
+WHERE user_id = sqlc.arg(user_id)
+  AND (CASE WHEN sqlc.narg(status) IS NULL THEN status != 'trashed' ELSE status = sqlc.narg(status) END)
+  AND (sqlc.narg(domain) IS NULL OR destination_host = sqlc.narg(domain) OR destination_host LIKE '%.' || sqlc.narg(domain))
+  AND (sqlc.narg(folder_id) IS NULL OR folder_id = sqlc.narg(folder_id))
+  AND (sqlc.narg(tags) IS NULL OR (
+    SELECT COUNT(*) FROM link_tags
+    JOIN tags ON tags.id = link_tags.tag_id
+    WHERE link_tags.link_id = links.id AND tags.name IN (SELECT value FROM json_each(sqlc.narg(tags)))
+  ) = json_array_length(sqlc.narg(tags)))
+  AND (sqlc.narg(created_after) IS NULL OR id >= sqlc.narg(created_after))
+  AND (sqlc.narg(created_before) IS NULL OR id < sqlc.narg(created_before))
+  AND (
+    sqlc.narg(cursor_id) IS NULL
+    OR click_count < sqlc.narg(cursor_click_count)
+    OR (click_count = sqlc.narg(cursor_click_count) AND id < sqlc.narg(cursor_id))
+  )
+ORDER BY click_count DESC, id DESC
+LIMIT sqlc.arg(page_size);
@@ -0,0 +1,14 @@
+-- name: CreateLinkRevision :one
+INSERT INTO link_revisions (link_id, user_id, action, old_values, new_values) VALUES (?, ?, ?, ?, ?) RETURNING *;
+
+-- name: GetLinkRevision :one
+SELECT * FROM link_revisions WHERE link_id = ? AND id = ? LIMIT 1;
+
+-- name: ListLinkRevisions :many
+SELECT * FROM link_revisions WHERE link_id = ? ORDER BY id DESC;
+
+-- name: DeleteLinkRevisions :exec
+DELETE FROM link_revisions WHERE link_id = ?;
+
+-- name: ListUserLinkRevisions :many
+SELECT * FROM link_revisions WHERE user_id = ? ORDER BY id;
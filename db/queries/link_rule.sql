@@ -0,0 +1,9 @@
+-- name: CreateLinkRule :one
+INSERT INTO link_rules (id, link_id, position, os, device, country, region, timezone, starts_at, ends_at, days, daily_start, daily_end, destination_url)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: ListLinkRules :many
+SELECT * FROM link_rules WHERE link_id = ? ORDER BY position;
+
+-- name: DeleteLinkRules :exec
+DELETE FROM link_rules WHERE link_id = ?;
@@ -0,0 +1,18 @@
+-- name: CreateLinkVariant :one
+INSERT INTO link_variants (id, link_id, destination_url, weight)
+VALUES (?, ?, ?, ?) RETURNING *;
+
+-- name: GetLinkVariant :one
+SELECT * FROM link_variants WHERE id = ? AND link_id = ? LIMIT 1;
+
+-- name: ListLinkVariants :many
+SELECT * FROM link_variants WHERE link_id = ? ORDER BY id;
+
+-- name: SetLinkVariantWeight :one
+UPDATE link_variants SET weight = ? WHERE id = ? AND link_id = ? RETURNING *;
+
+-- name: DeleteLinkVariant :execrows
+DELETE FROM link_variants WHERE id = ? AND link_id = ?;
+
+-- name: DeleteLinkVariants :exec
+DELETE FROM link_variants WHERE link_id = ?;
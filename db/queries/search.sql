@@ -0,0 +1,9 @@
+-- name: SearchLinks :many
+-- Matched terms in the snippet are wrapped in \x02 and \x03, which can't
+-- appear in a URL or a title, so callers can escape the text around them.
+SELECT sqlc.embed(links), CAST(snippet(links_search, -1, char(2), char(3), '…', 12) AS TEXT) AS snippet
+FROM links_search
+JOIN links ON links.id = links_search.link_id
+WHERE links_search MATCH sqlc.arg(query) AND links.user_id = sqlc.arg(user_id) AND links.deleted_at IS NULL
+ORDER BY bm25(links_search, 0.0, 1.0, 4.0, 5.0, 3.0), links.created_at DESC
+LIMIT sqlc.arg(max_results);
@@ -0,0 +1,41 @@
+-- name: CreateTag :one
+INSERT INTO tags (id, user_id, name) VALUES (?, ?, ?) RETURNING *;
+
+-- name: GetTag :one
+SELECT * FROM tags WHERE id = ? AND user_id = ? LIMIT 1;
+
+-- name: GetTagByName :one
+SELECT * FROM tags WHERE user_id = ? AND name = ? LIMIT 1;
+
+-- name: ListTags :many
+SELECT tags.id, tags.name, tags.created_at, COUNT(links.id) AS link_count
+FROM tags
+LEFT JOIN link_tags ON link_tags.tag_id = tags.id
+LEFT JOIN links ON links.id = link_tags.link_id AND links.deleted_at IS NULL
+WHERE tags.user_id = ?
+GROUP BY tags.id
+ORDER BY tags.name;
+
+-- name: RenameTag :one
+UPDATE tags SET name = ? WHERE id = ? AND user_id = ? RETURNING *;
+
+-- name: DeleteTag :exec
+DELETE FROM tags WHERE id = ? AND user_id = ?;
+
+-- name: DeleteTagLinks :exec
+DELETE FROM link_tags WHERE tag_id = ?;
+
+-- name: TagLink :exec
+INSERT OR IGNORE INTO link_tags (link_id, tag_id) VALUES (?, ?);
+
+-- name: UntagLink :exec
+DELETE FROM link_tags WHERE link_id = ? AND tag_id = ?;
+
+-- name: ListLinkTagNames :many
+SELECT tags.name FROM link_tags
+JOIN tags ON tags.id = link_tags.tag_id
+WHERE link_tags.link_id = ?
+ORDER BY tags.name;
+
+-- name: DeleteLinkTags :exec
+DELETE FROM link_tags WHERE link_id = ?;
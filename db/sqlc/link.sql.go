@@ -7,18 +7,41 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
+const addLinkClicks = `-- name: AddLinkClicks :exec
+UPDATE links SET click_count = click_count + ?1 WHERE id = ?2
+`
+
+type AddLinkClicksParams struct {
+	Clicks int64
+	ID     string
+}
+
+func (q *Queries) AddLinkClicks(ctx context.Context, arg AddLinkClicksParams) error {
+	_, err := q.db.ExecContext(ctx, addLinkClicks, arg.Clicks, arg.ID)
+	return err
+}
+
 const createShortLink = `-- name: CreateShortLink :one
-INSERT INTO links (id, user_id, original_url, short_url_id, pretty_id) VALUES (?, ?, ?, ?, ?) RETURNING id, user_id, original_url, short_url_id, pretty_id, updated_at, created_at
+INSERT INTO links (id, user_id, original_url, short_url_id, pretty_id, redirect_type, destination_host, expires_at, max_clicks, fallback_url, activates_at, domain_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, user_id, original_url, short_url_id, pretty_id, updated_at, created_at, redirect_type, status, destination_host, click_count, expires_at, max_clicks, fallback_url, password_hash, deleted_at, folder_id, title, description, favicon_url, image_url, metadata_fetched_at, preview_title, preview_description, preview_image_url, activates_at, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, campaign_id, domain_id
 `
 
 type CreateShortLinkParams struct {
-	ID          string
-	UserID      string
-	OriginalUrl string
-	ShortUrlID  string
-	PrettyID    string
+	ID              string
+	UserID          string
+	OriginalUrl     string
+	ShortUrlID      string
+	PrettyID        string
+	RedirectType    int64
+	DestinationHost string
+	ExpiresAt       sql.NullTime
+	MaxClicks       sql.NullInt64
+	FallbackUrl     string
+	ActivatesAt     sql.NullTime
+	DomainID        sql.NullString
 }
 
 func (q *Queries) CreateShortLink(ctx context.Context, arg CreateShortLinkParams) (Link, error) {
@@ -28,6 +51,13 @@ func (q *Queries) CreateShortLink(ctx context.Context, arg CreateShortLinkParams
 		arg.OriginalUrl,
 		arg.ShortUrlID,
 		arg.PrettyID,
+		arg.RedirectType,
+		arg.DestinationHost,
+		arg.ExpiresAt,
+		arg.MaxClicks,
+		arg.FallbackUrl,
+		arg.ActivatesAt,
+		arg.DomainID,
 	)
 	var i Link
 	err := row.Scan(
@@ -38,6 +68,33 @@ func (q *Queries) CreateShortLink(ctx context.Context, arg CreateShortLinkParams
 		&i.PrettyID,
 		&i.UpdatedAt,
 		&i.CreatedAt,
+		&i.RedirectType,
+		&i.Status,
+		&i.DestinationHost,
+		&i.ClickCount,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.FallbackUrl,
+		&i.PasswordHash,
+		&i.DeletedAt,
+		&i.FolderID,
+		&i.Title,
+		&i.Description,
+		&i.FaviconUrl,
+		&i.ImageUrl,
+		&i.MetadataFetchedAt,
+		&i.PreviewTitle,
+		&i.PreviewDescription,
+		&i.PreviewImageUrl,
+		&i.ActivatesAt,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.CampaignID,
+		&i.DomainID,
 	)
 	return i, err
 }
@@ -56,8 +113,82 @@ func (q *Queries) DeleteShortLink(ctx context.Context, arg DeleteShortLinkParams
 	return err
 }
 
+const expireLinks = `-- name: ExpireLinks :execrows
+UPDATE links SET status = 'expired'
+WHERE status = 'active'
+  AND (
+    (expires_at IS NOT NULL AND expires_at <= ?1)
+    OR (max_clicks IS NOT NULL AND click_count >= max_clicks)
+  )
+`
+
+func (q *Queries) ExpireLinks(ctx context.Context, now time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, expireLinks, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getLinkByCode = `-- name: GetLinkByCode :one
+SELECT id, user_id, original_url, short_url_id, pretty_id, updated_at, created_at, redirect_type, status, destination_host, click_count, expires_at, max_clicks, fallback_url, password_hash, deleted_at, folder_id, title, description, favicon_url, image_url, metadata_fetched_at, preview_title, preview_description, preview_image_url, activates_at, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, campaign_id, domain_id FROM links
+WHERE COALESCE(domain_id, '') = COALESCE(CAST(?1 AS TEXT), '')
+  AND (short_url_id = ?2 OR (pretty_id != '' AND pretty_id = ?2 COLLATE NOCASE))
+ORDER BY short_url_id = ?2 DESC
+LIMIT 1
+`
+
+type GetLinkByCodeParams struct {
+	DomainID sql.NullString
+	Code     string
+}
+
+// A NULL domain_id is the shared domain. The filter matches the expression
+// idx_links_domain_pretty_id is built on, so aliases are looked up by index.
+func (q *Queries) GetLinkByCode(ctx context.Context, arg GetLinkByCodeParams) (Link, error) {
+	row := q.db.QueryRowContext(ctx, getLinkByCode, arg.DomainID, arg.Code)
+	var i Link
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.OriginalUrl,
+		&i.ShortUrlID,
+		&i.PrettyID,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.RedirectType,
+		&i.Status,
+		&i.DestinationHost,
+		&i.ClickCount,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.FallbackUrl,
+		&i.PasswordHash,
+		&i.DeletedAt,
+		&i.FolderID,
+		&i.Title,
+		&i.Description,
+		&i.FaviconUrl,
+		&i.ImageUrl,
+		&i.MetadataFetchedAt,
+		&i.PreviewTitle,
+		&i.PreviewDescription,
+		&i.PreviewImageUrl,
+		&i.ActivatesAt,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.CampaignID,
+		&i.DomainID,
+	)
+	return i, err
+}
+
 const getShortLinkById = `-- name: GetShortLinkById :one
-SELECT id, user_id, original_url, short_url_id, pretty_id, updated_at, created_at FROM links WHERE user_id = ? AND id = ? LIMIT 1
+SELECT id, user_id, original_url, short_url_id, pretty_id, updated_at, created_at, redirect_type, status, destination_host, click_count, expires_at, max_clicks, fallback_url, password_hash, deleted_at, folder_id, title, description, favicon_url, image_url, metadata_fetched_at, preview_title, preview_description, preview_image_url, activates_at, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, campaign_id, domain_id FROM links WHERE user_id = ? AND id = ? LIMIT 1
 `
 
 type GetShortLinkByIdParams struct {
@@ -76,12 +207,39 @@ func (q *Queries) GetShortLinkById(ctx context.Context, arg GetShortLinkByIdPara
 		&i.PrettyID,
 		&i.UpdatedAt,
 		&i.CreatedAt,
+		&i.RedirectType,
+		&i.Status,
+		&i.DestinationHost,
+		&i.ClickCount,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.FallbackUrl,
+		&i.PasswordHash,
+		&i.DeletedAt,
+		&i.FolderID,
+		&i.Title,
+		&i.Description,
+		&i.FaviconUrl,
+		&i.ImageUrl,
+		&i.MetadataFetchedAt,
+		&i.PreviewTitle,
+		&i.PreviewDescription,
+		&i.PreviewImageUrl,
+		&i.ActivatesAt,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.CampaignID,
+		&i.DomainID,
 	)
 	return i, err
 }
 
 const getShortLinkByShortUrlId = `-- name: GetShortLinkByShortUrlId :one
-SELECT id, user_id, original_url, short_url_id, pretty_id, updated_at, created_at FROM links WHERE user_id = ? AND short_url_id = ? LIMIT 1
+SELECT id, user_id, original_url, short_url_id, pretty_id, updated_at, created_at, redirect_type, status, destination_host, click_count, expires_at, max_clicks, fallback_url, password_hash, deleted_at, folder_id, title, description, favicon_url, image_url, metadata_fetched_at, preview_title, preview_description, preview_image_url, activates_at, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, campaign_id, domain_id FROM links WHERE user_id = ? AND short_url_id = ? LIMIT 1
 `
 
 type GetShortLinkByShortUrlIdParams struct {
@@ -100,12 +258,39 @@ func (q *Queries) GetShortLinkByShortUrlId(ctx context.Context, arg GetShortLink
 		&i.PrettyID,
 		&i.UpdatedAt,
 		&i.CreatedAt,
+		&i.RedirectType,
+		&i.Status,
+		&i.DestinationHost,
+		&i.ClickCount,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.FallbackUrl,
+		&i.PasswordHash,
+		&i.DeletedAt,
+		&i.FolderID,
+		&i.Title,
+		&i.Description,
+		&i.FaviconUrl,
+		&i.ImageUrl,
+		&i.MetadataFetchedAt,
+		&i.PreviewTitle,
+		&i.PreviewDescription,
+		&i.PreviewImageUrl,
+		&i.ActivatesAt,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.CampaignID,
+		&i.DomainID,
 	)
 	return i, err
 }
 
 const getShortLinks = `-- name: GetShortLinks :many
-SELECT id, user_id, original_url, short_url_id, pretty_id, updated_at, created_at FROM links WHERE user_id = ?
+SELECT id, user_id, original_url, short_url_id, pretty_id, updated_at, created_at, redirect_type, status, destination_host, click_count, expires_at, max_clicks, fallback_url, password_hash, deleted_at, folder_id, title, description, favicon_url, image_url, metadata_fetched_at, preview_title, preview_description, preview_image_url, activates_at, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, campaign_id, domain_id FROM links WHERE user_id = ?
 `
 
 func (q *Queries) GetShortLinks(ctx context.Context, userID string) ([]Link, error) {
@@ -125,6 +310,445 @@ func (q *Queries) GetShortLinks(ctx context.Context, userID string) ([]Link, err
 			&i.PrettyID,
 			&i.UpdatedAt,
 			&i.CreatedAt,
+			&i.RedirectType,
+			&i.Status,
+			&i.DestinationHost,
+			&i.ClickCount,
+			&i.ExpiresAt,
+			&i.MaxClicks,
+			&i.FallbackUrl,
+			&i.PasswordHash,
+			&i.DeletedAt,
+			&i.FolderID,
+			&i.Title,
+			&i.Description,
+			&i.FaviconUrl,
+			&i.ImageUrl,
+			&i.MetadataFetchedAt,
+			&i.PreviewTitle,
+			&i.PreviewDescription,
+			&i.PreviewImageUrl,
+			&i.ActivatesAt,
+			&i.UtmSource,
+			&i.UtmMedium,
+			&i.UtmCampaign,
+			&i.UtmTerm,
+			&i.UtmContent,
+			&i.QueryPassthrough,
+			&i.CampaignID,
+			&i.DomainID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const isCodeTaken = `-- name: IsCodeTaken :one
+SELECT EXISTS(
+    SELECT 1 FROM links
+    WHERE COALESCE(domain_id, '') = COALESCE(CAST(?1 AS TEXT), '')
+      AND (short_url_id = ?2 OR (pretty_id != '' AND pretty_id = ?2 COLLATE NOCASE))
+    UNION ALL
+    SELECT 1 FROM retired_codes
+    WHERE domain_id = COALESCE(CAST(?1 AS TEXT), '') AND code = ?2
+) AS is_taken
+`
+
+type IsCodeTakenParams struct {
+	DomainID sql.NullString
+	Code     string
+}
+
+// Codes are only taken, and only stay retired, on the domain they're on.
+func (q *Queries) IsCodeTaken(ctx context.Context, arg IsCodeTakenParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, isCodeTaken, arg.DomainID, arg.Code)
+	var is_taken int64
+	err := row.Scan(&is_taken)
+	return is_taken, err
+}
+
+const listLinksByClicks = `-- name: ListLinksByClicks :many
+SELECT id, user_id, original_url, short_url_id, pretty_id, updated_at, created_at, redirect_type, status, destination_host, click_count, expires_at, max_clicks, fallback_url, password_hash, deleted_at, folder_id, title, description, favicon_url, image_url, metadata_fetched_at, preview_title, preview_description, preview_image_url, activates_at, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, campaign_id, domain_id FROM links
+WHERE user_id = ?1
+  AND (CASE WHEN ?2 IS NULL THEN status != 'trashed' ELSE status = ?2 END)
+  AND (?3 IS NULL OR destination_host = ?3 OR destination_host LIKE '%.' || ?3)
+  AND (?4 IS NULL OR folder_id = ?4)
+  AND (?5 IS NULL OR (
+    SELECT COUNT(*) FROM link_tags
+    JOIN tags ON tags.id = link_tags.tag_id
+    WHERE link_tags.link_id = links.id AND tags.name IN (SELECT value FROM json_each(?5))
+  ) = json_array_length(?5))
+  AND (?6 IS NULL OR id >= ?6)
+  AND (?7 IS NULL OR id < ?7)
+  AND (
+    ?8 IS NULL
+    OR click_count < ?9
+    OR (click_count = ?9 AND id < ?8)
+  )
+ORDER BY click_count DESC, id DESC
+LIMIT ?10
+`
+
+type ListLinksByClicksParams struct {
+	UserID           string
+	Status           sql.NullString
+	Domain           sql.NullString
+	FolderID         sql.NullString
+	Tags             sql.NullString
+	CreatedAfter     sql.NullString
+	CreatedBefore    sql.NullString
+	CursorID         sql.NullString
+	CursorClickCount sql.NullInt64
+	PageSize         int64
+}
+
+func (q *Queries) ListLinksByClicks(ctx context.Context, arg ListLinksByClicksParams) ([]Link, error) {
+	rows, err := q.db.QueryContext(ctx, listLinksByClicks,
+		arg.UserID,
+		arg.Status,
+		arg.Domain,
+		arg.FolderID,
+		arg.Tags,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.CursorID,
+		arg.CursorClickCount,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Link
+	for rows.Next() {
+		var i Link
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.OriginalUrl,
+			&i.ShortUrlID,
+			&i.PrettyID,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.RedirectType,
+			&i.Status,
+			&i.DestinationHost,
+			&i.ClickCount,
+			&i.ExpiresAt,
+			&i.MaxClicks,
+			&i.FallbackUrl,
+			&i.PasswordHash,
+			&i.DeletedAt,
+			&i.FolderID,
+			&i.Title,
+			&i.Description,
+			&i.FaviconUrl,
+			&i.ImageUrl,
+			&i.MetadataFetchedAt,
+			&i.PreviewTitle,
+			&i.PreviewDescription,
+			&i.PreviewImageUrl,
+			&i.ActivatesAt,
+			&i.UtmSource,
+			&i.UtmMedium,
+			&i.UtmCampaign,
+			&i.UtmTerm,
+			&i.UtmContent,
+			&i.QueryPassthrough,
+			&i.CampaignID,
+			&i.DomainID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLinksByCreated = `-- name: ListLinksByCreated :many
+SELECT id, user_id, original_url, short_url_id, pretty_id, updated_at, created_at, redirect_type, status, destination_host, click_count, expires_at, max_clicks, fallback_url, password_hash, deleted_at, folder_id, title, description, favicon_url, image_url, metadata_fetched_at, preview_title, preview_description, preview_image_url, activates_at, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, campaign_id, domain_id FROM links
+WHERE user_id = ?1
+  AND (CASE WHEN ?2 IS NULL THEN status != 'trashed' ELSE status = ?2 END)
+  AND (?3 IS NULL OR destination_host = ?3 OR destination_host LIKE '%.' || ?3)
+  AND (?4 IS NULL OR folder_id = ?4)
+  AND (?5 IS NULL OR (
+    SELECT COUNT(*) FROM link_tags
+    JOIN tags ON tags.id = link_tags.tag_id
+    WHERE link_tags.link_id = links.id AND tags.name IN (SELECT value FROM json_each(?5))
+  ) = json_array_length(?5))
+  AND (?6 IS NULL OR id >= ?6)
+  AND (?7 IS NULL OR id < ?7)
+  AND (?8 IS NULL OR id < ?8)
+ORDER BY id DESC
+LIMIT ?9
+`
+
+type ListLinksByCreatedParams struct {
+	UserID        string
+	Status        sql.NullString
+	Domain        sql.NullString
+	FolderID      sql.NullString
+	Tags          sql.NullString
+	CreatedAfter  sql.NullString
+	CreatedBefore sql.NullString
+	CursorID      sql.NullString
+	PageSize      int64
+}
+
+func (q *Queries) ListLinksByCreated(ctx context.Context, arg ListLinksByCreatedParams) ([]Link, error) {
+	rows, err := q.db.QueryContext(ctx, listLinksByCreated,
+		arg.UserID,
+		arg.Status,
+		arg.Domain,
+		arg.FolderID,
+		arg.Tags,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Link
+	for rows.Next() {
+		var i Link
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.OriginalUrl,
+			&i.ShortUrlID,
+			&i.PrettyID,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.RedirectType,
+			&i.Status,
+			&i.DestinationHost,
+			&i.ClickCount,
+			&i.ExpiresAt,
+			&i.MaxClicks,
+			&i.FallbackUrl,
+			&i.PasswordHash,
+			&i.DeletedAt,
+			&i.FolderID,
+			&i.Title,
+			&i.Description,
+			&i.FaviconUrl,
+			&i.ImageUrl,
+			&i.MetadataFetchedAt,
+			&i.PreviewTitle,
+			&i.PreviewDescription,
+			&i.PreviewImageUrl,
+			&i.ActivatesAt,
+			&i.UtmSource,
+			&i.UtmMedium,
+			&i.UtmCampaign,
+			&i.UtmTerm,
+			&i.UtmContent,
+			&i.QueryPassthrough,
+			&i.CampaignID,
+			&i.DomainID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLinksByUpdated = `-- name: ListLinksByUpdated :many
+SELECT id, user_id, original_url, short_url_id, pretty_id, updated_at, created_at, redirect_type, status, destination_host, click_count, expires_at, max_clicks, fallback_url, password_hash, deleted_at, folder_id, title, description, favicon_url, image_url, metadata_fetched_at, preview_title, preview_description, preview_image_url, activates_at, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, campaign_id, domain_id FROM links
+WHERE user_id = ?1
+  AND (CASE WHEN ?2 IS NULL THEN status != 'trashed' ELSE status = ?2 END)
+  AND (?3 IS NULL OR destination_host = ?3 OR destination_host LIKE '%.' || ?3)
+  AND (?4 IS NULL OR folder_id = ?4)
+  AND (?5 IS NULL OR (
+    SELECT COUNT(*) FROM link_tags
+    JOIN tags ON tags.id = link_tags.tag_id
+    WHERE link_tags.link_id = links.id AND tags.name IN (SELECT value FROM json_each(?5))
+  ) = json_array_length(?5))
+  AND (?6 IS NULL OR id >= ?6)
+  AND (?7 IS NULL OR id < ?7)
+  AND (
+    ?8 IS NULL
+    OR updated_at < CAST(?9 AS TEXT)
+    OR (updated_at = CAST(?9 AS TEXT) AND id < ?8)
+  )
+ORDER BY updated_at DESC, id DESC
+LIMIT ?10
+`
+
+type ListLinksByUpdatedParams struct {
+	UserID          string
+	Status          sql.NullString
+	Domain          sql.NullString
+	FolderID        sql.NullString
+	Tags            sql.NullString
+	CreatedAfter    sql.NullString
+	CreatedBefore   sql.NullString
+	CursorID        sql.NullString
+	CursorUpdatedAt sql.NullString
+	PageSize        int64
+}
+
+func (q *Queries) ListLinksByUpdated(ctx context.Context, arg ListLinksByUpdatedParams) ([]Link, error) {
+	rows, err := q.db.QueryContext(ctx, listLinksByUpdated,
+		arg.UserID,
+		arg.Status,
+		arg.Domain,
+		arg.FolderID,
+		arg.Tags,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.CursorID,
+		arg.CursorUpdatedAt,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Link
+	for rows.Next() {
+		var i Link
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.OriginalUrl,
+			&i.ShortUrlID,
+			&i.PrettyID,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.RedirectType,
+			&i.Status,
+			&i.DestinationHost,
+			&i.ClickCount,
+			&i.ExpiresAt,
+			&i.MaxClicks,
+			&i.FallbackUrl,
+			&i.PasswordHash,
+			&i.DeletedAt,
+			&i.FolderID,
+			&i.Title,
+			&i.Description,
+			&i.FaviconUrl,
+			&i.ImageUrl,
+			&i.MetadataFetchedAt,
+			&i.PreviewTitle,
+			&i.PreviewDescription,
+			&i.PreviewImageUrl,
+			&i.ActivatesAt,
+			&i.UtmSource,
+			&i.UtmMedium,
+			&i.UtmCampaign,
+			&i.UtmTerm,
+			&i.UtmContent,
+			&i.QueryPassthrough,
+			&i.CampaignID,
+			&i.DomainID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLinksMissingMetadata = `-- name: ListLinksMissingMetadata :many
+SELECT id, original_url FROM links
+WHERE metadata_fetched_at IS NULL AND deleted_at IS NULL
+ORDER BY created_at
+LIMIT ?1
+`
+
+type ListLinksMissingMetadataRow struct {
+	ID          string
+	OriginalUrl string
+}
+
+func (q *Queries) ListLinksMissingMetadata(ctx context.Context, maxLinks int64) ([]ListLinksMissingMetadataRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLinksMissingMetadata, maxLinks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLinksMissingMetadataRow
+	for rows.Next() {
+		var i ListLinksMissingMetadataRow
+		if err := rows.Scan(&i.ID, &i.OriginalUrl); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPurgeableLinks = `-- name: ListPurgeableLinks :many
+SELECT id, short_url_id, pretty_id, domain_id FROM links
+WHERE deleted_at IS NOT NULL AND deleted_at <= ?1
+ORDER BY deleted_at
+LIMIT ?2
+`
+
+type ListPurgeableLinksParams struct {
+	DeletedBefore time.Time
+	MaxLinks      int64
+}
+
+type ListPurgeableLinksRow struct {
+	ID         string
+	ShortUrlID string
+	PrettyID   string
+	DomainID   sql.NullString
+}
+
+func (q *Queries) ListPurgeableLinks(ctx context.Context, arg ListPurgeableLinksParams) ([]ListPurgeableLinksRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPurgeableLinks, arg.DeletedBefore, arg.MaxLinks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPurgeableLinksRow
+	for rows.Next() {
+		var i ListPurgeableLinksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ShortUrlID,
+			&i.PrettyID,
+			&i.DomainID,
 		); err != nil {
 			return nil, err
 		}
@@ -139,8 +763,21 @@ func (q *Queries) GetShortLinks(ctx context.Context, userID string) ([]Link, err
 	return items, nil
 }
 
+const nextCodeSequence = `-- name: NextCodeSequence :one
+INSERT INTO code_sequences (name, value) VALUES (?, 1)
+ON CONFLICT (name) DO UPDATE SET value = value + 1
+RETURNING value
+`
+
+func (q *Queries) NextCodeSequence(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, nextCodeSequence, name)
+	var value int64
+	err := row.Scan(&value)
+	return value, err
+}
+
 const prettifyShortLink = `-- name: PrettifyShortLink :one
-UPDATE links SET pretty_id = ? WHERE id = ? AND user_id = ? RETURNING id, user_id, original_url, short_url_id, pretty_id, updated_at, created_at
+UPDATE links SET pretty_id = ? WHERE id = ? AND user_id = ? RETURNING id, user_id, original_url, short_url_id, pretty_id, updated_at, created_at, redirect_type, status, destination_host, click_count, expires_at, max_clicks, fallback_url, password_hash, deleted_at, folder_id, title, description, favicon_url, image_url, metadata_fetched_at, preview_title, preview_description, preview_image_url, activates_at, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, campaign_id, domain_id
 `
 
 type PrettifyShortLinkParams struct {
@@ -160,6 +797,470 @@ func (q *Queries) PrettifyShortLink(ctx context.Context, arg PrettifyShortLinkPa
 		&i.PrettyID,
 		&i.UpdatedAt,
 		&i.CreatedAt,
+		&i.RedirectType,
+		&i.Status,
+		&i.DestinationHost,
+		&i.ClickCount,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.FallbackUrl,
+		&i.PasswordHash,
+		&i.DeletedAt,
+		&i.FolderID,
+		&i.Title,
+		&i.Description,
+		&i.FaviconUrl,
+		&i.ImageUrl,
+		&i.MetadataFetchedAt,
+		&i.PreviewTitle,
+		&i.PreviewDescription,
+		&i.PreviewImageUrl,
+		&i.ActivatesAt,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.CampaignID,
+		&i.DomainID,
+	)
+	return i, err
+}
+
+const purgeLink = `-- name: PurgeLink :exec
+DELETE FROM links WHERE id = ? AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) PurgeLink(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, purgeLink, id)
+	return err
+}
+
+const restoreLink = `-- name: RestoreLink :one
+UPDATE links SET status = ?1, deleted_at = NULL
+WHERE id = ?2 AND user_id = ?3 AND deleted_at IS NOT NULL
+RETURNING id, user_id, original_url, short_url_id, pretty_id, updated_at, created_at, redirect_type, status, destination_host, click_count, expires_at, max_clicks, fallback_url, password_hash, deleted_at, folder_id, title, description, favicon_url, image_url, metadata_fetched_at, preview_title, preview_description, preview_image_url, activates_at, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, campaign_id, domain_id
+`
+
+type RestoreLinkParams struct {
+	Status string
+	ID     string
+	UserID string
+}
+
+func (q *Queries) RestoreLink(ctx context.Context, arg RestoreLinkParams) (Link, error) {
+	row := q.db.QueryRowContext(ctx, restoreLink, arg.Status, arg.ID, arg.UserID)
+	var i Link
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.OriginalUrl,
+		&i.ShortUrlID,
+		&i.PrettyID,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.RedirectType,
+		&i.Status,
+		&i.DestinationHost,
+		&i.ClickCount,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.FallbackUrl,
+		&i.PasswordHash,
+		&i.DeletedAt,
+		&i.FolderID,
+		&i.Title,
+		&i.Description,
+		&i.FaviconUrl,
+		&i.ImageUrl,
+		&i.MetadataFetchedAt,
+		&i.PreviewTitle,
+		&i.PreviewDescription,
+		&i.PreviewImageUrl,
+		&i.ActivatesAt,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.CampaignID,
+		&i.DomainID,
+	)
+	return i, err
+}
+
+const retireCode = `-- name: RetireCode :exec
+INSERT OR IGNORE INTO retired_codes (domain_id, code)
+VALUES (COALESCE(CAST(?1 AS TEXT), ''), ?2)
+`
+
+type RetireCodeParams struct {
+	DomainID sql.NullString
+	Code     string
+}
+
+func (q *Queries) RetireCode(ctx context.Context, arg RetireCodeParams) error {
+	_, err := q.db.ExecContext(ctx, retireCode, arg.DomainID, arg.Code)
+	return err
+}
+
+const setLinkMetadata = `-- name: SetLinkMetadata :execrows
+UPDATE links
+SET title = ?, description = ?, favicon_url = ?, image_url = ?, metadata_fetched_at = CURRENT_TIMESTAMP
+WHERE id = ? AND original_url = ?
+`
+
+type SetLinkMetadataParams struct {
+	Title       sql.NullString
+	Description sql.NullString
+	FaviconUrl  sql.NullString
+	ImageUrl    sql.NullString
+	ID          string
+	OriginalUrl string
+}
+
+// Matching the destination too means metadata fetched for a URL that has
+// since been changed is dropped, and the new one gets fetched instead.
+func (q *Queries) SetLinkMetadata(ctx context.Context, arg SetLinkMetadataParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setLinkMetadata,
+		arg.Title,
+		arg.Description,
+		arg.FaviconUrl,
+		arg.ImageUrl,
+		arg.ID,
+		arg.OriginalUrl,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setLinkPassword = `-- name: SetLinkPassword :one
+UPDATE links SET password_hash = ? WHERE id = ? AND user_id = ? RETURNING id, user_id, original_url, short_url_id, pretty_id, updated_at, created_at, redirect_type, status, destination_host, click_count, expires_at, max_clicks, fallback_url, password_hash, deleted_at, folder_id, title, description, favicon_url, image_url, metadata_fetched_at, preview_title, preview_description, preview_image_url, activates_at, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, campaign_id, domain_id
+`
+
+type SetLinkPasswordParams struct {
+	PasswordHash string
+	ID           string
+	UserID       string
+}
+
+func (q *Queries) SetLinkPassword(ctx context.Context, arg SetLinkPasswordParams) (Link, error) {
+	row := q.db.QueryRowContext(ctx, setLinkPassword, arg.PasswordHash, arg.ID, arg.UserID)
+	var i Link
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.OriginalUrl,
+		&i.ShortUrlID,
+		&i.PrettyID,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.RedirectType,
+		&i.Status,
+		&i.DestinationHost,
+		&i.ClickCount,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.FallbackUrl,
+		&i.PasswordHash,
+		&i.DeletedAt,
+		&i.FolderID,
+		&i.Title,
+		&i.Description,
+		&i.FaviconUrl,
+		&i.ImageUrl,
+		&i.MetadataFetchedAt,
+		&i.PreviewTitle,
+		&i.PreviewDescription,
+		&i.PreviewImageUrl,
+		&i.ActivatesAt,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.CampaignID,
+		&i.DomainID,
+	)
+	return i, err
+}
+
+const setLinkPreview = `-- name: SetLinkPreview :one
+UPDATE links SET preview_title = ?, preview_description = ?, preview_image_url = ?
+WHERE id = ? AND user_id = ? RETURNING id, user_id, original_url, short_url_id, pretty_id, updated_at, created_at, redirect_type, status, destination_host, click_count, expires_at, max_clicks, fallback_url, password_hash, deleted_at, folder_id, title, description, favicon_url, image_url, metadata_fetched_at, preview_title, preview_description, preview_image_url, activates_at, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, campaign_id, domain_id
+`
+
+type SetLinkPreviewParams struct {
+	PreviewTitle       sql.NullString
+	PreviewDescription sql.NullString
+	PreviewImageUrl    sql.NullString
+	ID                 string
+	UserID             string
+}
+
+func (q *Queries) SetLinkPreview(ctx context.Context, arg SetLinkPreviewParams) (Link, error) {
+	row := q.db.QueryRowContext(ctx, setLinkPreview,
+		arg.PreviewTitle,
+		arg.PreviewDescription,
+		arg.PreviewImageUrl,
+		arg.ID,
+		arg.UserID,
+	)
+	var i Link
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.OriginalUrl,
+		&i.ShortUrlID,
+		&i.PrettyID,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.RedirectType,
+		&i.Status,
+		&i.DestinationHost,
+		&i.ClickCount,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.FallbackUrl,
+		&i.PasswordHash,
+		&i.DeletedAt,
+		&i.FolderID,
+		&i.Title,
+		&i.Description,
+		&i.FaviconUrl,
+		&i.ImageUrl,
+		&i.MetadataFetchedAt,
+		&i.PreviewTitle,
+		&i.PreviewDescription,
+		&i.PreviewImageUrl,
+		&i.ActivatesAt,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.CampaignID,
+		&i.DomainID,
+	)
+	return i, err
+}
+
+const setLinkQueryParams = `-- name: SetLinkQueryParams :one
+UPDATE links SET utm_source = ?, utm_medium = ?, utm_campaign = ?, utm_term = ?, utm_content = ?, query_passthrough = ?
+WHERE id = ? AND user_id = ? RETURNING id, user_id, original_url, short_url_id, pretty_id, updated_at, created_at, redirect_type, status, destination_host, click_count, expires_at, max_clicks, fallback_url, password_hash, deleted_at, folder_id, title, description, favicon_url, image_url, metadata_fetched_at, preview_title, preview_description, preview_image_url, activates_at, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, campaign_id, domain_id
+`
+
+type SetLinkQueryParamsParams struct {
+	UtmSource        sql.NullString
+	UtmMedium        sql.NullString
+	UtmCampaign      sql.NullString
+	UtmTerm          sql.NullString
+	UtmContent       sql.NullString
+	QueryPassthrough string
+	ID               string
+	UserID           string
+}
+
+func (q *Queries) SetLinkQueryParams(ctx context.Context, arg SetLinkQueryParamsParams) (Link, error) {
+	row := q.db.QueryRowContext(ctx, setLinkQueryParams,
+		arg.UtmSource,
+		arg.UtmMedium,
+		arg.UtmCampaign,
+		arg.UtmTerm,
+		arg.UtmContent,
+		arg.QueryPassthrough,
+		arg.ID,
+		arg.UserID,
+	)
+	var i Link
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.OriginalUrl,
+		&i.ShortUrlID,
+		&i.PrettyID,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.RedirectType,
+		&i.Status,
+		&i.DestinationHost,
+		&i.ClickCount,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.FallbackUrl,
+		&i.PasswordHash,
+		&i.DeletedAt,
+		&i.FolderID,
+		&i.Title,
+		&i.Description,
+		&i.FaviconUrl,
+		&i.ImageUrl,
+		&i.MetadataFetchedAt,
+		&i.PreviewTitle,
+		&i.PreviewDescription,
+		&i.PreviewImageUrl,
+		&i.ActivatesAt,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.CampaignID,
+		&i.DomainID,
+	)
+	return i, err
+}
+
+const spendLinkClick = `-- name: SpendLinkClick :execrows
+UPDATE links SET click_count = click_count + 1 WHERE id = ? AND click_count < max_clicks
+`
+
+// Nothing changes once the link's click budget is used up.
+func (q *Queries) SpendLinkClick(ctx context.Context, id string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, spendLinkClick, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const trashLink = `-- name: TrashLink :one
+UPDATE links SET status = 'trashed', deleted_at = ?1
+WHERE id = ?2 AND user_id = ?3 AND deleted_at IS NULL
+RETURNING id, user_id, original_url, short_url_id, pretty_id, updated_at, created_at, redirect_type, status, destination_host, click_count, expires_at, max_clicks, fallback_url, password_hash, deleted_at, folder_id, title, description, favicon_url, image_url, metadata_fetched_at, preview_title, preview_description, preview_image_url, activates_at, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, campaign_id, domain_id
+`
+
+type TrashLinkParams struct {
+	DeletedAt sql.NullTime
+	ID        string
+	UserID    string
+}
+
+func (q *Queries) TrashLink(ctx context.Context, arg TrashLinkParams) (Link, error) {
+	row := q.db.QueryRowContext(ctx, trashLink, arg.DeletedAt, arg.ID, arg.UserID)
+	var i Link
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.OriginalUrl,
+		&i.ShortUrlID,
+		&i.PrettyID,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.RedirectType,
+		&i.Status,
+		&i.DestinationHost,
+		&i.ClickCount,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.FallbackUrl,
+		&i.PasswordHash,
+		&i.DeletedAt,
+		&i.FolderID,
+		&i.Title,
+		&i.Description,
+		&i.FaviconUrl,
+		&i.ImageUrl,
+		&i.MetadataFetchedAt,
+		&i.PreviewTitle,
+		&i.PreviewDescription,
+		&i.PreviewImageUrl,
+		&i.ActivatesAt,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.CampaignID,
+		&i.DomainID,
+	)
+	return i, err
+}
+
+const updateLink = `-- name: UpdateLink :one
+UPDATE links SET
+    original_url = ?,
+    destination_host = ?,
+    pretty_id = ?,
+    redirect_type = ?,
+    expires_at = ?,
+    max_clicks = ?,
+    activates_at = ?,
+    status = ?
+WHERE id = ? AND user_id = ?
+RETURNING id, user_id, original_url, short_url_id, pretty_id, updated_at, created_at, redirect_type, status, destination_host, click_count, expires_at, max_clicks, fallback_url, password_hash, deleted_at, folder_id, title, description, favicon_url, image_url, metadata_fetched_at, preview_title, preview_description, preview_image_url, activates_at, utm_source, utm_medium, utm_campaign, utm_term, utm_content, query_passthrough, campaign_id, domain_id
+`
+
+type UpdateLinkParams struct {
+	OriginalUrl     string
+	DestinationHost string
+	PrettyID        string
+	RedirectType    int64
+	ExpiresAt       sql.NullTime
+	MaxClicks       sql.NullInt64
+	ActivatesAt     sql.NullTime
+	Status          string
+	ID              string
+	UserID          string
+}
+
+func (q *Queries) UpdateLink(ctx context.Context, arg UpdateLinkParams) (Link, error) {
+	row := q.db.QueryRowContext(ctx, updateLink,
+		arg.OriginalUrl,
+		arg.DestinationHost,
+		arg.PrettyID,
+		arg.RedirectType,
+		arg.ExpiresAt,
+		arg.MaxClicks,
+		arg.ActivatesAt,
+		arg.Status,
+		arg.ID,
+		arg.UserID,
+	)
+	var i Link
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.OriginalUrl,
+		&i.ShortUrlID,
+		&i.PrettyID,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.RedirectType,
+		&i.Status,
+		&i.DestinationHost,
+		&i.ClickCount,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.FallbackUrl,
+		&i.PasswordHash,
+		&i.DeletedAt,
+		&i.FolderID,
+		&i.Title,
+		&i.Description,
+		&i.FaviconUrl,
+		&i.ImageUrl,
+		&i.MetadataFetchedAt,
+		&i.PreviewTitle,
+		&i.PreviewDescription,
+		&i.PreviewImageUrl,
+		&i.ActivatesAt,
+		&i.UtmSource,
+		&i.UtmMedium,
+		&i.UtmCampaign,
+		&i.UtmTerm,
+		&i.UtmContent,
+		&i.QueryPassthrough,
+		&i.CampaignID,
+		&i.DomainID,
 	)
 	return i, err
 }
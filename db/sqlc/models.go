@@ -9,6 +9,82 @@ import (
 	"time"
 )
 
+type Campaign struct {
+	ID          string
+	UserID      string
+	Name        string
+	StartsAt    sql.NullTime
+	EndsAt      sql.NullTime
+	UtmSource   sql.NullString
+	UtmMedium   sql.NullString
+	UtmCampaign sql.NullString
+	UtmTerm     sql.NullString
+	UtmContent  sql.NullString
+	UpdatedAt   time.Time
+	CreatedAt   time.Time
+}
+
+type Click struct {
+	ID           int64
+	LinkID       string
+	ClickedAt    time.Time
+	Referrer     string
+	UserAgent    string
+	Device       string
+	Browser      string
+	Os           string
+	VisitorHash  string
+	ReferrerHost string
+	Country      string
+	City         string
+	VariantID    string
+}
+
+type ClickDailyRollup struct {
+	LinkID         string
+	Day            string
+	Clicks         int64
+	UniqueVisitors int64
+}
+
+type ClickDimensionRollup struct {
+	LinkID    string
+	Day       string
+	Dimension string
+	Value     string
+	Clicks    int64
+}
+
+type ClickHourlyRollup struct {
+	LinkID string
+	Hour   string
+	Clicks int64
+}
+
+type CodeSequence struct {
+	Name  string
+	Value int64
+}
+
+type DataExport struct {
+	JobID     string
+	UserID    string
+	Path      string
+	Size      int64
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type Domain struct {
+	ID                string
+	UserID            string
+	Hostname          string
+	VerificationToken string
+	VerifiedAt        sql.NullTime
+	UpdatedAt         time.Time
+	CreatedAt         time.Time
+}
+
 type EmailVerification struct {
 	ID         int64
 	UserID     string
@@ -19,14 +95,108 @@ type EmailVerification struct {
 	VerifiedAt sql.NullTime
 }
 
+type Folder struct {
+	ID        string
+	UserID    string
+	ParentID  sql.NullString
+	Name      string
+	UpdatedAt time.Time
+	CreatedAt time.Time
+}
+
+type Job struct {
+	ID         string
+	UserID     string
+	Kind       string
+	Status     string
+	Payload    []byte
+	Progress   int64
+	Total      int64
+	Result     string
+	Error      string
+	UpdatedAt  time.Time
+	CreatedAt  time.Time
+	FinishedAt sql.NullTime
+	Checkpoint string
+}
+
 type Link struct {
-	ID          string
-	UserID      string
-	OriginalUrl string
-	ShortUrlID  string
-	PrettyID    string
-	UpdatedAt   time.Time
-	CreatedAt   time.Time
+	ID                 string
+	UserID             string
+	OriginalUrl        string
+	ShortUrlID         string
+	PrettyID           string
+	UpdatedAt          time.Time
+	CreatedAt          time.Time
+	RedirectType       int64
+	Status             string
+	DestinationHost    string
+	ClickCount         int64
+	ExpiresAt          sql.NullTime
+	MaxClicks          sql.NullInt64
+	FallbackUrl        string
+	PasswordHash       string
+	DeletedAt          sql.NullTime
+	FolderID           sql.NullString
+	Title              sql.NullString
+	Description        sql.NullString
+	FaviconUrl         sql.NullString
+	ImageUrl           sql.NullString
+	MetadataFetchedAt  sql.NullTime
+	PreviewTitle       sql.NullString
+	PreviewDescription sql.NullString
+	PreviewImageUrl    sql.NullString
+	ActivatesAt        sql.NullTime
+	UtmSource          sql.NullString
+	UtmMedium          sql.NullString
+	UtmCampaign        sql.NullString
+	UtmTerm            sql.NullString
+	UtmContent         sql.NullString
+	QueryPassthrough   string
+	CampaignID         sql.NullString
+	DomainID           sql.NullString
+}
+
+type LinkRevision struct {
+	ID        int64
+	LinkID    string
+	UserID    string
+	Action    string
+	OldValues string
+	NewValues string
+	CreatedAt time.Time
+}
+
+type LinkRule struct {
+	ID             string
+	LinkID         string
+	Position       int64
+	Os             sql.NullString
+	Device         sql.NullString
+	DestinationUrl string
+	CreatedAt      time.Time
+	Country        sql.NullString
+	Region         sql.NullString
+	Timezone       sql.NullString
+	StartsAt       sql.NullTime
+	EndsAt         sql.NullTime
+	Days           sql.NullString
+	DailyStart     sql.NullString
+	DailyEnd       sql.NullString
+}
+
+type LinkTag struct {
+	LinkID    string
+	TagID     string
+	CreatedAt time.Time
+}
+
+type LinkVariant struct {
+	ID             string
+	LinkID         string
+	DestinationUrl string
+	Weight         int64
+	CreatedAt      time.Time
 }
 
 type PasswordResetToken struct {
@@ -37,6 +207,19 @@ type PasswordResetToken struct {
 	CreatedAt time.Time
 }
 
+type Tag struct {
+	ID        string
+	UserID    string
+	Name      string
+	CreatedAt time.Time
+}
+
+type RetiredCode struct {
+	DomainID  string
+	Code      string
+	RetiredAt time.Time
+}
+
 type User struct {
 	ID          string
 	Email       string
@@ -48,3 +231,9 @@ type User struct {
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
+
+type VisitorSalt struct {
+	ID   int64
+	Day  string
+	Salt []byte
+}
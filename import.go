@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	db "url-shortener/db/sqlc"
+	"url-shortener/internal/config"
+	"url-shortener/internal/link"
+)
+
+// runImport imports a CSV export from another shortener for one user:
+//
+//	url-shortener import -email someone@example.com [-format auto] links.csv
+//
+// Progress goes to stderr and the final report to stdout as JSON.
+func runImport(ctx context.Context, cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	email := flags.String("email", "", "email of the user who will own the links")
+	format := flags.String("format", link.ImportFormatAuto, "export format: auto, bitly, rebrandly or tinyurl")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *email == "" || flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("import needs -email and a file")
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	records, err := link.ParseImport(file, *format)
+	if err != nil {
+		return err
+	}
+
+	sqliteDB, err := initDB(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer sqliteDB.Close()
+
+	if err := runMigration(sqliteDB); err != nil {
+		return err
+	}
+
+	store := db.NewStore(sqliteDB)
+
+	user, err := store.GetUserByEmail(ctx, *email)
+	if err != nil {
+		return fmt.Errorf("couldn't find user %s: %w", *email, err)
+	}
+
+	codeGenerator, err := link.NewCodeGenerator(cfg.Link.CodeStrategy, cfg.Link.CodeLength, cfg.Link.CodeSecret)
+	if err != nil {
+		return err
+	}
+
+	linkService := link.NewLinkService(store, codeGenerator, nil, cfg.Link.TrashRetention)
+
+	report, err := linkService.ImportLinks(ctx, link.ImportLinksParams{
+		UserID:  user.ID,
+		Records: records,
+	}, func(done, total int64) {
+		fmt.Fprintf(os.Stderr, "imported %d/%d\n", done, total)
+	})
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
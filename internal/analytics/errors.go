@@ -0,0 +1,11 @@
+package analytics
+
+import "errors"
+
+var (
+	ErrLinkNotFound     = errors.New("link not found")
+	ErrCampaignNotFound = errors.New("campaign not found")
+	ErrInvalidRange     = errors.New("invalid time range")
+	ErrInvalidInterval  = errors.New("interval must be one of hour, day or week")
+	ErrUnknownError     = errors.New("something went wrong")
+)
@@ -0,0 +1,199 @@
+package analytics
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+	db "url-shortener/db/sqlc"
+)
+
+const (
+	defaultBufferSize    = 10000
+	defaultBatchSize     = 500
+	defaultFlushInterval = 2 * time.Second
+
+	maxReferrerLength  = 2048
+	maxUserAgentLength = 512
+	maxCityLength      = 128
+)
+
+type Click struct {
+	LinkID    string
+	ClickedAt time.Time
+	Referrer  string
+	UserAgent string
+	IP        string
+	Country   string
+	City      string
+	// A/B variant the visitor was sent to, if the link has any
+	VariantID string
+	// Set when the redirect already added the click to the link's click
+	// count while spending its click budget
+	Counted bool
+}
+
+type RecorderConfig struct {
+	BufferSize    int
+	BatchSize     int
+	FlushInterval time.Duration
+}
+
+// Recorder takes clicks off the redirect path. Clicks are queued on a
+// buffered channel and written in batches by Run, so a redirect never waits
+// on SQLite.
+type Recorder struct {
+	store         *db.Store
+	visitorHasher *VisitorHasher
+	clicks        chan Click
+	batchSize     int
+	flushInterval time.Duration
+}
+
+func NewRecorder(store *db.Store, config RecorderConfig) *Recorder {
+	if config.BufferSize <= 0 {
+		config.BufferSize = defaultBufferSize
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaultFlushInterval
+	}
+
+	return &Recorder{
+		store:         store,
+		visitorHasher: NewVisitorHasher(),
+		clicks:        make(chan Click, config.BufferSize),
+		batchSize:     config.BatchSize,
+		flushInterval: config.FlushInterval,
+	}
+}
+
+// Record queues a click without blocking. When the buffer is full the click
+// is dropped: losing analytics beats slowing down redirects.
+func (r *Recorder) Record(click Click) {
+	select {
+	case r.clicks <- click:
+	default:
+		slog.Warn("analytics.Recorder", "message", "click buffer full, dropping click", "link", click.LinkID)
+	}
+}
+
+// Run writes queued clicks until ctx is cancelled, then flushes whatever is
+// still buffered before returning.
+func (r *Recorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Click, 0, r.batchSize)
+
+	for {
+		select {
+		case click := <-r.clicks:
+			batch = append(batch, click)
+			if len(batch) >= r.batchSize {
+				r.flush(ctx, batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				r.flush(ctx, batch)
+				batch = batch[:0]
+			}
+		case <-ctx.Done():
+			drainCtx := context.WithoutCancel(ctx)
+			for {
+				select {
+				case click := <-r.clicks:
+					batch = append(batch, click)
+					if len(batch) >= r.batchSize {
+						r.flush(drainCtx, batch)
+						batch = batch[:0]
+					}
+				default:
+					if len(batch) > 0 {
+						r.flush(drainCtx, batch)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+func (r *Recorder) flush(ctx context.Context, batch []Click) {
+	const serviceID = "analytics.Recorder.flush"
+
+	clicksPerLink := map[string]int64{}
+
+	err := r.store.ExecTx(ctx, func(q *db.Queries) error {
+		for _, click := range batch {
+			params, err := r.toCreateClickParams(ctx, q, click)
+			if err != nil {
+				slog.Warn(serviceID, "message", "couldn't prepare click", "link", click.LinkID, "error", err)
+				continue
+			}
+			if err := q.CreateClick(ctx, params); err != nil {
+				return err
+			}
+			if !click.Counted {
+				clicksPerLink[click.LinkID]++
+			}
+		}
+
+		for linkID, clicks := range clicksPerLink {
+			if err := q.AddLinkClicks(ctx, db.AddLinkClicksParams{Clicks: clicks, ID: linkID}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't write clicks", "count", len(batch), "error", err)
+	}
+}
+
+func (r *Recorder) toCreateClickParams(ctx context.Context, q *db.Queries, click Click) (db.CreateClickParams, error) {
+	visitorHash, err := r.visitorHasher.Hash(ctx, q, click.IP, click.ClickedAt)
+	if err != nil {
+		return db.CreateClickParams{}, err
+	}
+
+	client := ParseUserAgent(click.UserAgent)
+
+	return db.CreateClickParams{
+		LinkID:       click.LinkID,
+		ClickedAt:    click.ClickedAt.UTC(),
+		Referrer:     truncate(click.Referrer, maxReferrerLength),
+		ReferrerHost: referrerHost(click.Referrer),
+		UserAgent:    truncate(click.UserAgent, maxUserAgentLength),
+		Device:       client.Device,
+		Browser:      client.Browser,
+		Os:           client.OS,
+		Country:      click.Country,
+		City:         truncate(click.City, maxCityLength),
+		VariantID:    click.VariantID,
+		VisitorHash:  visitorHash,
+	}, nil
+}
+
+// referrerHost keeps only the host of the referrer, which is what the stats
+// group by. Full referrer URLs are too unique to be useful in a top list.
+func referrerHost(referrer string) string {
+	u, err := url.Parse(referrer)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+func truncate(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+	return s[:length]
+}
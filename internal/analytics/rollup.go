@@ -0,0 +1,64 @@
+package analytics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	defaultRollupInterval = time.Minute
+	// Clicks can reach the database a little after the hour they happened
+	// in. Rollups start far enough back to pick those up.
+	rollupLookback = time.Hour
+)
+
+// RunRollups folds new clicks into the rollup tables every interval until
+// ctx is cancelled.
+func (s *StatsService) RunRollups(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultRollupInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Rollup(ctx); err != nil && ctx.Err() == nil {
+				slog.Error("service.analytics.RunRollups", "message", "couldn't roll up clicks", "error", err)
+			}
+		}
+	}
+}
+
+// Rollup recomputes every bucket from the day of the latest rolled up hour
+// onwards. Buckets are overwritten rather than incremented so running it
+// twice is harmless. Unique visitors can't be added up, which is why whole
+// days are recomputed instead of just the new hours.
+func (s *StatsService) Rollup(ctx context.Context) error {
+	latest, err := s.queries.GetLatestRollupHour(ctx)
+	if err != nil {
+		return err
+	}
+
+	var since time.Time
+	if latest != "" {
+		hour, err := time.ParseInLocation(rollupHourFormat, latest, time.UTC)
+		if err != nil {
+			return err
+		}
+		since = hour.Add(-rollupLookback).Truncate(24 * time.Hour)
+	}
+
+	if err := s.queries.RollupHourlyClicks(ctx, since); err != nil {
+		return err
+	}
+	if err := s.queries.RollupDailyClicks(ctx, since); err != nil {
+		return err
+	}
+	return s.queries.RollupClickDimensions(ctx, since)
+}
@@ -0,0 +1,449 @@
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+	db "url-shortener/db/sqlc"
+	"url-shortener/internal/utils"
+)
+
+const (
+	IntervalHour = "hour"
+	IntervalDay  = "day"
+	IntervalWeek = "week"
+
+	DimensionReferrer = "referrer"
+	DimensionCountry  = "country"
+	DimensionCity     = "city"
+	DimensionDevice   = "device"
+	DimensionBrowser  = "browser"
+	DimensionOS       = "os"
+	DimensionVariant  = "variant"
+
+	defaultStatsRange = 30 * 24 * time.Hour
+	maxStatsRange     = 366 * 24 * time.Hour
+	// Hourly series get long quickly, so they're capped to about a month
+	maxHourlyRange = 31 * 24 * time.Hour
+	topValuesLimit = 10
+
+	// Rollup buckets are stored as UTC text in these formats
+	rollupHourFormat = "2006-01-02 15:00:00"
+	rollupDayFormat  = time.DateOnly
+)
+
+type StatsService struct {
+	queries *db.Queries
+}
+
+func NewStatsService(queries *db.Queries) *StatsService {
+	return &StatsService{queries: queries}
+}
+
+type GetLinkStatsParams struct {
+	UserID   string
+	LinkID   string
+	From     time.Time
+	To       time.Time
+	Interval string
+	Location *time.Location
+}
+
+type Bucket struct {
+	Start  string `json:"start"`
+	Clicks int64  `json:"clicks"`
+}
+
+type TopValue struct {
+	Value  string `json:"value"`
+	Clicks int64  `json:"clicks"`
+}
+
+type LinkStats struct {
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Interval    string `json:"interval"`
+	Timezone    string `json:"timezone"`
+	TotalClicks int64  `json:"total_clicks"`
+	// Visitors are counted once for every UTC day they clicked on, since
+	// visitor hashes rotate daily and can't be linked across days
+	DailyUniqueVisitors int64      `json:"daily_unique_visitors"`
+	Series              []Bucket   `json:"series"`
+	Referrers           []TopValue `json:"referrers"`
+	Countries           []TopValue `json:"countries"`
+	Cities              []TopValue `json:"cities"`
+	Devices             []TopValue `json:"devices"`
+	Browsers            []TopValue `json:"browsers"`
+	OS                  []TopValue `json:"os"`
+	// Clicks per A/B variant, by variant ID
+	Variants []TopValue `json:"variants"`
+}
+
+// GetLinkStats reads a link's stats for [From, To) from the rollup tables.
+// Rollups are kept per UTC hour, so series in time zones with a non-whole
+// hour offset are approximate.
+func (s *StatsService) GetLinkStats(ctx context.Context, args GetLinkStatsParams) (LinkStats, error) {
+	const serviceID = "service.analytics.GetLinkStats"
+
+	window, err := newStatsWindow(args.From, args.To, args.Interval, args.Location)
+	if err != nil {
+		return LinkStats{}, err
+	}
+
+	_, err = s.queries.GetShortLinkById(ctx, db.GetShortLinkByIdParams{
+		UserID: args.UserID,
+		ID:     args.LinkID,
+	})
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return LinkStats{}, ErrLinkNotFound
+		}
+		slog.Error(serviceID, "message", "couldn't get link", "link", args.LinkID, "error", err)
+		return LinkStats{}, ErrUnknownError
+	}
+
+	source := statsSource{
+		hourlyClicks: func(fromHour, toHour string) ([]hourlyClicks, error) {
+			rows, err := s.queries.ListHourlyClicks(ctx, db.ListHourlyClicksParams{
+				LinkID:   args.LinkID,
+				FromHour: fromHour,
+				ToHour:   toHour,
+			})
+			hourly := make([]hourlyClicks, len(rows))
+			for i, row := range rows {
+				hourly[i] = hourlyClicks(row)
+			}
+			return hourly, err
+		},
+		dailyUniqueVisitors: func(fromDay, toDay string) (int64, error) {
+			return s.queries.GetDailyUniqueVisitors(ctx, db.GetDailyUniqueVisitorsParams{
+				LinkID:  args.LinkID,
+				FromDay: fromDay,
+				ToDay:   toDay,
+			})
+		},
+		topValues: func(dimension, fromDay, toDay string) ([]TopValue, error) {
+			rows, err := s.queries.ListTopDimensionValues(ctx, db.ListTopDimensionValuesParams{
+				LinkID:    args.LinkID,
+				Dimension: dimension,
+				FromDay:   fromDay,
+				ToDay:     toDay,
+				MaxValues: topValuesLimit,
+			})
+			values := make([]TopValue, len(rows))
+			for i, row := range rows {
+				values[i] = TopValue{Value: row.Value, Clicks: row.Clicks}
+			}
+			return values, err
+		},
+	}
+
+	stats, err := readStats(window, source)
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't read stats", "link", args.LinkID, "error", err)
+		return LinkStats{}, ErrUnknownError
+	}
+
+	return stats, nil
+}
+
+type GetCampaignStatsParams struct {
+	UserID     string
+	CampaignID string
+	// Default to the campaign's own dates, where it has them
+	From     time.Time
+	To       time.Time
+	Interval string
+	Location *time.Location
+}
+
+type CampaignStats struct {
+	LinkStats
+	// Clicks per link in the campaign, by link ID
+	Links []TopValue `json:"links"`
+}
+
+// GetCampaignStats adds up the stats of every link in a campaign, the way
+// GetLinkStats reads them for one. A visitor is counted once a day however
+// many of the campaign's links they click. Links belong to the campaign
+// they're in now, so one that's added or removed brings its whole history
+// in or out with it. Trashed links are left out, as they are from the
+// campaign's link list.
+func (s *StatsService) GetCampaignStats(ctx context.Context, args GetCampaignStatsParams) (CampaignStats, error) {
+	const serviceID = "service.analytics.GetCampaignStats"
+
+	campaign, err := s.queries.GetCampaign(ctx, db.GetCampaignParams{
+		ID:     args.CampaignID,
+		UserID: args.UserID,
+	})
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return CampaignStats{}, ErrCampaignNotFound
+		}
+		slog.Error(serviceID, "message", "couldn't get campaign", "campaign", args.CampaignID, "error", err)
+		return CampaignStats{}, ErrUnknownError
+	}
+
+	from, to := args.From, args.To
+	now := time.Now()
+	if to.IsZero() && campaign.EndsAt.Valid && campaign.EndsAt.Time.Before(now) {
+		to = campaign.EndsAt.Time
+	}
+	// Campaigns that haven't started, or run longer than stats can cover,
+	// fall back to the default range
+	if from.IsZero() && campaign.StartsAt.Valid {
+		end := to
+		if end.IsZero() {
+			end = now
+		}
+		if campaign.StartsAt.Time.Before(end) && end.Sub(campaign.StartsAt.Time) <= maxStatsRange {
+			from = campaign.StartsAt.Time
+		}
+	}
+
+	window, err := newStatsWindow(from, to, args.Interval, args.Location)
+	if err != nil {
+		return CampaignStats{}, err
+	}
+
+	campaignID := sql.NullString{String: campaign.ID, Valid: true}
+
+	source := statsSource{
+		hourlyClicks: func(fromHour, toHour string) ([]hourlyClicks, error) {
+			rows, err := s.queries.ListCampaignHourlyClicks(ctx, db.ListCampaignHourlyClicksParams{
+				CampaignID: campaignID,
+				FromHour:   fromHour,
+				ToHour:     toHour,
+			})
+			hourly := make([]hourlyClicks, len(rows))
+			for i, row := range rows {
+				hourly[i] = hourlyClicks(row)
+			}
+			return hourly, err
+		},
+		dailyUniqueVisitors: func(fromDay, toDay string) (int64, error) {
+			return s.queries.GetCampaignDailyUniqueVisitors(ctx, db.GetCampaignDailyUniqueVisitorsParams{
+				CampaignID: campaignID,
+				FromDay:    fromDay,
+				ToDay:      toDay,
+			})
+		},
+		topValues: func(dimension, fromDay, toDay string) ([]TopValue, error) {
+			rows, err := s.queries.ListCampaignTopDimensionValues(ctx, db.ListCampaignTopDimensionValuesParams{
+				CampaignID: campaignID,
+				Dimension:  dimension,
+				FromDay:    fromDay,
+				ToDay:      toDay,
+				MaxValues:  topValuesLimit,
+			})
+			values := make([]TopValue, len(rows))
+			for i, row := range rows {
+				values[i] = TopValue{Value: row.Value, Clicks: row.Clicks}
+			}
+			return values, err
+		},
+	}
+
+	linkStats, err := readStats(window, source)
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't read stats", "campaign", args.CampaignID, "error", err)
+		return CampaignStats{}, ErrUnknownError
+	}
+
+	linkClicks, err := s.queries.ListCampaignLinkClicks(ctx, db.ListCampaignLinkClicksParams{
+		FromDay:    window.fromDay(),
+		ToDay:      window.toDay(),
+		CampaignID: campaignID,
+	})
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't list link clicks", "campaign", args.CampaignID, "error", err)
+		return CampaignStats{}, ErrUnknownError
+	}
+
+	stats := CampaignStats{LinkStats: linkStats, Links: make([]TopValue, 0, len(linkClicks))}
+	for _, row := range linkClicks {
+		stats.Links = append(stats.Links, TopValue{Value: row.LinkID, Clicks: row.Clicks})
+	}
+
+	return stats, nil
+}
+
+// statsWindow is a validated time range and interval to read stats for.
+type statsWindow struct {
+	from     time.Time
+	to       time.Time
+	interval string
+	loc      *time.Location
+}
+
+func newStatsWindow(from, to time.Time, interval string, loc *time.Location) (statsWindow, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	if interval == "" {
+		interval = IntervalDay
+	}
+	if interval != IntervalHour && interval != IntervalDay && interval != IntervalWeek {
+		return statsWindow{}, ErrInvalidInterval
+	}
+
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultStatsRange)
+	}
+
+	if !from.Before(to) || to.Sub(from) > maxStatsRange {
+		return statsWindow{}, ErrInvalidRange
+	}
+	if interval == IntervalHour && to.Sub(from) > maxHourlyRange {
+		return statsWindow{}, ErrInvalidRange
+	}
+
+	return statsWindow{from: from, to: to, interval: interval, loc: loc}, nil
+}
+
+// last is the last instant that counts: `to` is exclusive, so it's the one
+// just before it.
+func (w statsWindow) last() time.Time {
+	return w.to.Add(-time.Nanosecond).UTC()
+}
+
+func (w statsWindow) fromDay() string {
+	return w.from.UTC().Format(rollupDayFormat)
+}
+
+func (w statsWindow) toDay() string {
+	return w.last().Format(rollupDayFormat)
+}
+
+type hourlyClicks struct {
+	Hour   string
+	Clicks int64
+}
+
+// statsSource reads the rollups of whatever the stats are about.
+type statsSource struct {
+	hourlyClicks        func(fromHour, toHour string) ([]hourlyClicks, error)
+	dailyUniqueVisitors func(fromDay, toDay string) (int64, error)
+	topValues           func(dimension, fromDay, toDay string) ([]TopValue, error)
+}
+
+func readStats(window statsWindow, source statsSource) (LinkStats, error) {
+	const serviceID = "service.analytics.readStats"
+
+	from, to, interval, loc := window.from, window.to, window.interval, window.loc
+
+	fromHour := from.UTC().Truncate(time.Hour).Format(rollupHourFormat)
+	toHour := window.last().Truncate(time.Hour).Format(rollupHourFormat)
+	fromDay := window.fromDay()
+	toDay := window.toDay()
+
+	hourly, err := source.hourlyClicks(fromHour, toHour)
+	if err != nil {
+		return LinkStats{}, err
+	}
+
+	dailyUniqueVisitors, err := source.dailyUniqueVisitors(fromDay, toDay)
+	if err != nil {
+		return LinkStats{}, err
+	}
+
+	stats := LinkStats{
+		From:                utils.ConvertTimeToString(from.In(loc)),
+		To:                  utils.ConvertTimeToString(to.In(loc)),
+		Interval:            interval,
+		Timezone:            loc.String(),
+		DailyUniqueVisitors: dailyUniqueVisitors,
+	}
+
+	var starts []time.Time
+	clicksPerBucket := map[time.Time]int64{}
+	for start := bucketStart(from, interval, loc); start.Before(to); start = nextBucket(start, interval) {
+		starts = append(starts, start)
+		clicksPerBucket[start] = 0
+	}
+
+	for _, row := range hourly {
+		hour, err := time.ParseInLocation(rollupHourFormat, row.Hour, time.UTC)
+		if err != nil {
+			slog.Warn(serviceID, "message", "couldn't parse rollup hour", "hour", row.Hour, "error", err)
+			continue
+		}
+		start := bucketStart(hour, interval, loc)
+		if _, ok := clicksPerBucket[start]; ok {
+			clicksPerBucket[start] += row.Clicks
+			stats.TotalClicks += row.Clicks
+		}
+	}
+
+	stats.Series = make([]Bucket, 0, len(starts))
+	for _, start := range starts {
+		stats.Series = append(stats.Series, Bucket{
+			Start:  utils.ConvertTimeToString(start),
+			Clicks: clicksPerBucket[start],
+		})
+	}
+
+	dimensions := []struct {
+		name   string
+		values *[]TopValue
+	}{
+		{DimensionReferrer, &stats.Referrers},
+		{DimensionCountry, &stats.Countries},
+		{DimensionCity, &stats.Cities},
+		{DimensionDevice, &stats.Devices},
+		{DimensionBrowser, &stats.Browsers},
+		{DimensionOS, &stats.OS},
+		{DimensionVariant, &stats.Variants},
+	}
+
+	for _, dimension := range dimensions {
+		values, err := source.topValues(dimension.name, fromDay, toDay)
+		if err != nil {
+			return LinkStats{}, err
+		}
+		*dimension.values = values
+	}
+
+	return stats, nil
+}
+
+// bucketStart returns the start of the local hour, day or ISO week that t
+// falls in.
+func bucketStart(t time.Time, interval string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	year, month, day := t.Date()
+
+	switch interval {
+	case IntervalHour:
+		return time.Date(year, month, day, t.Hour(), 0, 0, 0, loc)
+	case IntervalWeek:
+		// Weeks start on Monday
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, loc)
+	}
+}
+
+// nextBucket steps in calendar units so days and weeks stay aligned to local
+// midnight across DST changes.
+func nextBucket(start time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalHour:
+		return start.Add(time.Hour)
+	case IntervalWeek:
+		return start.AddDate(0, 0, 7)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
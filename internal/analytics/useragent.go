@@ -0,0 +1,43 @@
+package analytics
+
+import (
+	"github.com/mileusna/useragent"
+)
+
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceOther   = "other"
+)
+
+type Client struct {
+	Device  string
+	Browser string
+	OS      string
+}
+
+func ParseUserAgent(userAgent string) Client {
+	ua := useragent.Parse(userAgent)
+
+	var device string
+	switch {
+	case ua.Bot:
+		device = DeviceBot
+	case ua.Tablet:
+		device = DeviceTablet
+	case ua.Mobile:
+		device = DeviceMobile
+	case ua.Desktop:
+		device = DeviceDesktop
+	default:
+		device = DeviceOther
+	}
+
+	return Client{
+		Device:  device,
+		Browser: ua.Name,
+		OS:      ua.OS,
+	}
+}
@@ -0,0 +1,78 @@
+package analytics
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"sync"
+	"time"
+	db "url-shortener/db/sqlc"
+)
+
+// VisitorHasher turns an IP address into an identifier that's stable for a
+// day and can't be reversed. The salt is kept in the database so it
+// survives restarts, and is replaced at midnight UTC, after which
+// yesterday's hashes can't be linked to today's.
+type VisitorHasher struct {
+	mu   sync.Mutex
+	day  string
+	salt []byte
+}
+
+func NewVisitorHasher() *VisitorHasher {
+	return &VisitorHasher{}
+}
+
+// Hash uses q to read or rotate the salt, which may be a transaction.
+func (h *VisitorHasher) Hash(ctx context.Context, q *db.Queries, ip string, at time.Time) (string, error) {
+	salt, err := h.saltFor(ctx, q, at.UTC().Format(time.DateOnly))
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.New()
+	sum.Write(salt)
+	sum.Write([]byte(ip))
+
+	return hex.EncodeToString(sum.Sum(nil)[:16]), nil
+}
+
+func (h *VisitorHasher) saltFor(ctx context.Context, q *db.Queries, day string) ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Clicks recorded just before midnight can be hashed just after it. The
+	// old salt is gone by then so they share the new day's salt.
+	if h.salt != nil && day <= h.day {
+		return h.salt, nil
+	}
+
+	current, err := q.GetVisitorSalt(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	if err == sql.ErrNoRows || day > current.Day {
+		salt := make([]byte, 32)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+
+		if err := q.RotateVisitorSalt(ctx, db.RotateVisitorSaltParams{Day: day, Salt: salt}); err != nil {
+			return nil, err
+		}
+
+		// Whichever salt won is the one to use
+		current, err = q.GetVisitorSalt(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	h.day = current.Day
+	h.salt = current.Salt
+
+	return h.salt, nil
+}
@@ -0,0 +1,14 @@
+package config
+
+import "time"
+
+type Analytics struct {
+	// Number of clicks that can wait in memory before new ones are dropped
+	BufferSize int
+	// Number of clicks written per transaction
+	BatchSize int
+	// Longest a click waits in the buffer before it's written
+	FlushInterval time.Duration
+	// How often raw clicks are folded into the stats rollups
+	RollupInterval time.Duration
+}
@@ -13,6 +13,12 @@ type Config struct {
 	SMTP      SMTP
 	Database  Database
 	Server    Server
+	Link      Link
+	Analytics Analytics
+	Job       Job
+	Export    Export
+	Metadata  Metadata
+	GeoIP     GeoIP
 	Debug     bool
 	ResendKey string
 }
@@ -56,6 +62,8 @@ func initConfig(v *viper.Viper) Config {
 		Address:           fmt.Sprintf("0.0.0.0:%d", port),
 		Port:              port,
 		TokenSymmetricKey: v.GetString("TOKEN_SYMMETRIC_KEY"),
+		ClientIPHeader:    v.GetString("CLIENT_IP_HEADER"),
+		BaseURL:           v.GetString("BASE_URL"),
 	}
 
 	var logLevel string
@@ -84,6 +92,45 @@ func initConfig(v *viper.Viper) Config {
 		From:     v.GetString("SMTP_EMAIL"),
 	}
 
+	linkConfig := Link{
+		CodeStrategy:        v.GetString("LINK_CODE_STRATEGY"),
+		CodeLength:          v.GetInt("LINK_CODE_LENGTH"),
+		CodeSecret:          v.GetString("LINK_CODE_SECRET"),
+		ExpirySweepInterval: v.GetDuration("LINK_EXPIRY_SWEEP_INTERVAL"),
+		TrashRetention:      v.GetDuration("LINK_TRASH_RETENTION"),
+		TrashPurgeInterval:  v.GetDuration("LINK_TRASH_PURGE_INTERVAL"),
+		BulkMaxItems:        v.GetInt("LINK_BULK_MAX_ITEMS"),
+	}
+
+	analyticsConfig := Analytics{
+		BufferSize:     v.GetInt("ANALYTICS_BUFFER_SIZE"),
+		BatchSize:      v.GetInt("ANALYTICS_BATCH_SIZE"),
+		FlushInterval:  v.GetDuration("ANALYTICS_FLUSH_INTERVAL"),
+		RollupInterval: v.GetDuration("ANALYTICS_ROLLUP_INTERVAL"),
+	}
+
+	jobConfig := Job{
+		PollInterval: v.GetDuration("JOB_POLL_INTERVAL"),
+	}
+
+	exportConfig := Export{
+		DownloadTTL:     v.GetDuration("EXPORT_DOWNLOAD_TTL"),
+		CleanupInterval: v.GetDuration("EXPORT_CLEANUP_INTERVAL"),
+		Directory:       v.GetString("EXPORT_DIRECTORY"),
+	}
+
+	metadataConfig := Metadata{
+		PollInterval:    v.GetDuration("METADATA_POLL_INTERVAL"),
+		FetchTimeout:    v.GetDuration("METADATA_FETCH_TIMEOUT"),
+		MaxBodySize:     v.GetInt64("METADATA_MAX_BODY_SIZE"),
+		AllowPrivateIPs: v.GetBool("METADATA_ALLOW_PRIVATE_IPS"),
+	}
+
+	geoIPConfig := GeoIP{
+		DatabasePath:   v.GetString("GEOIP_DATABASE_PATH"),
+		ReloadInterval: v.GetDuration("GEOIP_RELOAD_INTERVAL"),
+	}
+
 	resendApiKey := v.GetString("RESEND_API_KEY")
 
 	return Config{
@@ -92,6 +139,12 @@ func initConfig(v *viper.Viper) Config {
 		SMTP:      smtpConfig,
 		Database:  databaseConfig,
 		Server:    serverConfig,
+		Link:      linkConfig,
+		Analytics: analyticsConfig,
+		Job:       jobConfig,
+		Export:    exportConfig,
+		Metadata:  metadataConfig,
+		GeoIP:     geoIPConfig,
 		ResendKey: resendApiKey,
 	}
 }
@@ -0,0 +1,13 @@
+package config
+
+import "time"
+
+type Export struct {
+	// How long account export download links keep working
+	DownloadTTL time.Duration
+	// How often expired export archives are deleted
+	CleanupInterval time.Duration
+	// Where export archives are kept until they expire. Defaults to an
+	// exports directory next to the database file.
+	Directory string
+}
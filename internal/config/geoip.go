@@ -0,0 +1,12 @@
+package config
+
+import "time"
+
+type GeoIP struct {
+	// Path of a MaxMind or DB-IP .mmdb file. Country and city databases
+	// both work. Without one, geo rules never match and clicks have no
+	// location.
+	DatabasePath string
+	// How often the file is checked for a new version
+	ReloadInterval time.Duration
+}
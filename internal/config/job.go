@@ -0,0 +1,8 @@
+package config
+
+import "time"
+
+type Job struct {
+	// How often the job runner checks for queued jobs it wasn't told about
+	PollInterval time.Duration
+}
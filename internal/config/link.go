@@ -0,0 +1,20 @@
+package config
+
+import "time"
+
+type Link struct {
+	// "random" | "counter" | "hash"
+	CodeStrategy string
+	// Number of characters in generated short codes
+	CodeLength int
+	// Key for the counter strategy's permutation
+	CodeSecret string
+	// How often links past their expiry or click budget are marked expired
+	ExpirySweepInterval time.Duration
+	// How long trashed links can be restored before they're purged
+	TrashRetention time.Duration
+	// How often trashed links past their retention are purged
+	TrashPurgeInterval time.Duration
+	// Most links a single bulk create may hold
+	BulkMaxItems int
+}
@@ -0,0 +1,17 @@
+package config
+
+import "time"
+
+type Metadata struct {
+	// How often links are checked for destination pages that haven't been
+	// fetched yet
+	PollInterval time.Duration
+	// How long fetching a single page may take
+	FetchTimeout time.Duration
+	// Most bytes read from a page
+	MaxBodySize int64
+	// Lets the fetcher reach private and loopback addresses. Never turn this
+	// on in production: any user could make the server request internal
+	// services.
+	AllowPrivateIPs bool
+}
@@ -6,4 +6,12 @@ type Server struct {
 	// "8080"
 	Port              int
 	TokenSymmetricKey string
+	// Header a trusted proxy puts the client's IP in, e.g. "Fly-Client-IP".
+	// Falls back to the connection's remote address when empty.
+	// X-Forwarded-For is read from the right, past private proxies.
+	ClientIPHeader string
+	// Public URL short links are served from, e.g. "https://sho.rt".
+	// Required, since the request's Host header can't be trusted to build
+	// links that go out in QR codes and emails.
+	BaseURL string
 }
@@ -5,4 +5,5 @@ type Emailer interface {
 	SendVerificationMail(email, code string) error
 	SendVerificationCompleteMail(email string) error
 	SendPasswordResetMail(email, token string) error
+	SendDataExportMail(email, downloadURL string) error
 }
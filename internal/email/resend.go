@@ -49,3 +49,8 @@ func (s *ResendService) SendPasswordResetMail(email, token string) error {
 	msg := fmt.Sprintf("Here's your password reseet token: %s", token)
 	return s.Send([]string{email}, "Reset your password", msg)
 }
+
+func (s *ResendService) SendDataExportMail(email, downloadURL string) error {
+	msg := fmt.Sprintf("Your data export is ready. Download it here: %s", downloadURL)
+	return s.Send([]string{email}, "Your data export is ready", msg)
+}
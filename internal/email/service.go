@@ -55,3 +55,8 @@ func (s *EmailService) SendPasswordResetMail(email, token string) error {
 	msg := fmt.Sprintf("Here's your password reseet token: %s", token)
 	return s.Send([]string{email}, "Reset your password", msg)
 }
+
+func (s *EmailService) SendDataExportMail(email, downloadURL string) error {
+	msg := fmt.Sprintf("Your data export is ready. Download it here: %s", downloadURL)
+	return s.Send([]string{email}, "Your data export is ready", msg)
+}
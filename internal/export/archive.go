@@ -0,0 +1,232 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+	db "url-shortener/db/sqlc"
+	"url-shortener/internal/utils"
+)
+
+// Clicks are read a page at a time so big accounts don't have to fit in
+// memory.
+const clickPageSize = 1000
+
+// The archive is written in this many steps, which is what progress counts.
+const archiveSteps = 4
+
+type profile struct {
+	ID          string `json:"id"`
+	Email       string `json:"email"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	Status      string `json:"status"`
+	LastLoginAt string `json:"last_login_at"`
+	UpdatedAt   string `json:"updated_at"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type exportedLink struct {
+	ID                string `json:"id"`
+	OriginalURL       string `json:"original_url"`
+	ShortURLID        string `json:"short_url_id"`
+	PrettyID          string `json:"pretty_id"`
+	RedirectType      int64  `json:"redirect_type"`
+	Status            string `json:"status"`
+	DestinationHost   string `json:"destination_host"`
+	ClickCount        int64  `json:"click_count"`
+	ExpiresAt         string `json:"expires_at"`
+	ActivatesAt       string `json:"activates_at"`
+	MaxClicks         string `json:"max_clicks"`
+	FallbackURL       string `json:"fallback_url"`
+	PasswordProtected bool   `json:"password_protected"`
+	DeletedAt         string `json:"deleted_at"`
+	UpdatedAt         string `json:"updated_at"`
+	CreatedAt         string `json:"created_at"`
+}
+
+type exportedRevision struct {
+	ID        int64           `json:"id"`
+	LinkID    string          `json:"link_id"`
+	Action    string          `json:"action"`
+	OldValues json.RawMessage `json:"old_values"`
+	NewValues json.RawMessage `json:"new_values"`
+	CreatedAt string          `json:"created_at"`
+}
+
+// buildArchive zips up everything stored about a user into w: profile.json,
+// links.json and links.csv, revisions.json and clicks.csv.
+func (s *ExportService) buildArchive(ctx context.Context, w io.Writer, user db.User, progress func(done, total int64)) error {
+	archive := zip.NewWriter(w)
+
+	err := writeJSON(archive, "profile.json", profile{
+		ID:          user.ID,
+		Email:       user.Email,
+		FirstName:   user.FirstName.String,
+		LastName:    user.LastName.String,
+		Status:      userStatus(user.Status),
+		LastLoginAt: nullTimeString(user.LastLoginAt.Time, user.LastLoginAt.Valid),
+		UpdatedAt:   utils.ConvertTimeToString(user.UpdatedAt),
+		CreatedAt:   utils.ConvertTimeToString(user.CreatedAt),
+	})
+	if err != nil {
+		return err
+	}
+	progress(1, archiveSteps)
+
+	dbLinks, err := s.queries.GetShortLinks(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	links := make([]exportedLink, 0, len(dbLinks))
+	for _, l := range dbLinks {
+		exported := exportedLink{
+			ID:                l.ID,
+			OriginalURL:       l.OriginalUrl,
+			ShortURLID:        l.ShortUrlID,
+			PrettyID:          l.PrettyID,
+			RedirectType:      l.RedirectType,
+			Status:            l.Status,
+			DestinationHost:   l.DestinationHost,
+			ClickCount:        l.ClickCount,
+			ExpiresAt:         nullTimeString(l.ExpiresAt.Time, l.ExpiresAt.Valid),
+			ActivatesAt:       nullTimeString(l.ActivatesAt.Time, l.ActivatesAt.Valid),
+			FallbackURL:       l.FallbackUrl,
+			PasswordProtected: l.PasswordHash != "",
+			DeletedAt:         nullTimeString(l.DeletedAt.Time, l.DeletedAt.Valid),
+			UpdatedAt:         utils.ConvertTimeToString(l.UpdatedAt),
+			CreatedAt:         utils.ConvertTimeToString(l.CreatedAt),
+		}
+		if l.MaxClicks.Valid {
+			exported.MaxClicks = strconv.FormatInt(l.MaxClicks.Int64, 10)
+		}
+		links = append(links, exported)
+	}
+
+	if err := writeJSON(archive, "links.json", links); err != nil {
+		return err
+	}
+
+	linkRows := [][]string{{
+		"id", "original_url", "short_url_id", "pretty_id", "redirect_type", "status", "click_count",
+		"expires_at", "activates_at", "max_clicks", "fallback_url", "password_protected", "deleted_at", "updated_at", "created_at",
+	}}
+	for _, l := range links {
+		linkRows = append(linkRows, []string{
+			l.ID, l.OriginalURL, l.ShortURLID, l.PrettyID, strconv.FormatInt(l.RedirectType, 10), l.Status,
+			strconv.FormatInt(l.ClickCount, 10), l.ExpiresAt, l.ActivatesAt, l.MaxClicks, l.FallbackURL,
+			strconv.FormatBool(l.PasswordProtected), l.DeletedAt, l.UpdatedAt, l.CreatedAt,
+		})
+	}
+	if err := writeCSV(archive, "links.csv", linkRows); err != nil {
+		return err
+	}
+	progress(2, archiveSteps)
+
+	dbRevisions, err := s.queries.ListUserLinkRevisions(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	revisions := make([]exportedRevision, 0, len(dbRevisions))
+	for _, r := range dbRevisions {
+		revisions = append(revisions, exportedRevision{
+			ID:        r.ID,
+			LinkID:    r.LinkID,
+			Action:    r.Action,
+			OldValues: json.RawMessage(r.OldValues),
+			NewValues: json.RawMessage(r.NewValues),
+			CreatedAt: utils.ConvertTimeToString(r.CreatedAt),
+		})
+	}
+	if err := writeJSON(archive, "revisions.json", revisions); err != nil {
+		return err
+	}
+	progress(3, archiveSteps)
+
+	if err := s.writeClicks(ctx, archive, user.ID); err != nil {
+		return err
+	}
+	progress(4, archiveSteps)
+
+	return archive.Close()
+}
+
+func (s *ExportService) writeClicks(ctx context.Context, archive *zip.Writer, userID string) error {
+	file, err := archive.Create("clicks.csv")
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"id", "link_id", "clicked_at", "referrer", "referrer_host", "user_agent", "device", "browser", "os", "country", "city", "variant_id"})
+
+	var afterID int64
+	for {
+		clicks, err := s.queries.ListUserClicks(ctx, db.ListUserClicksParams{
+			UserID:    userID,
+			AfterID:   afterID,
+			MaxClicks: clickPageSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, c := range clicks {
+			w.Write([]string{
+				strconv.FormatInt(c.ID, 10), c.LinkID, utils.ConvertTimeToString(c.ClickedAt), c.Referrer,
+				c.ReferrerHost, c.UserAgent, c.Device, c.Browser, c.Os, c.Country, c.City, c.VariantID,
+			})
+		}
+
+		if len(clicks) < clickPageSize {
+			break
+		}
+		afterID = clicks[len(clicks)-1].ID
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+func writeJSON(archive *zip.Writer, name string, v any) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func writeCSV(archive *zip.Writer, name string, rows [][]string) error {
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	return csv.NewWriter(file).WriteAll(rows)
+}
+
+func nullTimeString(t time.Time, valid bool) string {
+	if !valid {
+		return ""
+	}
+	return utils.ConvertTimeToString(t)
+}
+
+// userStatus reads users.status, which sqlc couldn't type, so it comes back
+// as whatever the driver hands over.
+func userStatus(status any) string {
+	switch s := status.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	}
+	return ""
+}
@@ -0,0 +1,10 @@
+package export
+
+import "errors"
+
+var (
+	ErrExportNotFound       = errors.New("export not found")
+	ErrExportExpired        = errors.New("export download has expired")
+	ErrInvalidDownloadToken = errors.New("invalid download link")
+	ErrUnknownError         = errors.New("something went wrong")
+)
@@ -0,0 +1,263 @@
+package export
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	db "url-shortener/db/sqlc"
+	"url-shortener/internal/token"
+	"url-shortener/internal/utils"
+)
+
+const (
+	// JobKind is the job kind RunJob handles
+	JobKind = "data_export"
+
+	DefaultDownloadTTL     = 24 * time.Hour
+	defaultCleanupInterval = time.Hour
+	defaultDirectoryName   = "url-shortener-exports"
+)
+
+type Emailer interface {
+	SendDataExportMail(email, downloadURL string) error
+}
+
+type ExportService struct {
+	queries      *db.Queries
+	tokenMaker   token.Maker
+	emailService Emailer
+	downloadTTL  time.Duration
+	directory    string
+}
+
+// NewExportService keeps archives in directory, or in a directory in the
+// system's temp directory when it's empty.
+func NewExportService(queries *db.Queries, tokenMaker token.Maker, emailService Emailer, downloadTTL time.Duration, directory string) *ExportService {
+	if downloadTTL <= 0 {
+		downloadTTL = DefaultDownloadTTL
+	}
+
+	if directory == "" {
+		directory = filepath.Join(os.TempDir(), defaultDirectoryName)
+	}
+
+	return &ExportService{
+		queries:      queries,
+		tokenMaker:   tokenMaker,
+		emailService: emailService,
+		downloadTTL:  downloadTTL,
+		directory:    directory,
+	}
+}
+
+type payload struct {
+	BaseURL string `json:"base_url"`
+}
+
+// JobPayload encodes the input for a JobKind job. Download links are built
+// on baseURL, since the job runs long after the request that queued it.
+func JobPayload(baseURL string) ([]byte, error) {
+	return json.Marshal(payload{BaseURL: baseURL})
+}
+
+// Result is what a finished export job reports.
+type Result struct {
+	DownloadURL string `json:"download_url"`
+	ExpiresAt   string `json:"expires_at"`
+	Size        int64  `json:"size"`
+}
+
+// RunJob builds a user's archive, stores it and emails them a download link
+// that works until the archive expires. A job that's run again after a
+// restart builds the archive again in place of the old one.
+func (s *ExportService) RunJob(ctx context.Context, job db.Job, progress func(done, total int64)) (any, error) {
+	const serviceID = "service.export.RunJob"
+
+	var args payload
+	if err := json.Unmarshal(job.Payload, &args); err != nil {
+		return nil, ErrUnknownError
+	}
+
+	user, err := s.queries.GetUser(ctx, job.UserID)
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't get user", "user", job.UserID, "error", err)
+		return nil, ErrUnknownError
+	}
+
+	path, size, err := s.writeArchive(ctx, job.ID, user, progress)
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't build archive", "job", job.ID, "error", err)
+		return nil, ErrUnknownError
+	}
+
+	expiresAt := time.Now().UTC().Add(s.downloadTTL)
+
+	err = s.queries.CreateDataExport(ctx, db.CreateDataExportParams{
+		JobID:     job.ID,
+		UserID:    user.ID,
+		Path:      path,
+		Size:      size,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't store archive", "job", job.ID, "error", err)
+		return nil, ErrUnknownError
+	}
+
+	downloadToken, _, err := s.tokenMaker.CreateScopedToken(job.ID, token.PurposeDownloadExport, s.downloadTTL)
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't sign download link", "job", job.ID, "error", err)
+		return nil, ErrUnknownError
+	}
+
+	downloadURL := strings.TrimRight(args.BaseURL, "/") + "/api/exports/" + job.ID + "?token=" + url.QueryEscape(downloadToken)
+
+	// The link is in the job result too, so a lost email isn't fatal
+	if err := s.emailService.SendDataExportMail(user.Email, downloadURL); err != nil {
+		slog.Error(serviceID, "message", "couldn't email download link", "job", job.ID, "error", err)
+	}
+
+	return Result{
+		DownloadURL: downloadURL,
+		ExpiresAt:   utils.ConvertTimeToString(expiresAt),
+		Size:        size,
+	}, nil
+}
+
+// writeArchive builds the archive in a temporary file and moves it to the
+// export's own path once it's complete, so a download never sees half an
+// archive. It returns the path and size of the archive.
+func (s *ExportService) writeArchive(ctx context.Context, jobID string, user db.User, progress func(done, total int64)) (string, int64, error) {
+	if err := os.MkdirAll(s.directory, 0o700); err != nil {
+		return "", 0, err
+	}
+
+	file, err := os.CreateTemp(s.directory, jobID+"-*.zip.tmp")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(file.Name())
+
+	if err := s.buildArchive(ctx, file, user, progress); err != nil {
+		file.Close()
+		return "", 0, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return "", 0, err
+	}
+
+	if err := file.Close(); err != nil {
+		return "", 0, err
+	}
+
+	path := filepath.Join(s.directory, jobID+".zip")
+	if err := os.Rename(file.Name(), path); err != nil {
+		return "", 0, err
+	}
+
+	return path, info.Size(), nil
+}
+
+type GetDownloadParams struct {
+	ExportID string
+	Token    string
+}
+
+// Download is an archive ready to be served. The caller closes File.
+type Download struct {
+	Filename  string
+	File      *os.File
+	CreatedAt time.Time
+}
+
+// GetDownload opens an archive for a signed download link. The token is the
+// only thing checked, so links work without logging in.
+func (s *ExportService) GetDownload(ctx context.Context, args GetDownloadParams) (Download, error) {
+	const serviceID = "service.export.GetDownload"
+
+	// The token's own expiry is enforced while it's verified
+	claims, err := s.tokenMaker.VerifyToken(args.Token)
+	if err != nil || claims.Purpose != token.PurposeDownloadExport || claims.UserID != args.ExportID {
+		return Download{}, ErrInvalidDownloadToken
+	}
+
+	dataExport, err := s.queries.GetDataExport(ctx, args.ExportID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Download{}, ErrExportNotFound
+		}
+		slog.Error(serviceID, "message", "couldn't get export", "export", args.ExportID, "error", err)
+		return Download{}, ErrUnknownError
+	}
+
+	if !time.Now().Before(dataExport.ExpiresAt) {
+		return Download{}, ErrExportExpired
+	}
+
+	file, err := os.Open(dataExport.Path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Download{}, ErrExportNotFound
+		}
+		slog.Error(serviceID, "message", "couldn't open archive", "export", args.ExportID, "error", err)
+		return Download{}, ErrUnknownError
+	}
+
+	return Download{
+		Filename:  "export-" + dataExport.CreatedAt.UTC().Format("2006-01-02") + ".zip",
+		File:      file,
+		CreatedAt: dataExport.CreatedAt,
+	}, nil
+}
+
+// RunCleanup deletes expired archives every interval until ctx is
+// cancelled.
+func (s *ExportService) RunCleanup(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultCleanupInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.DeleteExpired(ctx)
+		}
+	}
+}
+
+func (s *ExportService) DeleteExpired(ctx context.Context) {
+	const serviceID = "service.export.DeleteExpired"
+
+	paths, err := s.queries.DeleteExpiredDataExports(ctx, time.Now().UTC())
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't delete expired exports", "error", err)
+		return
+	}
+
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			slog.Error(serviceID, "message", "couldn't delete archive", "path", path, "error", err)
+		}
+	}
+
+	if len(paths) > 0 {
+		slog.Info(serviceID, "message", "deleted expired exports", "count", len(paths))
+	}
+}
@@ -0,0 +1,147 @@
+package geo
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+const defaultReloadInterval = time.Minute
+
+// Database looks locations up in an .mmdb file and picks up a new file when
+// it's replaced, so the database can be updated without a restart. Without
+// a file every location is empty. Replace the file by moving a new one over
+// it: the old one is memory mapped and mustn't change under lookups.
+type Database struct {
+	path string
+
+	mu      sync.RWMutex
+	reader  *geoip2.Reader
+	modTime time.Time
+	size    int64
+}
+
+// NewDatabase opens the database at path. A missing or broken file is
+// logged rather than returned: lookups come back empty until a good one
+// shows up.
+func NewDatabase(path string) *Database {
+	d := &Database{path: path}
+	if path != "" {
+		if err := d.reload(); err != nil {
+			slog.Error("geo.NewDatabase", "message", "couldn't open geoip database", "path", path, "error", err)
+		}
+	}
+	return d
+}
+
+func (d *Database) Locate(ip string) Location {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return Location{}
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.reader == nil {
+		return Location{}
+	}
+	// IPv4 only databases can't place IPv6 visitors
+	if parsed.To4() == nil && d.reader.Metadata().IPVersion == 4 {
+		return Location{}
+	}
+
+	// Country databases answer City lookups too, without the city
+	record, err := d.reader.City(parsed)
+	if err != nil {
+		slog.Warn("geo.Database.Locate", "message", "couldn't look up ip", "error", err)
+		return Location{}
+	}
+
+	location := Location{
+		Country: record.Country.IsoCode,
+		City:    record.City.Names["en"],
+	}
+	if len(record.Subdivisions) > 0 && location.Country != "" && record.Subdivisions[0].IsoCode != "" {
+		location.Region = location.Country + "-" + record.Subdivisions[0].IsoCode
+	}
+
+	return location
+}
+
+// RunReloader checks the file for changes every interval until ctx is
+// cancelled.
+func (d *Database) RunReloader(ctx context.Context, interval time.Duration) {
+	const serviceID = "geo.Database.RunReloader"
+
+	if d.path == "" {
+		return
+	}
+
+	if interval <= 0 {
+		interval = defaultReloadInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.mu.Lock()
+			if d.reader != nil {
+				d.reader.Close()
+				d.reader = nil
+			}
+			d.mu.Unlock()
+			return
+		case <-ticker.C:
+			if err := d.reload(); err != nil {
+				slog.Error(serviceID, "message", "couldn't reload geoip database", "path", d.path, "error", err)
+			}
+		}
+	}
+}
+
+// reload opens the file again if it changed since it was last opened. The
+// old database keeps answering until the new one has opened, and a file
+// that doesn't open is left for the next check.
+func (d *Database) reload() error {
+	info, err := os.Stat(d.path)
+	if err != nil {
+		return err
+	}
+
+	d.mu.RLock()
+	unchanged := d.reader != nil && info.ModTime().Equal(d.modTime) && info.Size() == d.size
+	d.mu.RUnlock()
+
+	if unchanged {
+		return nil
+	}
+
+	reader, err := geoip2.Open(d.path)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	previous := d.reader
+	d.reader = reader
+	d.modTime = info.ModTime()
+	d.size = info.Size()
+	d.mu.Unlock()
+
+	if previous != nil {
+		previous.Close()
+	}
+
+	slog.Info("geo.Database", "message", "loaded geoip database", "path", d.path, "type", reader.Metadata().DatabaseType)
+
+	return nil
+}
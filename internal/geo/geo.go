@@ -0,0 +1,18 @@
+// Package geo finds out where visitors are from a local MaxMind or DB-IP
+// database in the .mmdb format. Nothing is ever sent to an online service.
+package geo
+
+// Location is where an IP address is as far as the database knows. Fields
+// it doesn't know are empty.
+type Location struct {
+	// ISO 3166-1 alpha-2 code, like "US"
+	Country string
+	// ISO 3166-2 code of the largest subdivision, like "US-CA"
+	Region string
+	// English name
+	City string
+}
+
+type Locator interface {
+	Locate(ip string) Location
+}
@@ -0,0 +1,9 @@
+package job
+
+import "errors"
+
+var (
+	ErrJobNotFound    = errors.New("job not found")
+	ErrUnknownJobKind = errors.New("unknown job kind")
+	ErrUnknownError   = errors.New("something went wrong")
+)
@@ -0,0 +1,47 @@
+package job
+
+import (
+	"encoding/json"
+	db "url-shortener/db/sqlc"
+	"url-shortener/internal/utils"
+)
+
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+type Job struct {
+	ID         string          `json:"id"`
+	Kind       string          `json:"kind"`
+	Status     string          `json:"status"`
+	Progress   int64           `json:"progress"`
+	Total      int64           `json:"total"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	UpdatedAt  string          `json:"updated_at"`
+	CreatedAt  string          `json:"created_at"`
+	FinishedAt string          `json:"finished_at,omitempty"`
+}
+
+func fromDBJob(dbJob db.Job) Job {
+	job := Job{
+		ID:        dbJob.ID,
+		Kind:      dbJob.Kind,
+		Status:    dbJob.Status,
+		Progress:  dbJob.Progress,
+		Total:     dbJob.Total,
+		Error:     dbJob.Error,
+		UpdatedAt: utils.ConvertTimeToString(dbJob.UpdatedAt),
+		CreatedAt: utils.ConvertTimeToString(dbJob.CreatedAt),
+	}
+	if dbJob.Result != "" {
+		job.Result = json.RawMessage(dbJob.Result)
+	}
+	if dbJob.FinishedAt.Valid {
+		job.FinishedAt = utils.ConvertTimeToString(dbJob.FinishedAt.Time)
+	}
+	return job
+}
@@ -0,0 +1,220 @@
+package job
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+	db "url-shortener/db/sqlc"
+	"url-shortener/internal/utils"
+)
+
+const defaultPollInterval = 5 * time.Second
+
+// Handler does the work for one kind of job, reporting how many of its items
+// are done through progress. Its result is stored as JSON.
+type Handler func(ctx context.Context, job db.Job, progress func(done, total int64)) (any, error)
+
+// JobService runs queued jobs one at a time in the background. Jobs are
+// stored, so they survive restarts: anything left running is queued again
+// when Run starts.
+type JobService struct {
+	queries  *db.Queries
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	wake     chan struct{}
+}
+
+func NewJobService(queries *db.Queries) *JobService {
+	return &JobService{
+		queries:  queries,
+		handlers: make(map[string]Handler),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Register sets the handler for jobs of the given kind.
+func (s *JobService) Register(kind string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[kind] = handler
+}
+
+type EnqueueParams struct {
+	UserID  string
+	Kind    string
+	Payload []byte
+}
+
+func (s *JobService) Enqueue(ctx context.Context, args EnqueueParams) (Job, error) {
+	const serviceID = "service.job.Enqueue"
+
+	s.mu.RLock()
+	_, ok := s.handlers[args.Kind]
+	s.mu.RUnlock()
+
+	if !ok {
+		return Job{}, ErrUnknownJobKind
+	}
+
+	createdJob, err := s.queries.CreateJob(ctx, db.CreateJobParams{
+		ID:      utils.NewULID().String(),
+		UserID:  args.UserID,
+		Kind:    args.Kind,
+		Payload: args.Payload,
+	})
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't create job", "kind", args.Kind, "error", err)
+		return Job{}, ErrUnknownError
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return fromDBJob(createdJob), nil
+}
+
+type GetJobParams struct {
+	UserID string
+	JobID  string
+}
+
+func (s *JobService) GetJob(ctx context.Context, args GetJobParams) (Job, error) {
+	const serviceID = "service.job.GetJob"
+
+	dbJob, err := s.queries.GetJob(ctx, db.GetJobParams{
+		ID:     args.JobID,
+		UserID: args.UserID,
+	})
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Job{}, ErrJobNotFound
+		}
+		slog.Error(serviceID, "message", "couldn't get job", "job", args.JobID, "error", err)
+		return Job{}, ErrUnknownError
+	}
+
+	return fromDBJob(dbJob), nil
+}
+
+// Run works through queued jobs until ctx is cancelled, checking for new
+// ones every interval and whenever one is enqueued.
+func (s *JobService) Run(ctx context.Context, interval time.Duration) {
+	const serviceID = "service.job.Run"
+
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	requeued, err := s.queries.RequeueRunningJobs(ctx)
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't requeue interrupted jobs", "error", err)
+	} else if requeued > 0 {
+		slog.Info(serviceID, "message", "requeued interrupted jobs", "count", requeued)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for s.RunNext(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// RunNext claims the oldest queued job and runs it. It reports whether there
+// was a job to run.
+func (s *JobService) RunNext(ctx context.Context) bool {
+	const serviceID = "service.job.RunNext"
+
+	if ctx.Err() != nil {
+		return false
+	}
+
+	dbJob, err := s.queries.ClaimNextJob(ctx)
+
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.Error(serviceID, "message", "couldn't claim job", "error", err)
+		}
+		return false
+	}
+
+	s.mu.RLock()
+	handler, ok := s.handlers[dbJob.Kind]
+	s.mu.RUnlock()
+
+	if !ok {
+		s.fail(ctx, dbJob, ErrUnknownJobKind)
+		return true
+	}
+
+	progress := func(done, total int64) {
+		err := s.queries.UpdateJobProgress(ctx, db.UpdateJobProgressParams{
+			Progress: done,
+			Total:    total,
+			ID:       dbJob.ID,
+		})
+		if err != nil {
+			slog.Error(serviceID, "message", "couldn't update job progress", "job", dbJob.ID, "error", err)
+		}
+	}
+
+	result, err := handler(ctx, dbJob, progress)
+
+	if err != nil {
+		// Shutting down mid-job leaves it running, so it's picked up again
+		// on the next start
+		if ctx.Err() != nil {
+			return false
+		}
+		s.fail(ctx, dbJob, err)
+		return true
+	}
+
+	encoded, err := json.Marshal(result)
+
+	if err != nil {
+		s.fail(ctx, dbJob, err)
+		return true
+	}
+
+	err = s.queries.CompleteJob(ctx, db.CompleteJobParams{
+		Result: string(encoded),
+		ID:     dbJob.ID,
+	})
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't complete job", "job", dbJob.ID, "error", err)
+	}
+
+	return true
+}
+
+func (s *JobService) fail(ctx context.Context, dbJob db.Job, jobErr error) {
+	const serviceID = "service.job.fail"
+
+	slog.Error(serviceID, "message", "job failed", "job", dbJob.ID, "kind", dbJob.Kind, "error", jobErr)
+
+	err := s.queries.FailJob(ctx, db.FailJobParams{
+		Error: jobErr.Error(),
+		ID:    dbJob.ID,
+	})
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't mark job failed", "job", dbJob.ID, "error", err)
+	}
+}
@@ -0,0 +1,90 @@
+package link
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	minAliasLength = 3
+	maxAliasLength = 64
+	// Keeps aliases unique on each domain
+	aliasIndex = "idx_links_domain_pretty_id"
+)
+
+var aliasPattern = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9_-]*[a-z0-9])?$`)
+
+// Aliases live in the same top-level namespace as the app's own routes and
+// files, so anything we serve (or might serve) from `/<word>` is off limits.
+var reservedAliases = map[string]struct{}{
+	"about": {}, "account": {}, "admin": {}, "api": {}, "app": {}, "assets": {},
+	"auth": {}, "billing": {}, "blog": {}, "dashboard": {}, "docs": {}, "health": {},
+	"help": {}, "home": {}, "index": {}, "links": {}, "login": {}, "logout": {},
+	"new": {}, "privacy": {}, "register": {}, "reset-password": {}, "root": {}, "settings": {},
+	"signin": {}, "signup": {}, "static": {}, "status": {}, "support": {}, "terms": {},
+	"user": {}, "users": {}, "verify": {}, "waitlist": {}, "www": {},
+}
+
+// blockedAliasWords are rejected as a whole word of an alias, words being
+// separated by `-` or `_`, after undoing common character substitutions.
+// Matching inside words would also reject places and things like
+// "scunthorpe" or "flame-retardant".
+var blockedAliasWords = map[string]struct{}{
+	"asshole": {}, "bitch": {}, "bollock": {}, "cunt": {}, "dickhead": {}, "motherfucker": {},
+	"nigger": {}, "nigga": {}, "porn": {}, "retard": {}, "shit": {}, "slut": {}, "twat": {},
+	"wank": {}, "wanker": {}, "whore": {},
+}
+
+// blockedAliasStems are rejected anywhere inside an alias. Only terms no
+// innocent word contains belong here.
+var blockedAliasStems = []string{"fuck"}
+
+var leetReplacer = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s",
+)
+
+// NormalizeAlias applies the alias policy: aliases are case-insensitive and
+// stored lower-cased, limited to letters, digits, `-` and `_`, and can't be
+// a reserved word or contain blocked words.
+func NormalizeAlias(alias string) (string, error) {
+	alias = strings.ToLower(strings.TrimSpace(alias))
+
+	if len(alias) < minAliasLength || len(alias) > maxAliasLength || !aliasPattern.MatchString(alias) {
+		return "", ErrInvalidAlias
+	}
+
+	if _, ok := reservedAliases[alias]; ok {
+		return "", ErrReservedAlias
+	}
+
+	if isBlockedAlias(alias) {
+		return "", ErrBlockedAlias
+	}
+
+	return alias, nil
+}
+
+func isBlockedAlias(alias string) bool {
+	deobfuscated := leetReplacer.Replace(alias)
+
+	for _, stem := range blockedAliasStems {
+		if strings.Contains(alias, stem) || strings.Contains(deobfuscated, stem) {
+			return true
+		}
+	}
+
+	words := strings.FieldsFunc(deobfuscated, func(r rune) bool {
+		return r == '-' || r == '_'
+	})
+	for _, word := range words {
+		if _, ok := blockedAliasWords[word]; ok {
+			return true
+		}
+		// Plurals
+		if _, ok := blockedAliasWords[strings.TrimSuffix(word, "s")]; ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,63 @@
+package link
+
+import (
+	"context"
+	"log/slog"
+	db "url-shortener/db/sqlc"
+)
+
+const DefaultBulkMaxItems = 500
+
+type BulkCreateLinksParams struct {
+	UserID string
+	Items  []CreateLinkParams
+}
+
+// BulkResult is the outcome of one item of a bulk create. Exactly one of
+// Link and Err is set.
+type BulkResult struct {
+	Link Link
+	Err  error
+}
+
+// BulkCreateLinks creates every valid item in a single transaction. Items
+// that fail on their own come back with an error in their result and don't
+// stop the rest; only database failures abort the whole batch.
+func (s *LinkService) BulkCreateLinks(ctx context.Context, args BulkCreateLinksParams) ([]BulkResult, error) {
+	const serviceID = "service.link.BulkCreateLinks"
+
+	results := make([]BulkResult, len(args.Items))
+	inputs := make([]newLink, len(args.Items))
+
+	for i, item := range args.Items {
+		item.UserID = args.UserID
+		inputs[i], results[i].Err = prepareLink(item)
+	}
+
+	err := s.store.ExecTx(ctx, func(q *db.Queries) error {
+		for i, input := range inputs {
+			if results[i].Err != nil {
+				continue
+			}
+
+			createdLink, err := s.insertLink(ctx, q, input)
+
+			switch err {
+			case nil:
+				results[i].Link = fromDBLink(createdLink)
+			case ErrAliasTaken, ErrCodeSpaceExhausted, ErrGeneratingCode, ErrDomainNotFound, ErrDomainNotVerified:
+				results[i].Err = err
+			default:
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't create links", "count", len(args.Items), "error", err)
+		return nil, ErrCreatingLink
+	}
+
+	return results, nil
+}
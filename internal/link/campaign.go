@@ -0,0 +1,360 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+	db "url-shortener/db/sqlc"
+	"url-shortener/internal/utils"
+)
+
+const maxCampaignNameLength = 100
+
+type Campaign struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	StartsAt string `json:"starts_at"`
+	EndsAt   string `json:"ends_at"`
+	// Defaults for the UTM values of the campaign's links
+	UTM       UTM    `json:"utm"`
+	LinkCount int64  `json:"link_count"`
+	UpdatedAt string `json:"updated_at"`
+	CreatedAt string `json:"created_at"`
+}
+
+func fromDBCampaign(dbCampaign db.Campaign) Campaign {
+	return Campaign{
+		ID:       dbCampaign.ID,
+		Name:     dbCampaign.Name,
+		StartsAt: convertNullTimeToString(dbCampaign.StartsAt),
+		EndsAt:   convertNullTimeToString(dbCampaign.EndsAt),
+		UTM: UTM{
+			Source:   dbCampaign.UtmSource.String,
+			Medium:   dbCampaign.UtmMedium.String,
+			Campaign: dbCampaign.UtmCampaign.String,
+			Term:     dbCampaign.UtmTerm.String,
+			Content:  dbCampaign.UtmContent.String,
+		},
+		UpdatedAt: utils.ConvertTimeToString(dbCampaign.UpdatedAt),
+		CreatedAt: utils.ConvertTimeToString(dbCampaign.CreatedAt),
+	}
+}
+
+func normalizeCampaignName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxCampaignNameLength {
+		return "", ErrInvalidCampaignName
+	}
+	return name, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t.UTC(), Valid: !t.IsZero()}
+}
+
+func (s *LinkService) ListCampaigns(ctx context.Context, userID string) ([]Campaign, error) {
+	const serviceID = "service.link.ListCampaigns"
+
+	rows, err := s.queries.ListCampaigns(ctx, userID)
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't list campaigns", "error", err)
+		return nil, ErrUnknownError
+	}
+
+	campaigns := make([]Campaign, 0, len(rows))
+	for _, row := range rows {
+		campaign := fromDBCampaign(db.Campaign{
+			ID:          row.ID,
+			UserID:      row.UserID,
+			Name:        row.Name,
+			StartsAt:    row.StartsAt,
+			EndsAt:      row.EndsAt,
+			UtmSource:   row.UtmSource,
+			UtmMedium:   row.UtmMedium,
+			UtmCampaign: row.UtmCampaign,
+			UtmTerm:     row.UtmTerm,
+			UtmContent:  row.UtmContent,
+			UpdatedAt:   row.UpdatedAt,
+			CreatedAt:   row.CreatedAt,
+		})
+		campaign.LinkCount = row.LinkCount
+		campaigns = append(campaigns, campaign)
+	}
+
+	return campaigns, nil
+}
+
+type CreateCampaignParams struct {
+	UserID string
+	Name   string
+	// Optional. Stats default to this window.
+	StartsAt time.Time
+	EndsAt   time.Time
+	UTM      UTM
+}
+
+func (s *LinkService) CreateCampaign(ctx context.Context, args CreateCampaignParams) (Campaign, error) {
+	const serviceID = "service.link.CreateCampaign"
+
+	name, err := normalizeCampaignName(args.Name)
+	if err != nil {
+		return Campaign{}, err
+	}
+
+	if !args.StartsAt.IsZero() && !args.EndsAt.IsZero() && !args.EndsAt.After(args.StartsAt) {
+		return Campaign{}, ErrInvalidCampaignDates
+	}
+
+	utm, err := normalizeUTM(args.UTM)
+	if err != nil {
+		return Campaign{}, err
+	}
+
+	createdCampaign, err := s.queries.CreateCampaign(ctx, db.CreateCampaignParams{
+		ID:          utils.NewULID().String(),
+		UserID:      args.UserID,
+		Name:        name,
+		StartsAt:    nullTime(args.StartsAt),
+		EndsAt:      nullTime(args.EndsAt),
+		UtmSource:   nullString(utm.Source),
+		UtmMedium:   nullString(utm.Medium),
+		UtmCampaign: nullString(utm.Campaign),
+		UtmTerm:     nullString(utm.Term),
+		UtmContent:  nullString(utm.Content),
+	})
+
+	if err != nil {
+		return Campaign{}, campaignError(serviceID, err)
+	}
+
+	return fromDBCampaign(createdCampaign), nil
+}
+
+type UpdateCampaignParams struct {
+	UserID     string
+	CampaignID string
+	// Nil fields are left alone. Zero times remove the date.
+	Name     *string
+	StartsAt *time.Time
+	EndsAt   *time.Time
+	// Replaces all of the campaign's UTM values
+	UTM *UTM
+}
+
+func (s *LinkService) UpdateCampaign(ctx context.Context, args UpdateCampaignParams) (Campaign, error) {
+	const serviceID = "service.link.UpdateCampaign"
+
+	var updatedCampaign db.Campaign
+
+	err := s.store.ExecTx(ctx, func(q *db.Queries) error {
+		current, err := q.GetCampaign(ctx, db.GetCampaignParams{ID: args.CampaignID, UserID: args.UserID})
+		if err != nil {
+			return err
+		}
+
+		params := db.UpdateCampaignParams{
+			Name:        current.Name,
+			StartsAt:    current.StartsAt,
+			EndsAt:      current.EndsAt,
+			UtmSource:   current.UtmSource,
+			UtmMedium:   current.UtmMedium,
+			UtmCampaign: current.UtmCampaign,
+			UtmTerm:     current.UtmTerm,
+			UtmContent:  current.UtmContent,
+			ID:          current.ID,
+			UserID:      args.UserID,
+		}
+
+		if args.Name != nil {
+			if params.Name, err = normalizeCampaignName(*args.Name); err != nil {
+				return err
+			}
+		}
+		if args.StartsAt != nil {
+			params.StartsAt = nullTime(*args.StartsAt)
+		}
+		if args.EndsAt != nil {
+			params.EndsAt = nullTime(*args.EndsAt)
+		}
+		// Checked against the dates the campaign ends up with
+		if params.StartsAt.Valid && params.EndsAt.Valid && !params.EndsAt.Time.After(params.StartsAt.Time) {
+			return ErrInvalidCampaignDates
+		}
+		if args.UTM != nil {
+			utm, err := normalizeUTM(*args.UTM)
+			if err != nil {
+				return err
+			}
+			params.UtmSource = nullString(utm.Source)
+			params.UtmMedium = nullString(utm.Medium)
+			params.UtmCampaign = nullString(utm.Campaign)
+			params.UtmTerm = nullString(utm.Term)
+			params.UtmContent = nullString(utm.Content)
+		}
+
+		updatedCampaign, err = q.UpdateCampaign(ctx, params)
+		return err
+	})
+
+	if err != nil {
+		return Campaign{}, campaignError(serviceID, err)
+	}
+
+	return fromDBCampaign(updatedCampaign), nil
+}
+
+type DeleteCampaignParams struct {
+	UserID     string
+	CampaignID string
+}
+
+// DeleteCampaign removes a campaign but keeps its links, which stop getting
+// its UTM values.
+func (s *LinkService) DeleteCampaign(ctx context.Context, args DeleteCampaignParams) error {
+	const serviceID = "service.link.DeleteCampaign"
+
+	err := s.store.ExecTx(ctx, func(q *db.Queries) error {
+		current, err := q.GetCampaign(ctx, db.GetCampaignParams{ID: args.CampaignID, UserID: args.UserID})
+		if err != nil {
+			return err
+		}
+
+		if err := q.DetachCampaignLinks(ctx, sql.NullString{String: current.ID, Valid: true}); err != nil {
+			return err
+		}
+
+		return q.DeleteCampaign(ctx, db.DeleteCampaignParams{ID: current.ID, UserID: args.UserID})
+	})
+
+	if err != nil {
+		return campaignError(serviceID, err)
+	}
+
+	return nil
+}
+
+type ListCampaignLinksParams struct {
+	UserID     string
+	CampaignID string
+}
+
+// ListCampaignLinks returns the campaign's links that aren't in the trash,
+// newest first.
+func (s *LinkService) ListCampaignLinks(ctx context.Context, args ListCampaignLinksParams) ([]Link, error) {
+	const serviceID = "service.link.ListCampaignLinks"
+
+	_, err := s.queries.GetCampaign(ctx, db.GetCampaignParams{ID: args.CampaignID, UserID: args.UserID})
+	if err != nil {
+		return nil, campaignError(serviceID, err)
+	}
+
+	dbLinks, err := s.queries.ListCampaignLinks(ctx, sql.NullString{String: args.CampaignID, Valid: true})
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't list campaign links", "campaign", args.CampaignID, "error", err)
+		return nil, ErrUnknownError
+	}
+
+	links := make([]Link, 0, len(dbLinks))
+	for _, dbLink := range dbLinks {
+		links = append(links, fromDBLink(dbLink))
+	}
+
+	return links, nil
+}
+
+type AddCampaignLinksParams struct {
+	UserID     string
+	CampaignID string
+	LinkIDs    []string
+}
+
+// AddCampaignLinks puts many links in a campaign in one transaction. Links
+// can only be in one campaign, so they leave the one they were in.
+func (s *LinkService) AddCampaignLinks(ctx context.Context, args AddCampaignLinksParams) (LinkBatchResult, error) {
+	const serviceID = "service.link.AddCampaignLinks"
+
+	result := LinkBatchResult{NotFound: []string{}}
+
+	err := s.store.ExecTx(ctx, func(q *db.Queries) error {
+		if _, err := q.GetCampaign(ctx, db.GetCampaignParams{ID: args.CampaignID, UserID: args.UserID}); err != nil {
+			return err
+		}
+
+		for _, linkID := range args.LinkIDs {
+			added, err := q.SetLinkCampaign(ctx, db.SetLinkCampaignParams{
+				CampaignID: sql.NullString{String: args.CampaignID, Valid: true},
+				ID:         linkID,
+				UserID:     args.UserID,
+			})
+			if err != nil {
+				return err
+			}
+			if added == 0 {
+				result.NotFound = append(result.NotFound, linkID)
+				continue
+			}
+			result.Updated++
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return LinkBatchResult{}, campaignError(serviceID, err)
+	}
+
+	return result, nil
+}
+
+type RemoveCampaignLinkParams struct {
+	UserID     string
+	CampaignID string
+	LinkID     string
+}
+
+func (s *LinkService) RemoveCampaignLink(ctx context.Context, args RemoveCampaignLinkParams) error {
+	const serviceID = "service.link.RemoveCampaignLink"
+
+	err := s.store.ExecTx(ctx, func(q *db.Queries) error {
+		if _, err := q.GetCampaign(ctx, db.GetCampaignParams{ID: args.CampaignID, UserID: args.UserID}); err != nil {
+			return err
+		}
+
+		removed, err := q.DetachCampaignLink(ctx, db.DetachCampaignLinkParams{
+			ID:         args.LinkID,
+			CampaignID: sql.NullString{String: args.CampaignID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if removed == 0 {
+			return ErrLinkNotFound
+		}
+		return nil
+	})
+
+	if err != nil {
+		return campaignError(serviceID, err)
+	}
+
+	return nil
+}
+
+// campaignError maps errors from inside a campaign transaction to the
+// errors the service exposes.
+func campaignError(serviceID string, err error) error {
+	switch {
+	case err == sql.ErrNoRows:
+		return ErrCampaignNotFound
+	case err == ErrInvalidCampaignName, err == ErrInvalidCampaignDates, err == ErrInvalidUTM, err == ErrLinkNotFound:
+		return err
+	case utils.IsConflictError(err):
+		return ErrCampaignExists
+	}
+	slog.Error(serviceID, "message", "couldn't change campaign", "error", err)
+	return ErrUnknownError
+}
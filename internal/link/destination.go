@@ -0,0 +1,68 @@
+package link
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+const maxDestinationLength = 2048
+
+// NormalizeURL validates a destination URL and rewrites it into a canonical
+// form so the same page shortened twice compares equal.
+func NormalizeURL(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+
+	if rawURL == "" || len(rawURL) > maxDestinationLength {
+		return "", ErrInvalidURL
+	}
+
+	u, err := url.Parse(rawURL)
+
+	if err != nil {
+		return "", ErrInvalidURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", ErrInvalidURL
+	}
+
+	host := strings.ToLower(u.Hostname())
+
+	if host == "" {
+		return "", ErrInvalidURL
+	}
+
+	port := u.Port()
+
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+
+	if port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		// IPv6 literals need their brackets back
+		u.Host = "[" + host + "]"
+	} else {
+		u.Host = host
+	}
+
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	return u.String(), nil
+}
+
+// DestinationHost returns the lower-cased host of a normalized destination,
+// used to filter links by the site they point to.
+func DestinationHost(destination string) string {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
@@ -0,0 +1,313 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+	db "url-shortener/db/sqlc"
+	"url-shortener/internal/utils"
+)
+
+const (
+	maxHostnameLength = 253
+	maxLabelLength    = 63
+
+	// Owners prove they control a hostname by publishing
+	// `url-shortener-verification=<token>` as a TXT record on
+	// `_url-shortener.<hostname>`.
+	verificationRecordPrefix = "_url-shortener."
+	verificationValuePrefix  = "url-shortener-verification="
+	verificationTokenLength  = 32
+	verificationTimeout      = 5 * time.Second
+)
+
+// TXTResolver looks up DNS TXT records. *net.Resolver is one.
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+type Domain struct {
+	ID         string `json:"id"`
+	Hostname   string `json:"hostname"`
+	Verified   bool   `json:"verified"`
+	VerifiedAt string `json:"verified_at"`
+	// The record to publish before verifying the domain
+	Verification DomainVerification `json:"verification"`
+	UpdatedAt    string             `json:"updated_at"`
+	CreatedAt    string             `json:"created_at"`
+}
+
+type DomainVerification struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func fromDBDomain(dbDomain db.Domain) Domain {
+	return Domain{
+		ID:         dbDomain.ID,
+		Hostname:   dbDomain.Hostname,
+		Verified:   dbDomain.VerifiedAt.Valid,
+		VerifiedAt: convertNullTimeToString(dbDomain.VerifiedAt),
+		Verification: DomainVerification{
+			Type:  "TXT",
+			Name:  verificationRecordPrefix + dbDomain.Hostname,
+			Value: verificationValuePrefix + dbDomain.VerificationToken,
+		},
+		UpdatedAt: utils.ConvertTimeToString(dbDomain.UpdatedAt),
+		CreatedAt: utils.ConvertTimeToString(dbDomain.CreatedAt),
+	}
+}
+
+// NormalizeHostname lowercases a hostname and checks it's a domain name,
+// not an IP address, with at least two labels.
+func NormalizeHostname(hostname string) (string, error) {
+	hostname = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(hostname)), ".")
+
+	if hostname == "" || len(hostname) > maxHostnameLength || net.ParseIP(hostname) != nil {
+		return "", ErrInvalidHostname
+	}
+
+	labels := strings.Split(hostname, ".")
+	if len(labels) < 2 {
+		return "", ErrInvalidHostname
+	}
+
+	for _, label := range labels {
+		if label == "" || len(label) > maxLabelLength || label[0] == '-' || label[len(label)-1] == '-' {
+			return "", ErrInvalidHostname
+		}
+		for _, r := range label {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+				return "", ErrInvalidHostname
+			}
+		}
+	}
+
+	return hostname, nil
+}
+
+// domainForHost finds the verified domain requests for hostname are served
+// on. Hosts that aren't a verified domain get the shared one, which is a
+// NULL domain ID.
+func (s *LinkService) domainForHost(ctx context.Context, hostname string) (sql.NullString, error) {
+	hostname = strings.TrimSuffix(strings.ToLower(hostname), ".")
+	if hostname == "" {
+		return sql.NullString{}, nil
+	}
+
+	domain, err := s.queries.GetVerifiedDomainByHostname(ctx, hostname)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return sql.NullString{}, nil
+		}
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: domain.ID, Valid: true}, nil
+}
+
+func (s *LinkService) ListDomains(ctx context.Context, userID string) ([]Domain, error) {
+	const serviceID = "service.link.ListDomains"
+
+	dbDomains, err := s.queries.ListDomains(ctx, userID)
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't list domains", "error", err)
+		return nil, ErrUnknownError
+	}
+
+	domains := make([]Domain, 0, len(dbDomains))
+	for _, dbDomain := range dbDomains {
+		domains = append(domains, fromDBDomain(dbDomain))
+	}
+
+	return domains, nil
+}
+
+type GetDomainParams struct {
+	UserID   string
+	DomainID string
+}
+
+func (s *LinkService) GetDomain(ctx context.Context, args GetDomainParams) (Domain, error) {
+	const serviceID = "service.link.GetDomain"
+
+	dbDomain, err := s.queries.GetDomain(ctx, db.GetDomainParams{ID: args.DomainID, UserID: args.UserID})
+
+	if err != nil {
+		return Domain{}, domainError(serviceID, err)
+	}
+
+	return fromDBDomain(dbDomain), nil
+}
+
+type CreateDomainParams struct {
+	UserID   string
+	Hostname string
+}
+
+// CreateDomain adds an unverified domain. It can't be picked for links
+// until VerifyDomain has found its TXT record.
+func (s *LinkService) CreateDomain(ctx context.Context, args CreateDomainParams) (Domain, error) {
+	const serviceID = "service.link.CreateDomain"
+
+	hostname, err := NormalizeHostname(args.Hostname)
+	if err != nil {
+		return Domain{}, err
+	}
+
+	// Someone else may add it too, but only one account can verify it
+	_, err = s.queries.GetVerifiedDomainByHostname(ctx, hostname)
+	if err == nil {
+		return Domain{}, ErrDomainExists
+	}
+	if err != sql.ErrNoRows {
+		return Domain{}, domainError(serviceID, err)
+	}
+
+	token, err := utils.GenerateBase62(verificationTokenLength)
+	if err != nil {
+		return Domain{}, domainError(serviceID, err)
+	}
+
+	createdDomain, err := s.queries.CreateDomain(ctx, db.CreateDomainParams{
+		ID:                utils.NewULID().String(),
+		UserID:            args.UserID,
+		Hostname:          hostname,
+		VerificationToken: token,
+	})
+
+	if err != nil {
+		return Domain{}, domainError(serviceID, err)
+	}
+
+	return fromDBDomain(createdDomain), nil
+}
+
+type VerifyDomainParams struct {
+	UserID   string
+	DomainID string
+}
+
+// VerifyDomain looks for the domain's TXT record and, once it's there,
+// starts routing the hostname's requests to the user's links.
+func (s *LinkService) VerifyDomain(ctx context.Context, args VerifyDomainParams) (Domain, error) {
+	const serviceID = "service.link.VerifyDomain"
+
+	current, err := s.queries.GetDomain(ctx, db.GetDomainParams{ID: args.DomainID, UserID: args.UserID})
+	if err != nil {
+		return Domain{}, domainError(serviceID, err)
+	}
+
+	if current.VerifiedAt.Valid {
+		return fromDBDomain(current), nil
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, verificationTimeout)
+	defer cancel()
+
+	verification := fromDBDomain(current).Verification
+	records, err := s.resolver.LookupTXT(lookupCtx, verification.Name)
+
+	if err != nil {
+		// Missing records come back as errors too
+		slog.Info(serviceID, "message", "couldn't look up verification record", "hostname", current.Hostname, "error", err)
+		return Domain{}, ErrDomainVerificationFailed
+	}
+
+	found := false
+	for _, record := range records {
+		if strings.TrimSpace(record) == verification.Value {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return Domain{}, ErrDomainVerificationFailed
+	}
+
+	verifiedDomain, err := s.queries.VerifyDomain(ctx, db.VerifyDomainParams{ID: current.ID, UserID: args.UserID})
+
+	if err != nil {
+		return Domain{}, domainError(serviceID, err)
+	}
+
+	return fromDBDomain(verifiedDomain), nil
+}
+
+type DeleteDomainParams struct {
+	UserID   string
+	DomainID string
+}
+
+// DeleteDomain removes a domain that no links are on anymore. Links can't
+// move to another domain without their aliases clashing, so they have to be
+// purged first.
+func (s *LinkService) DeleteDomain(ctx context.Context, args DeleteDomainParams) error {
+	const serviceID = "service.link.DeleteDomain"
+
+	err := s.store.ExecTx(ctx, func(q *db.Queries) error {
+		current, err := q.GetDomain(ctx, db.GetDomainParams{ID: args.DomainID, UserID: args.UserID})
+		if err != nil {
+			return err
+		}
+
+		linkCount, err := q.CountDomainLinks(ctx, sql.NullString{String: current.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+		if linkCount > 0 {
+			return ErrDomainInUse
+		}
+
+		return q.DeleteDomain(ctx, db.DeleteDomainParams{ID: current.ID, UserID: args.UserID})
+	})
+
+	if err != nil {
+		return domainError(serviceID, err)
+	}
+
+	return nil
+}
+
+// checkLinkDomain makes sure a new link's domain is one of the user's
+// verified domains. It runs inside the create transaction.
+func checkLinkDomain(ctx context.Context, q *db.Queries, userID string, domainID sql.NullString) error {
+	if !domainID.Valid {
+		return nil
+	}
+
+	domain, err := q.GetDomain(ctx, db.GetDomainParams{ID: domainID.String, UserID: userID})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrDomainNotFound
+		}
+		return err
+	}
+
+	if !domain.VerifiedAt.Valid {
+		return ErrDomainNotVerified
+	}
+
+	return nil
+}
+
+// domainError maps errors from domain queries to the errors the service
+// exposes.
+func domainError(serviceID string, err error) error {
+	switch {
+	case err == sql.ErrNoRows:
+		return ErrDomainNotFound
+	case err == ErrDomainInUse:
+		return err
+	case utils.IsConflictError(err):
+		return ErrDomainExists
+	}
+	slog.Error(serviceID, "message", "couldn't change domain", "error", err)
+	return ErrUnknownError
+}
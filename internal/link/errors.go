@@ -0,0 +1,57 @@
+package link
+
+import "errors"
+
+var (
+	ErrLinkNotFound             = errors.New("link not found")
+	ErrInvalidURL               = errors.New("invalid destination url")
+	ErrCreatingLink             = errors.New("error creating link")
+	ErrCodeSpaceExhausted       = errors.New("no short codes left to hand out")
+	ErrGeneratingCode           = errors.New("couldn't generate a unique short code")
+	ErrInvalidAlias             = errors.New("alias must be 3-64 letters, numbers, dashes or underscores")
+	ErrReservedAlias            = errors.New("alias is reserved")
+	ErrBlockedAlias             = errors.New("alias isn't allowed")
+	ErrAliasTaken               = errors.New("alias is already taken")
+	ErrInvalidCursor            = errors.New("invalid cursor")
+	ErrInvalidSort              = errors.New("invalid sort")
+	ErrLinkExpired              = errors.New("link has expired")
+	ErrLinkNotActive            = errors.New("link isn't active yet")
+	ErrInvalidActivation        = errors.New("activation must be in the future and before the link expires")
+	ErrInvalidExpiry            = errors.New("expiry must be in the future")
+	ErrInvalidFallbackURL       = errors.New("invalid fallback url")
+	ErrInvalidPassword          = errors.New("link password must be 4-128 characters")
+	ErrRevisionNotFound         = errors.New("revision not found")
+	ErrLinkNotTrashed           = errors.New("link isn't in the trash")
+	ErrRestoreWindowPassed      = errors.New("link can no longer be restored")
+	ErrInvalidImport            = errors.New("file isn't a csv export we can read")
+	ErrInvalidImportFormat      = errors.New("import format must be auto, bitly, rebrandly or tinyurl")
+	ErrInvalidTagName           = errors.New("tag names must be 1-50 characters without commas")
+	ErrTagNotFound              = errors.New("tag not found")
+	ErrTagExists                = errors.New("tag already exists")
+	ErrInvalidFolderName        = errors.New("folder names must be 1-100 characters without slashes")
+	ErrFolderNotFound           = errors.New("folder not found")
+	ErrFolderExists             = errors.New("a folder with that name already exists here")
+	ErrFolderCycle              = errors.New("a folder can't be moved into itself")
+	ErrInvalidSearchQuery       = errors.New("search needs at least one word or number")
+	ErrInvalidPreview           = errors.New("preview title must be up to 200 characters and description up to 500")
+	ErrInvalidPreviewImage      = errors.New("preview image must be an http or https url")
+	ErrInvalidUTM               = errors.New("utm values must be up to 200 characters")
+	ErrInvalidPassthrough       = errors.New("query passthrough must be drop, merge or override")
+	ErrInvalidRule              = errors.New("rules need at least one condition, using known os, device, country and region values")
+	ErrInvalidSchedule          = errors.New("schedules need a known timezone, starts_at before ends_at, and both or neither of daily_start and daily_end as HH:MM")
+	ErrTooManyRules             = errors.New("links can have at most 20 rules")
+	ErrVariantNotFound          = errors.New("variant not found")
+	ErrTooManyVariants          = errors.New("links can have at most 10 variants")
+	ErrInvalidVariantWeight     = errors.New("variant weight must be 0-1000")
+	ErrInvalidCampaignName      = errors.New("campaign names must be 1-100 characters")
+	ErrInvalidCampaignDates     = errors.New("campaign must end after it starts")
+	ErrCampaignNotFound         = errors.New("campaign not found")
+	ErrCampaignExists           = errors.New("a campaign with that name already exists")
+	ErrInvalidHostname          = errors.New("hostname must be a domain name like go.example.com")
+	ErrDomainNotFound           = errors.New("domain not found")
+	ErrDomainExists             = errors.New("domain has already been added")
+	ErrDomainNotVerified        = errors.New("domain hasn't been verified yet")
+	ErrDomainVerificationFailed = errors.New("couldn't find the domain's verification TXT record")
+	ErrDomainInUse              = errors.New("domain still has links on it")
+	ErrUnknownError             = errors.New("something went wrong")
+)
@@ -0,0 +1,84 @@
+package link
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	db "url-shortener/db/sqlc"
+)
+
+const defaultExpirySweepInterval = time.Minute
+
+// isExpired is checked on every redirect so links stop working the moment
+// they expire, not when the sweeper next runs. The click budget is only
+// settled by SpendClick, since other visitors may be spending it too.
+func isExpired(link db.Link, now time.Time) bool {
+	if link.Status == StatusExpired {
+		return true
+	}
+	if link.ExpiresAt.Valid && !now.Before(link.ExpiresAt.Time) {
+		return true
+	}
+	return link.MaxClicks.Valid && link.ClickCount >= link.MaxClicks.Int64
+}
+
+// SpendClick takes a click off the budget of a link that's being followed.
+// It's a single conditional update, so visitors arriving at once can't
+// follow the link more often than its budget allows. Links without a budget
+// have nothing to spend.
+func (s *LinkService) SpendClick(ctx context.Context, l Link) error {
+	const serviceID = "service.link.SpendClick"
+
+	if l.MaxClicks <= 0 {
+		return nil
+	}
+
+	spent, err := s.queries.SpendLinkClick(ctx, l.ID)
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't spend click", "link", l.ID, "error", err)
+		return ErrUnknownError
+	}
+	if spent == 0 {
+		return ErrLinkExpired
+	}
+
+	return nil
+}
+
+// RunExpirySweeper marks expired links every interval until ctx is
+// cancelled, which keeps their status accurate in listings.
+func (s *LinkService) RunExpirySweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultExpirySweepInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.ExpireLinks(ctx)
+		}
+	}
+}
+
+func (s *LinkService) ExpireLinks(ctx context.Context) {
+	const serviceID = "service.link.ExpireLinks"
+
+	expired, err := s.queries.ExpireLinks(ctx, time.Now().UTC())
+
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Error(serviceID, "message", "couldn't expire links", "error", err)
+		}
+		return
+	}
+
+	if expired > 0 {
+		slog.Info(serviceID, "message", "expired links", "count", expired)
+	}
+}
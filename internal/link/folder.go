@@ -0,0 +1,279 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+	db "url-shortener/db/sqlc"
+	"url-shortener/internal/utils"
+)
+
+const maxFolderNameLength = 100
+
+type Folder struct {
+	ID        string `json:"id"`
+	ParentID  string `json:"parent_id"`
+	Name      string `json:"name"`
+	LinkCount int64  `json:"link_count"`
+	UpdatedAt string `json:"updated_at"`
+	CreatedAt string `json:"created_at"`
+}
+
+func fromDBFolder(dbFolder db.Folder) Folder {
+	return Folder{
+		ID:        dbFolder.ID,
+		ParentID:  dbFolder.ParentID.String,
+		Name:      dbFolder.Name,
+		UpdatedAt: utils.ConvertTimeToString(dbFolder.UpdatedAt),
+		CreatedAt: utils.ConvertTimeToString(dbFolder.CreatedAt),
+	}
+}
+
+func normalizeFolderName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxFolderNameLength || strings.Contains(name, "/") {
+		return "", ErrInvalidFolderName
+	}
+	return name, nil
+}
+
+// ListFolders returns all of a user's folders flat; parent_id links them up
+// into a tree.
+func (s *LinkService) ListFolders(ctx context.Context, userID string) ([]Folder, error) {
+	const serviceID = "service.link.ListFolders"
+
+	rows, err := s.queries.ListFolders(ctx, userID)
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't list folders", "error", err)
+		return nil, ErrUnknownError
+	}
+
+	folders := make([]Folder, 0, len(rows))
+	for _, row := range rows {
+		folders = append(folders, Folder{
+			ID:        row.ID,
+			ParentID:  row.ParentID.String,
+			Name:      row.Name,
+			LinkCount: row.LinkCount,
+			UpdatedAt: utils.ConvertTimeToString(row.UpdatedAt),
+			CreatedAt: utils.ConvertTimeToString(row.CreatedAt),
+		})
+	}
+
+	return folders, nil
+}
+
+type CreateFolderParams struct {
+	UserID string
+	Name   string
+	// Optional. Folders without a parent are top-level.
+	ParentID string
+}
+
+func (s *LinkService) CreateFolder(ctx context.Context, args CreateFolderParams) (Folder, error) {
+	const serviceID = "service.link.CreateFolder"
+
+	name, err := normalizeFolderName(args.Name)
+	if err != nil {
+		return Folder{}, err
+	}
+
+	var createdFolder db.Folder
+
+	err = s.store.ExecTx(ctx, func(q *db.Queries) error {
+		if args.ParentID != "" {
+			if _, err := q.GetFolder(ctx, db.GetFolderParams{ID: args.ParentID, UserID: args.UserID}); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		createdFolder, err = q.CreateFolder(ctx, db.CreateFolderParams{
+			ID:       utils.NewULID().String(),
+			UserID:   args.UserID,
+			ParentID: nullString(args.ParentID),
+			Name:     name,
+		})
+		return err
+	})
+
+	if err != nil {
+		return Folder{}, folderError(serviceID, err)
+	}
+
+	return fromDBFolder(createdFolder), nil
+}
+
+type UpdateFolderParams struct {
+	UserID   string
+	FolderID string
+	// Nil fields are left alone
+	Name *string
+	// An empty ParentID moves the folder to the top level
+	ParentID *string
+}
+
+func (s *LinkService) UpdateFolder(ctx context.Context, args UpdateFolderParams) (Folder, error) {
+	const serviceID = "service.link.UpdateFolder"
+
+	var updatedFolder db.Folder
+
+	err := s.store.ExecTx(ctx, func(q *db.Queries) error {
+		current, err := q.GetFolder(ctx, db.GetFolderParams{ID: args.FolderID, UserID: args.UserID})
+		if err != nil {
+			return err
+		}
+
+		name := current.Name
+		if args.Name != nil {
+			name, err = normalizeFolderName(*args.Name)
+			if err != nil {
+				return err
+			}
+		}
+
+		parentID := current.ParentID
+		if args.ParentID != nil {
+			parentID = nullString(*args.ParentID)
+		}
+
+		if parentID.Valid && parentID != current.ParentID {
+			if _, err := q.GetFolder(ctx, db.GetFolderParams{ID: parentID.String, UserID: args.UserID}); err != nil {
+				return err
+			}
+			// A folder can't move into itself or anything inside it
+			isAncestor, err := q.IsFolderAncestor(ctx, db.IsFolderAncestorParams{
+				FolderID:   parentID.String,
+				AncestorID: current.ID,
+			})
+			if err != nil {
+				return err
+			}
+			if isAncestor == 1 {
+				return ErrFolderCycle
+			}
+		}
+
+		updatedFolder, err = q.UpdateFolder(ctx, db.UpdateFolderParams{
+			Name:     name,
+			ParentID: parentID,
+			ID:       current.ID,
+			UserID:   args.UserID,
+		})
+		return err
+	})
+
+	if err != nil {
+		return Folder{}, folderError(serviceID, err)
+	}
+
+	return fromDBFolder(updatedFolder), nil
+}
+
+type DeleteFolderParams struct {
+	UserID   string
+	FolderID string
+}
+
+// DeleteFolder removes a folder without touching what's in it: its links and
+// subfolders move up to its parent.
+func (s *LinkService) DeleteFolder(ctx context.Context, args DeleteFolderParams) error {
+	const serviceID = "service.link.DeleteFolder"
+
+	err := s.store.ExecTx(ctx, func(q *db.Queries) error {
+		current, err := q.GetFolder(ctx, db.GetFolderParams{ID: args.FolderID, UserID: args.UserID})
+		if err != nil {
+			return err
+		}
+
+		folderID := sql.NullString{String: current.ID, Valid: true}
+
+		err = q.ReparentFolders(ctx, db.ReparentFoldersParams{
+			NewParentID: current.ParentID,
+			ParentID:    folderID,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.MoveFolderLinks(ctx, db.MoveFolderLinksParams{
+			NewFolderID: current.ParentID,
+			FolderID:    folderID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.DeleteFolder(ctx, db.DeleteFolderParams{ID: current.ID, UserID: args.UserID})
+	})
+
+	if err != nil {
+		return folderError(serviceID, err)
+	}
+
+	return nil
+}
+
+type MoveLinksParams struct {
+	UserID  string
+	LinkIDs []string
+	// An empty FolderID takes the links out of any folder
+	FolderID string
+}
+
+// MoveLinks puts many links in a folder in one transaction.
+func (s *LinkService) MoveLinks(ctx context.Context, args MoveLinksParams) (LinkBatchResult, error) {
+	const serviceID = "service.link.MoveLinks"
+
+	result := LinkBatchResult{NotFound: []string{}}
+
+	err := s.store.ExecTx(ctx, func(q *db.Queries) error {
+		if args.FolderID != "" {
+			if _, err := q.GetFolder(ctx, db.GetFolderParams{ID: args.FolderID, UserID: args.UserID}); err != nil {
+				return err
+			}
+		}
+
+		for _, linkID := range args.LinkIDs {
+			moved, err := q.SetLinkFolder(ctx, db.SetLinkFolderParams{
+				FolderID: nullString(args.FolderID),
+				ID:       linkID,
+				UserID:   args.UserID,
+			})
+			if err != nil {
+				return err
+			}
+			if moved == 0 {
+				result.NotFound = append(result.NotFound, linkID)
+				continue
+			}
+			result.Updated++
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return LinkBatchResult{}, folderError(serviceID, err)
+	}
+
+	return result, nil
+}
+
+// folderError maps errors from inside a folder transaction to the errors
+// the service exposes.
+func folderError(serviceID string, err error) error {
+	switch {
+	case err == sql.ErrNoRows:
+		return ErrFolderNotFound
+	case err == ErrInvalidFolderName, err == ErrFolderCycle:
+		return err
+	case utils.IsConflictError(err):
+		return ErrFolderExists
+	}
+	slog.Error(serviceID, "message", "couldn't change folder", "error", err)
+	return ErrUnknownError
+}
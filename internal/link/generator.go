@@ -0,0 +1,149 @@
+package link
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	db "url-shortener/db/sqlc"
+	"url-shortener/internal/utils"
+)
+
+const (
+	StrategyRandom  = "random"
+	StrategyCounter = "counter"
+	StrategyHash    = "hash"
+
+	defaultCodeLength = 7
+	feistelRounds     = 4
+	codeSequenceName  = "links"
+)
+
+type CodeGenerator interface {
+	// Generate returns a candidate short code for the destination URL.
+	// attempt starts at 0 and increases every time a candidate collides.
+	// Generators that need the database use q, which may be a transaction.
+	Generate(ctx context.Context, q *db.Queries, destination string, attempt int) (string, error)
+}
+
+func NewCodeGenerator(strategy string, length int, secret string) (CodeGenerator, error) {
+	if length <= 0 {
+		length = defaultCodeLength
+	}
+
+	switch strategy {
+	case "", StrategyRandom:
+		return &randomGenerator{length: length}, nil
+	case StrategyCounter:
+		// Anyone can undo the permutation of a known key, so codes would be
+		// as guessable as the sequence itself
+		if secret == "" {
+			return nil, errors.New("the counter code strategy needs a secret")
+		}
+		return newCounterGenerator(length, []byte(secret)), nil
+	case StrategyHash:
+		return &hashGenerator{length: length}, nil
+	default:
+		return nil, fmt.Errorf("unknown code strategy: %q", strategy)
+	}
+}
+
+// randomGenerator draws every code from crypto/rand.
+type randomGenerator struct {
+	length int
+}
+
+func (g *randomGenerator) Generate(_ context.Context, _ *db.Queries, _ string, _ int) (string, error) {
+	return utils.GenerateBase62(g.length)
+}
+
+// counterGenerator hands out codes from a database sequence. The sequence
+// value is run through a keyed Feistel network so consecutive links don't
+// get consecutive (and guessable) codes. The permutation is a bijection so
+// codes never collide until the sequence outgrows the domain.
+type counterGenerator struct {
+	length   int
+	key      []byte
+	halfBits uint
+}
+
+func newCounterGenerator(length int, key []byte) *counterGenerator {
+	// Largest even number of bits that still fits into `length` base62 chars
+	bits := uint(float64(length) * math.Log2(62))
+	bits -= bits % 2
+	if bits > 64 {
+		bits = 64
+	}
+	return &counterGenerator{
+		length:   length,
+		key:      key,
+		halfBits: bits / 2,
+	}
+}
+
+func (g *counterGenerator) Generate(ctx context.Context, q *db.Queries, _ string, _ int) (string, error) {
+	value, err := q.NextCodeSequence(ctx, codeSequenceName)
+
+	if err != nil {
+		return "", err
+	}
+
+	n := uint64(value)
+	if g.halfBits < 32 && n >= 1<<(2*g.halfBits) {
+		return "", ErrCodeSpaceExhausted
+	}
+
+	return utils.EncodeBase62(g.permute(n), g.length), nil
+}
+
+func (g *counterGenerator) permute(n uint64) uint64 {
+	mask := uint64(1)<<g.halfBits - 1
+	left, right := (n>>g.halfBits)&mask, n&mask
+
+	for round := 0; round < feistelRounds; round++ {
+		left, right = right, left^(g.round(round, right)&mask)
+	}
+
+	return left<<g.halfBits | right
+}
+
+func (g *counterGenerator) round(round int, half uint64) uint64 {
+	mac := hmac.New(sha256.New, g.key)
+	var buf [9]byte
+	buf[0] = byte(round)
+	binary.BigEndian.PutUint64(buf[1:], half)
+	mac.Write(buf[:])
+	return binary.BigEndian.Uint64(mac.Sum(nil))
+}
+
+// hashGenerator derives the code from the destination itself so shortening
+// the same URL twice lands on the same code, which insertLink answers with
+// the existing link. Collisions with a different URL, or another user's link
+// to it, are resolved by salting the hash with the attempt number.
+type hashGenerator struct {
+	length int
+}
+
+func (g *hashGenerator) Generate(_ context.Context, _ *db.Queries, destination string, attempt int) (string, error) {
+	input := destination
+	if attempt > 0 {
+		input = fmt.Sprintf("%s#%d", destination, attempt)
+	}
+
+	sum := sha256.Sum256([]byte(input))
+	n := binary.BigEndian.Uint64(sum[:8])
+
+	// 62^11 overflows a uint64, longer codes use the full hash prefix
+	if g.length < 11 {
+		space := uint64(1)
+		for i := 0; i < g.length; i++ {
+			space *= 62
+		}
+		n %= space
+	}
+
+	return utils.EncodeBase62(n, g.length), nil
+}
@@ -0,0 +1,392 @@
+package link
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	db "url-shortener/db/sqlc"
+)
+
+const (
+	ImportFormatAuto      = "auto"
+	ImportFormatBitly     = "bitly"
+	ImportFormatRebrandly = "rebrandly"
+	ImportFormatTinyURL   = "tinyurl"
+
+	// ImportJobKind is the job kind ImportJob handles
+	ImportJobKind = "import_links"
+
+	importBatchSize = 100
+)
+
+// importFormat lists the columns each shortener's export uses, by their
+// normalized header names.
+type importFormat struct {
+	urlColumns []string
+	// Columns holding the back-half on its own
+	backHalfColumns []string
+	// Columns holding the full short URL, whose path is the back-half
+	shortURLColumns []string
+}
+
+var importFormats = map[string]importFormat{
+	ImportFormatBitly: {
+		urlColumns:      []string{"longurl", "url"},
+		backHalfColumns: []string{"custombitlink", "backhalf"},
+		shortURLColumns: []string{"bitlink", "link", "shorturl"},
+	},
+	ImportFormatRebrandly: {
+		urlColumns:      []string{"destination", "longurl"},
+		backHalfColumns: []string{"slashtag"},
+		shortURLColumns: []string{"shorturl", "link"},
+	},
+	ImportFormatTinyURL: {
+		urlColumns:      []string{"longurl", "url"},
+		backHalfColumns: []string{"alias"},
+		shortURLColumns: []string{"tinyurl", "shorturl"},
+	},
+}
+
+// Tried in order when the format is auto, so the formats with the most
+// distinctive columns come first.
+var importDetectOrder = []string{ImportFormatRebrandly, ImportFormatBitly, ImportFormatTinyURL}
+
+// ImportRecord is one link read from an export. Row is its line in the file.
+type ImportRecord struct {
+	Row      int    `json:"row"`
+	URL      string `json:"url"`
+	BackHalf string `json:"back_half"`
+}
+
+// ParseImport reads a CSV export from another shortener. The header row
+// decides which columns are used; format "auto" or "" picks the format from
+// the header too.
+func ParseImport(r io.Reader, format string) ([]ImportRecord, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, ErrInvalidImport
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			// Spreadsheet apps like to start files with a byte order mark
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		if _, ok := columns[normalizeImportColumn(name)]; !ok {
+			columns[normalizeImportColumn(name)] = i
+		}
+	}
+
+	spec, err := detectImportFormat(columns, format)
+	if err != nil {
+		return nil, err
+	}
+
+	urlColumn := findImportColumn(columns, spec.urlColumns)
+	backHalfColumn := findImportColumn(columns, spec.backHalfColumns)
+	shortURLColumn := findImportColumn(columns, spec.shortURLColumns)
+
+	var records []ImportRecord
+
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+
+		url := importField(record, urlColumn)
+		if url == "" {
+			continue
+		}
+
+		backHalf := importField(record, backHalfColumn)
+		if backHalf == "" {
+			backHalf = backHalfOf(importField(record, shortURLColumn))
+		}
+
+		records = append(records, ImportRecord{Row: row, URL: url, BackHalf: backHalf})
+	}
+
+	return records, nil
+}
+
+func detectImportFormat(columns map[string]int, format string) (importFormat, error) {
+	if format != "" && format != ImportFormatAuto {
+		spec, ok := importFormats[format]
+		if !ok {
+			return importFormat{}, ErrInvalidImportFormat
+		}
+		if findImportColumn(columns, spec.urlColumns) < 0 {
+			return importFormat{}, ErrInvalidImport
+		}
+		return spec, nil
+	}
+
+	for _, name := range importDetectOrder {
+		spec := importFormats[name]
+		if findImportColumn(columns, spec.urlColumns) < 0 {
+			continue
+		}
+		if findImportColumn(columns, spec.backHalfColumns) >= 0 || findImportColumn(columns, spec.shortURLColumns) >= 0 {
+			return spec, nil
+		}
+	}
+
+	// A plain list of URLs still imports, just without back-halves
+	for _, name := range importDetectOrder {
+		spec := importFormats[name]
+		if findImportColumn(columns, spec.urlColumns) >= 0 {
+			return spec, nil
+		}
+	}
+
+	return importFormat{}, ErrInvalidImport
+}
+
+// normalizeImportColumn makes "Long URL", "long_url" and "longUrl" match.
+func normalizeImportColumn(name string) string {
+	return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+}
+
+func findImportColumn(columns map[string]int, names []string) int {
+	for _, name := range names {
+		if i, ok := columns[name]; ok {
+			return i
+		}
+	}
+	return -1
+}
+
+func importField(record []string, column int) string {
+	if column < 0 || column >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[column])
+}
+
+// backHalfOf returns the path of a short URL like "bit.ly/abc" or
+// "https://rebrand.ly/abc".
+func backHalfOf(shortURL string) string {
+	if i := strings.Index(shortURL, "://"); i >= 0 {
+		shortURL = shortURL[i+3:]
+	}
+	_, path, found := strings.Cut(shortURL, "/")
+	if !found {
+		return ""
+	}
+	path, _, _ = strings.Cut(path, "?")
+	return strings.Trim(path, "/")
+}
+
+// ImportConflict is a link that was imported under a new code because its
+// back-half couldn't be kept.
+type ImportConflict struct {
+	Row        int    `json:"row"`
+	BackHalf   string `json:"back_half"`
+	ShortUrlID string `json:"short_url_id"`
+	Reason     string `json:"reason"`
+}
+
+// ImportFailure is a row that couldn't be imported at all.
+type ImportFailure struct {
+	Row    int    `json:"row"`
+	URL    string `json:"url"`
+	Reason string `json:"reason"`
+}
+
+type ImportReport struct {
+	Total     int              `json:"total"`
+	Imported  int              `json:"imported"`
+	Preserved int              `json:"preserved"`
+	Conflicts []ImportConflict `json:"conflicts"`
+	Failures  []ImportFailure  `json:"failures"`
+}
+
+type ImportLinksParams struct {
+	UserID  string
+	Records []ImportRecord
+}
+
+// importCheckpoint is how far an import got: the index of the next record
+// and the report up to it.
+type importCheckpoint struct {
+	Next   int          `json:"next"`
+	Report ImportReport `json:"report"`
+}
+
+// ImportLinks creates a link for every record, keeping the record's
+// back-half as the alias when it's free. Records are written in batches,
+// each in its own transaction, and progress is reported after every batch.
+func (s *LinkService) ImportLinks(ctx context.Context, args ImportLinksParams, progress func(done, total int64)) (ImportReport, error) {
+	return s.importLinks(ctx, args, importCheckpoint{}, nil, progress)
+}
+
+// importLinks imports the records from where checkpoint says to. save, if
+// set, runs inside every batch's transaction with the checkpoint after it,
+// so a checkpoint never gets ahead of or behind the links written.
+func (s *LinkService) importLinks(ctx context.Context, args ImportLinksParams, checkpoint importCheckpoint, save func(q *db.Queries, checkpoint importCheckpoint) error, progress func(done, total int64)) (ImportReport, error) {
+	const serviceID = "service.link.ImportLinks"
+
+	report := checkpoint.Report
+	report.Total = len(args.Records)
+	if report.Conflicts == nil {
+		report.Conflicts = []ImportConflict{}
+	}
+	if report.Failures == nil {
+		report.Failures = []ImportFailure{}
+	}
+
+	for start := checkpoint.Next; start < len(args.Records); start += importBatchSize {
+		batch := args.Records[start:min(start+importBatchSize, len(args.Records))]
+		var merged ImportReport
+
+		err := s.store.ExecTx(ctx, func(q *db.Queries) error {
+			batchReport := ImportReport{}
+			for _, record := range batch {
+				if err := s.importRecord(ctx, q, args.UserID, record, &batchReport); err != nil {
+					return err
+				}
+			}
+			merged = mergeImportReports(report, batchReport)
+			if save == nil {
+				return nil
+			}
+			return save(q, importCheckpoint{Next: start + len(batch), Report: merged})
+		})
+
+		if err != nil {
+			slog.Error(serviceID, "message", "couldn't import links", "row", batch[0].Row, "error", err)
+			return ImportReport{}, ErrCreatingLink
+		}
+
+		report = merged
+
+		if progress != nil {
+			progress(int64(start+len(batch)), int64(len(args.Records)))
+		}
+	}
+
+	return report, nil
+}
+
+func mergeImportReports(report ImportReport, batchReport ImportReport) ImportReport {
+	report.Imported += batchReport.Imported
+	report.Preserved += batchReport.Preserved
+	report.Conflicts = append(report.Conflicts, batchReport.Conflicts...)
+	report.Failures = append(report.Failures, batchReport.Failures...)
+	return report
+}
+
+// importRecord adds the outcome of one record to report. Only database
+// errors are returned.
+func (s *LinkService) importRecord(ctx context.Context, q *db.Queries, userID string, record ImportRecord, report *ImportReport) error {
+	input, err := prepareLink(CreateLinkParams{
+		UserID:      userID,
+		OriginalURL: record.URL,
+		Alias:       record.BackHalf,
+	})
+
+	var conflict error
+
+	switch err {
+	case nil:
+	case ErrInvalidAlias, ErrReservedAlias, ErrBlockedAlias:
+		conflict = err
+		input, err = prepareLink(CreateLinkParams{UserID: userID, OriginalURL: record.URL})
+		if err != nil {
+			report.Failures = append(report.Failures, ImportFailure{Row: record.Row, URL: record.URL, Reason: err.Error()})
+			return nil
+		}
+	default:
+		report.Failures = append(report.Failures, ImportFailure{Row: record.Row, URL: record.URL, Reason: err.Error()})
+		return nil
+	}
+
+	createdLink, err := s.insertLink(ctx, q, input)
+
+	if err == ErrAliasTaken {
+		conflict = err
+		input.alias = ""
+		createdLink, err = s.insertLink(ctx, q, input)
+	}
+
+	switch err {
+	case nil:
+	case ErrCodeSpaceExhausted, ErrGeneratingCode:
+		report.Failures = append(report.Failures, ImportFailure{Row: record.Row, URL: record.URL, Reason: err.Error()})
+		return nil
+	default:
+		return err
+	}
+
+	report.Imported++
+
+	if conflict != nil {
+		report.Conflicts = append(report.Conflicts, ImportConflict{
+			Row:        record.Row,
+			BackHalf:   record.BackHalf,
+			ShortUrlID: createdLink.ShortUrlID,
+			Reason:     conflict.Error(),
+		})
+	} else if input.alias != "" {
+		report.Preserved++
+	}
+
+	return nil
+}
+
+type importPayload struct {
+	Records []ImportRecord `json:"records"`
+}
+
+// ImportJobPayload encodes records for an ImportJobKind job.
+func ImportJobPayload(records []ImportRecord) ([]byte, error) {
+	return json.Marshal(importPayload{Records: records})
+}
+
+// ImportJob runs an import queued as a job. Every batch checkpoints the
+// job, so an import interrupted by a restart carries on after the last
+// batch that was written instead of importing it all again.
+func (s *LinkService) ImportJob(ctx context.Context, job db.Job, progress func(done, total int64)) (any, error) {
+	var payload importPayload
+
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, ErrInvalidImport
+	}
+
+	var checkpoint importCheckpoint
+	if job.Checkpoint != "" {
+		if err := json.Unmarshal([]byte(job.Checkpoint), &checkpoint); err != nil {
+			return nil, err
+		}
+	}
+
+	save := func(q *db.Queries, checkpoint importCheckpoint) error {
+		encoded, err := json.Marshal(checkpoint)
+		if err != nil {
+			return err
+		}
+		return q.SetJobCheckpoint(ctx, db.SetJobCheckpointParams{
+			Checkpoint: string(encoded),
+			ID:         job.ID,
+		})
+	}
+
+	return s.importLinks(ctx, ImportLinksParams{
+		UserID:  job.UserID,
+		Records: payload.Records,
+	}, checkpoint, save, progress)
+}
@@ -0,0 +1,210 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"time"
+	db "url-shortener/db/sqlc"
+
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	SortCreated = "created"
+	SortUpdated = "updated"
+	SortClicks  = "clicks"
+
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+
+	// Format of CURRENT_TIMESTAMP, which is how SQLite stores `updated_at`
+	sqliteTimestampFormat = "2006-01-02 15:04:05"
+)
+
+type ListLinksParams struct {
+	UserID   string
+	Status   string
+	Domain   string
+	FolderID string
+	// Links must have every one of these tags
+	Tags          []string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Sort          string
+	Cursor        string
+	PageSize      int
+}
+
+type LinkPage struct {
+	Links      []Link
+	NextCursor string
+}
+
+// cursor marks the last link of a page. It carries the sort key alongside the
+// ID so the next page can resume from the right place in the ordering.
+type cursor struct {
+	Sort       string `json:"s"`
+	ID         string `json:"id"`
+	UpdatedAt  string `json:"u,omitempty"`
+	ClickCount int64  `json:"c,omitempty"`
+}
+
+func encodeCursor(c cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(encoded string, sort string) (cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" || c.Sort != sort {
+		return cursor{}, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// ulidBound turns a timestamp into the smallest ULID created at that instant,
+// which lets us filter on creation time through the primary key.
+func ulidBound(t time.Time) sql.NullString {
+	if t.IsZero() {
+		return sql.NullString{}
+	}
+	var id ulid.ULID
+	if err := id.SetTime(ulid.Timestamp(t)); err != nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: id.String(), Valid: true}
+}
+
+// tagsFilter encodes tag names as the JSON array the list queries expect.
+func tagsFilter(names []string) (sql.NullString, error) {
+	normalized, err := normalizeTagNames(names)
+	if err != nil || len(normalized) == 0 {
+		return sql.NullString{}, err
+	}
+	encoded, err := json.Marshal(normalized)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(encoded), Valid: true}, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func (s *LinkService) ListLinks(ctx context.Context, args ListLinksParams) (LinkPage, error) {
+	const serviceID = "service.link.ListLinks"
+
+	sort := args.Sort
+	if sort == "" {
+		sort = SortCreated
+	}
+
+	pageSize := args.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	var after cursor
+	if args.Cursor != "" {
+		decoded, err := decodeCursor(args.Cursor, sort)
+		if err != nil {
+			return LinkPage{}, err
+		}
+		after = decoded
+	}
+
+	cursorID := nullString(after.ID)
+	status := nullString(args.Status)
+	domain := nullString(args.Domain)
+	folderID := nullString(args.FolderID)
+	tags, err := tagsFilter(args.Tags)
+	if err != nil {
+		return LinkPage{}, err
+	}
+	createdAfter := ulidBound(args.CreatedAfter)
+	createdBefore := ulidBound(args.CreatedBefore)
+	// Fetch one extra row to find out whether there's a next page
+	limit := int64(pageSize + 1)
+
+	var dbLinks []db.Link
+
+	switch sort {
+	case SortCreated:
+		dbLinks, err = s.queries.ListLinksByCreated(ctx, db.ListLinksByCreatedParams{
+			UserID:        args.UserID,
+			Status:        status,
+			Domain:        domain,
+			FolderID:      folderID,
+			Tags:          tags,
+			CreatedAfter:  createdAfter,
+			CreatedBefore: createdBefore,
+			CursorID:      cursorID,
+			PageSize:      limit,
+		})
+	case SortUpdated:
+		dbLinks, err = s.queries.ListLinksByUpdated(ctx, db.ListLinksByUpdatedParams{
+			UserID:          args.UserID,
+			Status:          status,
+			Domain:          domain,
+			FolderID:        folderID,
+			Tags:            tags,
+			CreatedAfter:    createdAfter,
+			CreatedBefore:   createdBefore,
+			CursorID:        cursorID,
+			CursorUpdatedAt: nullString(after.UpdatedAt),
+			PageSize:        limit,
+		})
+	case SortClicks:
+		dbLinks, err = s.queries.ListLinksByClicks(ctx, db.ListLinksByClicksParams{
+			UserID:           args.UserID,
+			Status:           status,
+			Domain:           domain,
+			FolderID:         folderID,
+			Tags:             tags,
+			CreatedAfter:     createdAfter,
+			CreatedBefore:    createdBefore,
+			CursorID:         cursorID,
+			CursorClickCount: sql.NullInt64{Int64: after.ClickCount, Valid: after.ID != ""},
+			PageSize:         limit,
+		})
+	default:
+		return LinkPage{}, ErrInvalidSort
+	}
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't list links", "error", err)
+		return LinkPage{}, ErrUnknownError
+	}
+
+	page := LinkPage{Links: make([]Link, 0, len(dbLinks))}
+
+	if len(dbLinks) > pageSize {
+		last := dbLinks[pageSize-1]
+		page.NextCursor = encodeCursor(cursor{
+			Sort:       sort,
+			ID:         last.ID,
+			UpdatedAt:  last.UpdatedAt.UTC().Format(sqliteTimestampFormat),
+			ClickCount: last.ClickCount,
+		})
+		dbLinks = dbLinks[:pageSize]
+	}
+
+	for _, dbLink := range dbLinks {
+		page.Links = append(page.Links, fromDBLink(dbLink))
+	}
+
+	return page, nil
+}
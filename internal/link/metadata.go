@@ -0,0 +1,121 @@
+package link
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+	db "url-shortener/db/sqlc"
+	"url-shortener/internal/metadata"
+)
+
+const (
+	defaultMetadataPollInterval = 5 * time.Second
+
+	metadataBatchSize = 20
+	// Pages fetched at once. Slow destinations only hold up their own slot.
+	metadataWorkers = 4
+)
+
+// RunMetadataFetcher fetches the title, description, favicon and preview
+// image of new destinations every interval until ctx is cancelled. Links
+// whose destination changes are fetched again.
+func (s *LinkService) RunMetadataFetcher(ctx context.Context, fetcher *metadata.Fetcher, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultMetadataPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.FetchMissingMetadata(ctx, fetcher)
+		}
+	}
+}
+
+// FetchMissingMetadata works through every link whose destination hasn't been
+// fetched yet. Destinations that can't be fetched are marked as fetched with
+// no metadata, so they aren't tried again on every run.
+func (s *LinkService) FetchMissingMetadata(ctx context.Context, fetcher *metadata.Fetcher) {
+	const serviceID = "service.link.FetchMissingMetadata"
+
+	for ctx.Err() == nil {
+		pending, err := s.queries.ListLinksMissingMetadata(ctx, metadataBatchSize)
+
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error(serviceID, "message", "couldn't list links missing metadata", "error", err)
+			}
+			return
+		}
+
+		work := make(chan db.ListLinksMissingMetadataRow)
+		failed := false
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+
+		for range metadataWorkers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for link := range work {
+					if err := s.fetchMetadata(ctx, fetcher, link); err != nil {
+						mu.Lock()
+						failed = true
+						mu.Unlock()
+					}
+				}
+			}()
+		}
+
+		for _, link := range pending {
+			work <- link
+		}
+		close(work)
+		wg.Wait()
+
+		// Stopping on database errors keeps a broken write from refetching
+		// the same batch in a tight loop; the next tick tries again.
+		if failed || len(pending) < metadataBatchSize {
+			return
+		}
+	}
+}
+
+// fetchMetadata stores the metadata of one link's destination. Only database
+// errors are returned.
+func (s *LinkService) fetchMetadata(ctx context.Context, fetcher *metadata.Fetcher, link db.ListLinksMissingMetadataRow) error {
+	const serviceID = "service.link.fetchMetadata"
+
+	page, err := fetcher.Fetch(ctx, link.OriginalUrl)
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		slog.Warn(serviceID, "message", "couldn't fetch destination", "link", link.ID, "error", err)
+	}
+
+	_, err = s.queries.SetLinkMetadata(ctx, db.SetLinkMetadataParams{
+		Title:       nullString(page.Title),
+		Description: nullString(page.Description),
+		FaviconUrl:  nullString(page.FaviconURL),
+		ImageUrl:    nullString(page.ImageURL),
+		ID:          link.ID,
+		OriginalUrl: link.OriginalUrl,
+	})
+
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Error(serviceID, "message", "couldn't store metadata", "link", link.ID, "error", err)
+		}
+		return err
+	}
+
+	return nil
+}
@@ -1,28 +1,135 @@
 package link
 
 import (
+	"database/sql"
 	db "url-shortener/db/sqlc"
 	"url-shortener/internal/utils"
 )
 
+const (
+	StatusActive  = "active"
+	StatusExpired = "expired"
+	StatusTrashed = "trashed"
+)
+
 type Link struct {
-	ID          string `json:"id"`
-	UserID      string `json:"user_id"`
-	OriginalUrl string `json:"original_url"`
-	ShortUrlID  string `json:"short_url_id"`
-	PrettyID    string `json:"pretty_id"`
-	UpdatedAt   string `json:"updated_at"`
-	CreatedAt   string `json:"created_at"`
+	ID              string `json:"id"`
+	UserID          string `json:"user_id"`
+	OriginalUrl     string `json:"original_url"`
+	ShortUrlID      string `json:"short_url_id"`
+	PrettyID        string `json:"pretty_id"`
+	RedirectType    int    `json:"redirect_type"`
+	Status          string `json:"status"`
+	DestinationHost string `json:"destination_host"`
+	ClickCount      int64  `json:"click_count"`
+	ExpiresAt       string `json:"expires_at"`
+	MaxClicks       int64  `json:"max_clicks"`
+	FallbackUrl     string `json:"fallback_url"`
+	PasswordHash    string `json:"-"`
+	DeletedAt       string `json:"deleted_at"`
+	FolderID        string `json:"folder_id"`
+	Title           string `json:"title"`
+	Description     string `json:"description"`
+	FaviconUrl      string `json:"favicon_url"`
+	ImageUrl        string `json:"image_url"`
+	// Owner overrides for the social preview
+	PreviewTitle       string `json:"preview_title"`
+	PreviewDescription string `json:"preview_description"`
+	PreviewImageUrl    string `json:"preview_image_url"`
+	ActivatesAt        string `json:"activates_at"`
+	// Added to the destination on every redirect
+	UTM              UTM    `json:"utm"`
+	QueryPassthrough string `json:"query_passthrough"`
+	CampaignID       string `json:"campaign_id"`
+	DomainID         string `json:"domain_id"`
+	UpdatedAt        string `json:"updated_at"`
+	CreatedAt        string `json:"created_at"`
+
+	// The campaign's UTM values, which fill in the link's unset ones. Only
+	// ResolveLink looks them up.
+	campaignUTM UTM
 }
 
 func fromDBLink(dbUser db.Link) Link {
 	return Link{
-		ID:          dbUser.ID,
-		UserID:      dbUser.UserID,
-		OriginalUrl: dbUser.OriginalUrl,
-		ShortUrlID:  dbUser.ShortUrlID,
-		PrettyID:    dbUser.PrettyID,
-		UpdatedAt:   utils.ConvertTimeToString(dbUser.UpdatedAt),
-		CreatedAt:   utils.ConvertTimeToString(dbUser.CreatedAt),
+		ID:                 dbUser.ID,
+		UserID:             dbUser.UserID,
+		OriginalUrl:        dbUser.OriginalUrl,
+		ShortUrlID:         dbUser.ShortUrlID,
+		PrettyID:           dbUser.PrettyID,
+		RedirectType:       int(dbUser.RedirectType),
+		Status:             dbUser.Status,
+		DestinationHost:    dbUser.DestinationHost,
+		ClickCount:         dbUser.ClickCount,
+		ExpiresAt:          convertNullTimeToString(dbUser.ExpiresAt),
+		MaxClicks:          dbUser.MaxClicks.Int64,
+		FallbackUrl:        dbUser.FallbackUrl,
+		PasswordHash:       dbUser.PasswordHash,
+		DeletedAt:          convertNullTimeToString(dbUser.DeletedAt),
+		FolderID:           dbUser.FolderID.String,
+		Title:              dbUser.Title.String,
+		Description:        dbUser.Description.String,
+		FaviconUrl:         dbUser.FaviconUrl.String,
+		ImageUrl:           dbUser.ImageUrl.String,
+		PreviewTitle:       dbUser.PreviewTitle.String,
+		PreviewDescription: dbUser.PreviewDescription.String,
+		PreviewImageUrl:    dbUser.PreviewImageUrl.String,
+		ActivatesAt:        convertNullTimeToString(dbUser.ActivatesAt),
+		UTM: UTM{
+			Source:   dbUser.UtmSource.String,
+			Medium:   dbUser.UtmMedium.String,
+			Campaign: dbUser.UtmCampaign.String,
+			Term:     dbUser.UtmTerm.String,
+			Content:  dbUser.UtmContent.String,
+		},
+		QueryPassthrough: dbUser.QueryPassthrough,
+		CampaignID:       dbUser.CampaignID.String,
+		DomainID:         dbUser.DomainID.String,
+		UpdatedAt:        utils.ConvertTimeToString(dbUser.UpdatedAt),
+		CreatedAt:        utils.ConvertTimeToString(dbUser.CreatedAt),
+	}
+}
+
+func (l Link) IsProtected() bool {
+	return l.PasswordHash != ""
+}
+
+// Preview is what link unfurlers are shown in place of a redirect.
+type Preview struct {
+	Title       string
+	Description string
+	ImageURL    string
+}
+
+// SocialPreview fills in whatever the owner left unset from the destination
+// page. ok is false when the owner hasn't overridden anything: crawlers are
+// better off following the redirect and reading the destination themselves.
+func (l Link) SocialPreview() (preview Preview, ok bool) {
+	if l.PreviewTitle == "" && l.PreviewDescription == "" && l.PreviewImageUrl == "" {
+		return Preview{}, false
+	}
+
+	preview = Preview{
+		Title:       firstNonEmpty(l.PreviewTitle, l.Title, l.DestinationHost),
+		Description: firstNonEmpty(l.PreviewDescription, l.Description),
+		ImageURL:    firstNonEmpty(l.PreviewImageUrl, l.ImageUrl),
+	}
+
+	return preview, true
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func convertNullTimeToString(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
 	}
+	return utils.ConvertTimeToString(t.Time)
 }
@@ -0,0 +1,80 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"unicode/utf8"
+	db "url-shortener/db/sqlc"
+	"url-shortener/internal/auth"
+)
+
+const (
+	minLinkPasswordLength = 4
+	maxLinkPasswordLength = 128
+)
+
+type SetLinkPasswordParams struct {
+	UserID string
+	LinkID string
+	// An empty password removes the protection
+	Password string
+}
+
+// SetLinkPassword protects a link behind a password. Link passwords are
+// shared with visitors, so they aren't held to the account password rules.
+func (s *LinkService) SetLinkPassword(ctx context.Context, args SetLinkPasswordParams) (Link, error) {
+	const serviceID = "service.link.SetLinkPassword"
+
+	var passwordHash string
+
+	if args.Password != "" {
+		length := utf8.RuneCountInString(args.Password)
+		if length < minLinkPasswordLength || length > maxLinkPasswordLength {
+			return Link{}, ErrInvalidPassword
+		}
+
+		hash, err := auth.HashPassword(args.Password)
+
+		if err != nil {
+			slog.Error(serviceID, "message", "couldn't hash link password", "error", err)
+			return Link{}, ErrUnknownError
+		}
+
+		passwordHash = hash
+	}
+
+	updatedLink, err := s.queries.SetLinkPassword(ctx, db.SetLinkPasswordParams{
+		PasswordHash: passwordHash,
+		ID:           args.LinkID,
+		UserID:       args.UserID,
+	})
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Link{}, ErrLinkNotFound
+		}
+		slog.Error(serviceID, "message", "couldn't set link password", "error", err)
+		return Link{}, ErrUnknownError
+	}
+
+	return fromDBLink(updatedLink), nil
+}
+
+// VerifyLinkPassword checks a visitor's guess against a protected link.
+func (s *LinkService) VerifyLinkPassword(link Link, password string) bool {
+	const serviceID = "service.link.VerifyLinkPassword"
+
+	if !link.IsProtected() {
+		return true
+	}
+
+	ok, err := auth.VerifyPassword(password, link.PasswordHash)
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't verify link password", "link", link.ID, "error", err)
+		return false
+	}
+
+	return ok
+}
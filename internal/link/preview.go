@@ -0,0 +1,64 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+	db "url-shortener/db/sqlc"
+)
+
+const (
+	maxPreviewTitleLength       = 200
+	maxPreviewDescriptionLength = 500
+)
+
+type SetLinkPreviewParams struct {
+	UserID string
+	LinkID string
+	// Empty fields fall back to the destination page's metadata
+	Title       string
+	Description string
+	ImageURL    string
+}
+
+// SetLinkPreview overrides the title, description and image that link
+// unfurlers like Slack and Twitter show for a link.
+func (s *LinkService) SetLinkPreview(ctx context.Context, args SetLinkPreviewParams) (Link, error) {
+	const serviceID = "service.link.SetLinkPreview"
+
+	title := strings.Join(strings.Fields(args.Title), " ")
+	description := strings.TrimSpace(args.Description)
+
+	if utf8.RuneCountInString(title) > maxPreviewTitleLength || utf8.RuneCountInString(description) > maxPreviewDescriptionLength {
+		return Link{}, ErrInvalidPreview
+	}
+
+	var imageURL string
+	if strings.TrimSpace(args.ImageURL) != "" {
+		normalized, err := NormalizeURL(args.ImageURL)
+		if err != nil {
+			return Link{}, ErrInvalidPreviewImage
+		}
+		imageURL = normalized
+	}
+
+	updatedLink, err := s.queries.SetLinkPreview(ctx, db.SetLinkPreviewParams{
+		PreviewTitle:       nullString(title),
+		PreviewDescription: nullString(description),
+		PreviewImageUrl:    nullString(imageURL),
+		ID:                 args.LinkID,
+		UserID:             args.UserID,
+	})
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Link{}, ErrLinkNotFound
+		}
+		slog.Error(serviceID, "message", "couldn't set link preview", "error", err)
+		return Link{}, ErrUnknownError
+	}
+
+	return fromDBLink(updatedLink), nil
+}
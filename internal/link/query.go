@@ -0,0 +1,239 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode/utf8"
+	db "url-shortener/db/sqlc"
+)
+
+// What happens to the query string a visitor comes with
+const (
+	// The destination is used as is
+	PassthroughDrop = "drop"
+	// Parameters the destination doesn't have are added to it
+	PassthroughMerge = "merge"
+	// Parameters replace the destination's ones with the same name
+	PassthroughOverride = "override"
+)
+
+const maxUTMValueLength = 200
+
+var passthroughPolicies = []string{PassthroughDrop, PassthroughMerge, PassthroughOverride}
+
+type UTM struct {
+	Source   string `json:"source"`
+	Medium   string `json:"medium"`
+	Campaign string `json:"campaign"`
+	Term     string `json:"term"`
+	Content  string `json:"content"`
+}
+
+func (u UTM) IsZero() bool {
+	return u == UTM{}
+}
+
+// withDefaults fills in u's unset values from defaults.
+func (u UTM) withDefaults(defaults UTM) UTM {
+	return UTM{
+		Source:   firstNonEmpty(u.Source, defaults.Source),
+		Medium:   firstNonEmpty(u.Medium, defaults.Medium),
+		Campaign: firstNonEmpty(u.Campaign, defaults.Campaign),
+		Term:     firstNonEmpty(u.Term, defaults.Term),
+		Content:  firstNonEmpty(u.Content, defaults.Content),
+	}
+}
+
+func normalizeUTM(u UTM) (UTM, error) {
+	u = UTM{
+		Source:   strings.TrimSpace(u.Source),
+		Medium:   strings.TrimSpace(u.Medium),
+		Campaign: strings.TrimSpace(u.Campaign),
+		Term:     strings.TrimSpace(u.Term),
+		Content:  strings.TrimSpace(u.Content),
+	}
+	for _, param := range u.params() {
+		if utf8.RuneCountInString(param.value) > maxUTMValueLength {
+			return UTM{}, ErrInvalidUTM
+		}
+	}
+	return u, nil
+}
+
+// params are the set UTM values in their usual order.
+func (u UTM) params() []queryParam {
+	var params []queryParam
+	for _, param := range []queryParam{
+		{key: "utm_source", value: u.Source},
+		{key: "utm_medium", value: u.Medium},
+		{key: "utm_campaign", value: u.Campaign},
+		{key: "utm_term", value: u.Term},
+		{key: "utm_content", value: u.Content},
+	} {
+		if param.value != "" {
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// queryParam is one key=value pair of a query string. raw keeps the pair as
+// the destination had it, so parameters nobody touches are passed on byte
+// for byte. Undecodable pairs only have raw.
+type queryParam struct {
+	key         string
+	value       string
+	raw         string
+	undecodable bool
+}
+
+func (p queryParam) String() string {
+	if p.raw != "" {
+		return p.raw
+	}
+	return url.QueryEscape(p.key) + "=" + url.QueryEscape(p.value)
+}
+
+// parseQuery keeps duplicates and order, unlike url.ParseQuery. Pairs that
+// can't be decoded are kept as they are, without a key to match on.
+func parseQuery(rawQuery string) []queryParam {
+	var params []queryParam
+	for _, raw := range strings.Split(rawQuery, "&") {
+		if raw == "" {
+			continue
+		}
+		rawKey, rawValue, _ := strings.Cut(raw, "=")
+		key, keyErr := url.QueryUnescape(rawKey)
+		value, valueErr := url.QueryUnescape(rawValue)
+		if keyErr != nil || valueErr != nil || key == "" {
+			params = append(params, queryParam{raw: raw, undecodable: true})
+			continue
+		}
+		params = append(params, queryParam{key: key, value: value, raw: raw})
+	}
+	return params
+}
+
+// setQueryParam replaces every param named key with values, where the
+// first of them was, or at the end when there was none.
+func setQueryParam(params []queryParam, key string, values []queryParam) []queryParam {
+	index := slices.IndexFunc(params, func(p queryParam) bool { return p.key == key })
+	if index < 0 {
+		return append(params, values...)
+	}
+	kept := slices.DeleteFunc(slices.Clone(params[index:]), func(p queryParam) bool { return p.key == key })
+	return append(append(params[:index:index], values...), kept...)
+}
+
+// BuildDestination adds the link's UTM parameters, and its campaign's where
+// the link has none, to destination, replacing any it already has. Then it
+// adds the visitor's own query string as the link's passthrough policy says.
+func (l Link) BuildDestination(destination string, visitorQuery string) string {
+	utm := l.UTM.withDefaults(l.campaignUTM)
+
+	passthrough := l.QueryPassthrough != PassthroughDrop && l.QueryPassthrough != "" && visitorQuery != ""
+	if utm.IsZero() && !passthrough {
+		return destination
+	}
+
+	u, err := url.Parse(destination)
+	if err != nil {
+		return destination
+	}
+
+	params := parseQuery(u.RawQuery)
+
+	for _, param := range utm.params() {
+		params = setQueryParam(params, param.key, []queryParam{param})
+	}
+
+	if passthrough {
+		// Visitors' pairs are encoded again, as they may have sent
+		// anything. Ones that can't be decoded are left out.
+		visitorParams := slices.DeleteFunc(parseQuery(visitorQuery), func(p queryParam) bool { return p.undecodable })
+		for i := range visitorParams {
+			visitorParams[i].raw = ""
+		}
+
+		var keys []string
+		for _, param := range visitorParams {
+			if !slices.Contains(keys, param.key) {
+				keys = append(keys, param.key)
+			}
+		}
+
+		for _, key := range keys {
+			values := slices.DeleteFunc(slices.Clone(visitorParams), func(p queryParam) bool { return p.key != key })
+			exists := slices.ContainsFunc(params, func(p queryParam) bool { return p.key == key })
+
+			switch {
+			case l.QueryPassthrough == PassthroughOverride:
+				params = setQueryParam(params, key, values)
+			case !exists:
+				params = append(params, values...)
+			}
+		}
+	}
+
+	pairs := make([]string, len(params))
+	for i, param := range params {
+		pairs[i] = param.String()
+	}
+	u.RawQuery = strings.Join(pairs, "&")
+	u.ForceQuery = false
+
+	return u.String()
+}
+
+type SetLinkQueryParamsParams struct {
+	UserID string
+	LinkID string
+	// Empty values aren't added
+	UTM UTM
+	// One of the Passthrough policies, drop when empty
+	Passthrough string
+}
+
+// SetLinkQueryParams sets what a link adds to its destination's query
+// string when redirecting.
+func (s *LinkService) SetLinkQueryParams(ctx context.Context, args SetLinkQueryParamsParams) (Link, error) {
+	const serviceID = "service.link.SetLinkQueryParams"
+
+	utm, err := normalizeUTM(args.UTM)
+	if err != nil {
+		return Link{}, err
+	}
+
+	passthrough := strings.ToLower(strings.TrimSpace(args.Passthrough))
+	if passthrough == "" {
+		passthrough = PassthroughDrop
+	}
+	if !slices.Contains(passthroughPolicies, passthrough) {
+		return Link{}, ErrInvalidPassthrough
+	}
+
+	updatedLink, err := s.queries.SetLinkQueryParams(ctx, db.SetLinkQueryParamsParams{
+		UtmSource:        nullString(utm.Source),
+		UtmMedium:        nullString(utm.Medium),
+		UtmCampaign:      nullString(utm.Campaign),
+		UtmTerm:          nullString(utm.Term),
+		UtmContent:       nullString(utm.Content),
+		QueryPassthrough: passthrough,
+		ID:               args.LinkID,
+		UserID:           args.UserID,
+	})
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Link{}, ErrLinkNotFound
+		}
+		slog.Error(serviceID, "message", "couldn't set link query params", "error", err)
+		return Link{}, ErrUnknownError
+	}
+
+	return fromDBLink(updatedLink), nil
+}
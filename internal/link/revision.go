@@ -0,0 +1,297 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"time"
+	db "url-shortener/db/sqlc"
+	"url-shortener/internal/utils"
+)
+
+const (
+	RevisionCreate   = "create"
+	RevisionUpdate   = "update"
+	RevisionRollback = "rollback"
+)
+
+// LinkValues are the fields of a link that can be edited, and so the ones
+// every revision keeps a copy of.
+type LinkValues struct {
+	OriginalURL  string     `json:"original_url"`
+	PrettyID     string     `json:"pretty_id"`
+	RedirectType int64      `json:"redirect_type"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	MaxClicks    *int64     `json:"max_clicks"`
+	ActivatesAt  *time.Time `json:"activates_at"`
+}
+
+func valuesOf(link db.Link) LinkValues {
+	values := LinkValues{
+		OriginalURL:  link.OriginalUrl,
+		PrettyID:     link.PrettyID,
+		RedirectType: link.RedirectType,
+	}
+	if link.ExpiresAt.Valid {
+		expiresAt := link.ExpiresAt.Time.UTC()
+		values.ExpiresAt = &expiresAt
+	}
+	if link.MaxClicks.Valid {
+		maxClicks := link.MaxClicks.Int64
+		values.MaxClicks = &maxClicks
+	}
+	if link.ActivatesAt.Valid {
+		activatesAt := link.ActivatesAt.Time.UTC()
+		values.ActivatesAt = &activatesAt
+	}
+	return values
+}
+
+// changed lists the fields that differ between v and other, by JSON name.
+func (v LinkValues) changed(other LinkValues) []string {
+	fields := []string{}
+	if v.OriginalURL != other.OriginalURL {
+		fields = append(fields, "original_url")
+	}
+	if v.PrettyID != other.PrettyID {
+		fields = append(fields, "pretty_id")
+	}
+	if v.RedirectType != other.RedirectType {
+		fields = append(fields, "redirect_type")
+	}
+	if (v.ExpiresAt == nil) != (other.ExpiresAt == nil) || (v.ExpiresAt != nil && !v.ExpiresAt.Equal(*other.ExpiresAt)) {
+		fields = append(fields, "expires_at")
+	}
+	if (v.MaxClicks == nil) != (other.MaxClicks == nil) || (v.MaxClicks != nil && *v.MaxClicks != *other.MaxClicks) {
+		fields = append(fields, "max_clicks")
+	}
+	if (v.ActivatesAt == nil) != (other.ActivatesAt == nil) || (v.ActivatesAt != nil && !v.ActivatesAt.Equal(*other.ActivatesAt)) {
+		fields = append(fields, "activates_at")
+	}
+	return fields
+}
+
+type Revision struct {
+	ID        int64      `json:"id"`
+	LinkID    string     `json:"link_id"`
+	UserID    string     `json:"user_id"`
+	Action    string     `json:"action"`
+	OldValues LinkValues `json:"old_values"`
+	NewValues LinkValues `json:"new_values"`
+	Changed   []string   `json:"changed"`
+	CreatedAt string     `json:"created_at"`
+}
+
+func fromDBRevision(dbRevision db.LinkRevision) (Revision, error) {
+	revision := Revision{
+		ID:        dbRevision.ID,
+		LinkID:    dbRevision.LinkID,
+		UserID:    dbRevision.UserID,
+		Action:    dbRevision.Action,
+		CreatedAt: utils.ConvertTimeToString(dbRevision.CreatedAt),
+	}
+	if err := json.Unmarshal([]byte(dbRevision.OldValues), &revision.OldValues); err != nil {
+		return Revision{}, err
+	}
+	if err := json.Unmarshal([]byte(dbRevision.NewValues), &revision.NewValues); err != nil {
+		return Revision{}, err
+	}
+	revision.Changed = revision.NewValues.changed(revision.OldValues)
+	return revision, nil
+}
+
+func recordRevision(ctx context.Context, q *db.Queries, userID string, linkID string, action string, before LinkValues, after LinkValues) error {
+	oldValues, err := json.Marshal(before)
+	if err != nil {
+		return err
+	}
+	newValues, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateLinkRevision(ctx, db.CreateLinkRevisionParams{
+		LinkID:    linkID,
+		UserID:    userID,
+		Action:    action,
+		OldValues: string(oldValues),
+		NewValues: string(newValues),
+	})
+	return err
+}
+
+type ListRevisionsParams struct {
+	UserID string
+	LinkID string
+}
+
+// ListRevisions returns a link's history, newest first.
+func (s *LinkService) ListRevisions(ctx context.Context, args ListRevisionsParams) ([]Revision, error) {
+	const serviceID = "service.link.ListRevisions"
+
+	_, err := s.queries.GetShortLinkById(ctx, db.GetShortLinkByIdParams{
+		UserID: args.UserID,
+		ID:     args.LinkID,
+	})
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrLinkNotFound
+		}
+		slog.Error(serviceID, "message", "couldn't get link", "link", args.LinkID, "error", err)
+		return nil, ErrUnknownError
+	}
+
+	dbRevisions, err := s.queries.ListLinkRevisions(ctx, args.LinkID)
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't list revisions", "link", args.LinkID, "error", err)
+		return nil, ErrUnknownError
+	}
+
+	revisions := make([]Revision, 0, len(dbRevisions))
+	for _, dbRevision := range dbRevisions {
+		revision, err := fromDBRevision(dbRevision)
+		if err != nil {
+			slog.Error(serviceID, "message", "couldn't decode revision", "revision", dbRevision.ID, "error", err)
+			return nil, ErrUnknownError
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, nil
+}
+
+type RollbackLinkParams struct {
+	UserID     string
+	LinkID     string
+	RevisionID int64
+}
+
+// RollbackLink puts a link back the way it was right after the given
+// revision. The rollback is itself recorded, so it can be undone too.
+func (s *LinkService) RollbackLink(ctx context.Context, args RollbackLinkParams) (Link, error) {
+	const serviceID = "service.link.RollbackLink"
+
+	var updated db.Link
+
+	err := s.store.ExecTx(ctx, func(q *db.Queries) error {
+		current, err := q.GetShortLinkById(ctx, db.GetShortLinkByIdParams{
+			UserID: args.UserID,
+			ID:     args.LinkID,
+		})
+		if err != nil {
+			return err
+		}
+
+		dbRevision, err := q.GetLinkRevision(ctx, db.GetLinkRevisionParams{
+			LinkID: args.LinkID,
+			ID:     args.RevisionID,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrRevisionNotFound
+			}
+			return err
+		}
+
+		revision, err := fromDBRevision(dbRevision)
+		if err != nil {
+			return err
+		}
+
+		updated, err = applyValues(ctx, q, current, revision.NewValues, args.UserID, RevisionRollback)
+		return err
+	})
+
+	if err != nil {
+		return Link{}, editError(serviceID, err)
+	}
+
+	return fromDBLink(updated), nil
+}
+
+// applyValues writes values to the link and records the change. Links
+// that are no longer past their expiry or click budget become active again.
+func applyValues(ctx context.Context, q *db.Queries, current db.Link, values LinkValues, userID string, action string) (db.Link, error) {
+	before := valuesOf(current)
+
+	if len(values.changed(before)) == 0 {
+		return current, nil
+	}
+
+	if values.PrettyID != "" && values.PrettyID != current.PrettyID {
+		isTaken, err := q.IsCodeTaken(ctx, db.IsCodeTakenParams{
+			DomainID: current.DomainID,
+			Code:     values.PrettyID,
+		})
+		if err != nil {
+			return db.Link{}, err
+		}
+		if isTaken == 1 {
+			return db.Link{}, ErrAliasTaken
+		}
+	}
+
+	candidate := current
+	candidate.ExpiresAt = sql.NullTime{}
+	if values.ExpiresAt != nil {
+		candidate.ExpiresAt = sql.NullTime{Time: values.ExpiresAt.UTC(), Valid: true}
+	}
+	candidate.MaxClicks = sql.NullInt64{}
+	if values.MaxClicks != nil {
+		candidate.MaxClicks = sql.NullInt64{Int64: *values.MaxClicks, Valid: true}
+	}
+	activatesAt := sql.NullTime{}
+	if values.ActivatesAt != nil {
+		activatesAt = sql.NullTime{Time: values.ActivatesAt.UTC(), Valid: true}
+	}
+
+	status := current.Status
+	if status == StatusActive || status == StatusExpired {
+		candidate.Status = StatusActive
+		status = StatusActive
+		if isExpired(candidate, time.Now()) {
+			status = StatusExpired
+		}
+	}
+
+	updated, err := q.UpdateLink(ctx, db.UpdateLinkParams{
+		OriginalUrl:     values.OriginalURL,
+		DestinationHost: DestinationHost(values.OriginalURL),
+		PrettyID:        values.PrettyID,
+		RedirectType:    values.RedirectType,
+		ExpiresAt:       candidate.ExpiresAt,
+		MaxClicks:       candidate.MaxClicks,
+		ActivatesAt:     activatesAt,
+		Status:          status,
+		ID:              current.ID,
+		UserID:          current.UserID,
+	})
+	if err != nil {
+		return db.Link{}, err
+	}
+
+	if err := recordRevision(ctx, q, userID, current.ID, action, before, valuesOf(updated)); err != nil {
+		return db.Link{}, err
+	}
+
+	return updated, nil
+}
+
+// editError maps errors from inside an edit transaction to the errors the
+// service exposes.
+func editError(serviceID string, err error) error {
+	switch {
+	case err == sql.ErrNoRows:
+		return ErrLinkNotFound
+	case err == ErrLinkNotFound || err == ErrAliasTaken || err == ErrRevisionNotFound || err == ErrInvalidActivation:
+		return err
+	case utils.IsConflictError(err):
+		return ErrAliasTaken
+	default:
+		slog.Error(serviceID, "message", "couldn't edit link", "error", err)
+		return ErrUnknownError
+	}
+}
@@ -0,0 +1,401 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	db "url-shortener/db/sqlc"
+	"url-shortener/internal/utils"
+
+	"github.com/mileusna/useragent"
+)
+
+const MaxRulesPerLink = 20
+
+const (
+	OSIOS      = "ios"
+	OSAndroid  = "android"
+	OSWindows  = "windows"
+	OSMacOS    = "macos"
+	OSLinux    = "linux"
+	OSChromeOS = "chromeos"
+
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceDesktop = "desktop"
+)
+
+var (
+	ruleOSes    = []string{OSIOS, OSAndroid, OSWindows, OSMacOS, OSLinux, OSChromeOS}
+	ruleDevices = []string{DeviceMobile, DeviceTablet, DeviceDesktop}
+
+	// ISO 3166-1 alpha-2 and ISO 3166-2, which is how the geoip databases
+	// name them
+	countryCodePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+	regionCodePattern  = regexp.MustCompile(`^[A-Z]{2}-[A-Z0-9]{1,3}$`)
+)
+
+// Rule sends visitors matching all of its conditions to its own
+// destination. Empty conditions match everyone. Time conditions are read in
+// the rule's timezone.
+type Rule struct {
+	ID             string   `json:"id"`
+	Position       int      `json:"position"`
+	OS             []string `json:"os"`
+	Devices        []string `json:"devices"`
+	Countries      []string `json:"countries"`
+	Regions        []string `json:"regions"`
+	Timezone       string   `json:"timezone,omitempty"`
+	StartsAt       string   `json:"starts_at,omitempty"`
+	EndsAt         string   `json:"ends_at,omitempty"`
+	Days           []string `json:"days"`
+	DailyStart     string   `json:"daily_start,omitempty"`
+	DailyEnd       string   `json:"daily_end,omitempty"`
+	DestinationURL string   `json:"url"`
+	CreatedAt      string   `json:"created_at"`
+
+	schedule schedule
+}
+
+func fromDBRule(dbRule db.LinkRule) Rule {
+	rule := Rule{
+		ID:             dbRule.ID,
+		Position:       int(dbRule.Position),
+		OS:             splitRuleValues(dbRule.Os),
+		Devices:        splitRuleValues(dbRule.Device),
+		Countries:      splitRuleValues(dbRule.Country),
+		Regions:        splitRuleValues(dbRule.Region),
+		Timezone:       dbRule.Timezone.String,
+		Days:           splitRuleValues(dbRule.Days),
+		DailyStart:     dbRule.DailyStart.String,
+		DailyEnd:       dbRule.DailyEnd.String,
+		DestinationURL: dbRule.DestinationUrl,
+		CreatedAt:      utils.ConvertTimeToString(dbRule.CreatedAt),
+		schedule:       scheduleOf(dbRule),
+	}
+	if dbRule.StartsAt.Valid {
+		rule.StartsAt = dbRule.StartsAt.Time.In(rule.schedule.location).Format(time.RFC3339)
+	}
+	if dbRule.EndsAt.Valid {
+		rule.EndsAt = dbRule.EndsAt.Time.In(rule.schedule.location).Format(time.RFC3339)
+	}
+	return rule
+}
+
+func splitRuleValues(values sql.NullString) []string {
+	if !values.Valid || values.String == "" {
+		return []string{}
+	}
+	return strings.Split(values.String, ",")
+}
+
+// Visitor is who a redirect is for, as far as targeting rules and
+// variants care. Location fields are empty when it isn't known.
+type Visitor struct {
+	UserAgent string
+	IP        string
+	// ISO 3166-1 alpha-2 code
+	Country string
+	// ISO 3166-2 code
+	Region string
+	// Variant the visitor got on an earlier visit, if any
+	VariantID string
+}
+
+// Destination is where a visitor is sent.
+type Destination struct {
+	URL string
+	// Set when the visitor was split to one of the link's variants
+	VariantID string
+	// Whether the destination depends on who the visitor is, in which case
+	// it mustn't be cached for everyone
+	Personalized bool
+}
+
+type visitorClient struct {
+	os      string
+	device  string
+	country string
+	region  string
+	now     time.Time
+}
+
+func parseVisitorClient(visitor Visitor) visitorClient {
+	ua := useragent.Parse(visitor.UserAgent)
+
+	client := visitorClient{
+		country: visitor.Country,
+		region:  visitor.Region,
+		now:     time.Now(),
+	}
+
+	switch ua.OS {
+	case useragent.IOS:
+		client.os = OSIOS
+	case useragent.Android:
+		client.os = OSAndroid
+	case useragent.Windows, useragent.WindowsPhone:
+		client.os = OSWindows
+	case useragent.MacOS:
+		client.os = OSMacOS
+	case useragent.Linux:
+		client.os = OSLinux
+	case useragent.ChromeOS, useragent.CrOS:
+		client.os = OSChromeOS
+	}
+
+	// Bots don't get a device, so device rules never send them anywhere
+	switch {
+	case ua.Bot:
+	case ua.Tablet:
+		client.device = DeviceTablet
+	case ua.Mobile:
+		client.device = DeviceMobile
+	case ua.Desktop:
+		client.device = DeviceDesktop
+	}
+
+	return client
+}
+
+func (r Rule) matches(client visitorClient) bool {
+	if len(r.OS) > 0 && !slices.Contains(r.OS, client.os) {
+		return false
+	}
+	if len(r.Devices) > 0 && !slices.Contains(r.Devices, client.device) {
+		return false
+	}
+	if len(r.Countries) > 0 && !slices.Contains(r.Countries, client.country) {
+		return false
+	}
+	if len(r.Regions) > 0 && !slices.Contains(r.Regions, client.region) {
+		return false
+	}
+	return r.schedule.matches(client.now)
+}
+
+// ChooseDestination returns where a visitor of l should be sent: the
+// destination of the first rule they match, else the variant they're split
+// to, else the link's own.
+func (s *LinkService) ChooseDestination(ctx context.Context, l Link, visitor Visitor) Destination {
+	const serviceID = "service.link.ChooseDestination"
+
+	// Errors send everyone to the link's own destination, which beats
+	// failing the redirect
+	dbRules, err := s.queries.ListLinkRules(ctx, l.ID)
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't list link rules", "link", l.ID, "error", err)
+		return Destination{URL: l.OriginalUrl}
+	}
+
+	if len(dbRules) > 0 {
+		client := parseVisitorClient(visitor)
+
+		for _, dbRule := range dbRules {
+			if rule := fromDBRule(dbRule); rule.matches(client) {
+				return Destination{URL: rule.DestinationURL, Personalized: true}
+			}
+		}
+	}
+
+	dbVariants, err := s.queries.ListLinkVariants(ctx, l.ID)
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't list link variants", "link", l.ID, "error", err)
+		return Destination{URL: l.OriginalUrl, Personalized: len(dbRules) > 0}
+	}
+
+	if variant, ok := chooseVariant(l.ID, dbVariants, visitor); ok {
+		return Destination{URL: variant.DestinationUrl, VariantID: variant.ID, Personalized: true}
+	}
+
+	return Destination{URL: l.OriginalUrl, Personalized: len(dbRules) > 0}
+}
+
+type ListLinkRulesParams struct {
+	UserID string
+	LinkID string
+}
+
+func (s *LinkService) ListLinkRules(ctx context.Context, args ListLinkRulesParams) ([]Rule, error) {
+	const serviceID = "service.link.ListLinkRules"
+
+	_, err := s.queries.GetShortLinkById(ctx, db.GetShortLinkByIdParams{
+		UserID: args.UserID,
+		ID:     args.LinkID,
+	})
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrLinkNotFound
+		}
+		slog.Error(serviceID, "message", "couldn't get link", "link", args.LinkID, "error", err)
+		return nil, ErrUnknownError
+	}
+
+	dbRules, err := s.queries.ListLinkRules(ctx, args.LinkID)
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't list link rules", "link", args.LinkID, "error", err)
+		return nil, ErrUnknownError
+	}
+
+	rules := make([]Rule, len(dbRules))
+	for i, dbRule := range dbRules {
+		rules[i] = fromDBRule(dbRule)
+	}
+
+	return rules, nil
+}
+
+type RuleParams struct {
+	OS             []string
+	Devices        []string
+	Countries      []string
+	Regions        []string
+	Schedule       ScheduleParams
+	DestinationURL string
+}
+
+type SetLinkRulesParams struct {
+	UserID string
+	LinkID string
+	// In the order they're tried. An empty list removes all rules.
+	Rules []RuleParams
+}
+
+// SetLinkRules replaces all of a link's rules at once, so the order can
+// never end up half changed.
+func (s *LinkService) SetLinkRules(ctx context.Context, args SetLinkRulesParams) ([]Rule, error) {
+	const serviceID = "service.link.SetLinkRules"
+
+	if len(args.Rules) > MaxRulesPerLink {
+		return nil, ErrTooManyRules
+	}
+
+	params := make([]db.CreateLinkRuleParams, len(args.Rules))
+
+	for i, rule := range args.Rules {
+		osValues, err := normalizeRuleValues(rule.OS, oneOf(ruleOSes))
+		if err != nil {
+			return nil, err
+		}
+		devices, err := normalizeRuleValues(rule.Devices, oneOf(ruleDevices))
+		if err != nil {
+			return nil, err
+		}
+		countries, err := normalizeRuleValues(rule.Countries, matching(countryCodePattern))
+		if err != nil {
+			return nil, err
+		}
+		regions, err := normalizeRuleValues(rule.Regions, matching(regionCodePattern))
+		if err != nil {
+			return nil, err
+		}
+		schedule, err := parseSchedule(rule.Schedule)
+		if err != nil {
+			return nil, err
+		}
+		// A rule without conditions would catch every visitor, which is
+		// what the link's own destination is for
+		if len(osValues) == 0 && len(devices) == 0 && len(countries) == 0 && len(regions) == 0 && schedule.isZero() {
+			return nil, ErrInvalidRule
+		}
+
+		destination, err := NormalizeURL(rule.DestinationURL)
+		if err != nil {
+			return nil, ErrInvalidURL
+		}
+
+		columns := schedule.columns()
+
+		params[i] = db.CreateLinkRuleParams{
+			ID:             utils.NewULID().String(),
+			LinkID:         args.LinkID,
+			Position:       int64(i),
+			Os:             nullString(strings.Join(osValues, ",")),
+			Device:         nullString(strings.Join(devices, ",")),
+			Country:        nullString(strings.Join(countries, ",")),
+			Region:         nullString(strings.Join(regions, ",")),
+			Timezone:       columns.timezone,
+			StartsAt:       columns.startsAt,
+			EndsAt:         columns.endsAt,
+			Days:           columns.days,
+			DailyStart:     columns.dailyStart,
+			DailyEnd:       columns.dailyEnd,
+			DestinationUrl: destination,
+		}
+	}
+
+	rules := make([]Rule, 0, len(params))
+
+	err := s.store.ExecTx(ctx, func(q *db.Queries) error {
+		_, err := q.GetShortLinkById(ctx, db.GetShortLinkByIdParams{
+			UserID: args.UserID,
+			ID:     args.LinkID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := q.DeleteLinkRules(ctx, args.LinkID); err != nil {
+			return err
+		}
+
+		for _, param := range params {
+			created, err := q.CreateLinkRule(ctx, param)
+			if err != nil {
+				return err
+			}
+			rules = append(rules, fromDBRule(created))
+		}
+		return nil
+	})
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrLinkNotFound
+		}
+		slog.Error(serviceID, "message", "couldn't set link rules", "link", args.LinkID, "error", err)
+		return nil, ErrUnknownError
+	}
+
+	return rules, nil
+}
+
+// normalizeRuleValues normalizes and dedupes values, rejecting the whole
+// rule if any of them isn't valid.
+func normalizeRuleValues(values []string, normalize func(string) (string, bool)) ([]string, error) {
+	normalized := []string{}
+	for _, value := range values {
+		value, ok := normalize(strings.TrimSpace(value))
+		if !ok {
+			return nil, ErrInvalidRule
+		}
+		if !slices.Contains(normalized, value) {
+			normalized = append(normalized, value)
+		}
+	}
+	return normalized, nil
+}
+
+// oneOf accepts values from allowed in any case.
+func oneOf(allowed []string) func(string) (string, bool) {
+	return func(value string) (string, bool) {
+		value = strings.ToLower(value)
+		return value, slices.Contains(allowed, value)
+	}
+}
+
+// matching accepts codes that match pattern once uppercased.
+func matching(pattern *regexp.Regexp) func(string) (string, bool) {
+	return func(value string) (string, bool) {
+		value = strings.ToUpper(value)
+		return value, pattern.MatchString(value)
+	}
+}
@@ -0,0 +1,230 @@
+package link
+
+import (
+	"database/sql"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	db "url-shortener/db/sqlc"
+)
+
+const dailyTimeFormat = "15:04"
+
+var (
+	// Indexed by time.Weekday
+	scheduleDays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+	// Times without an offset are read in the rule's timezone
+	localTimeFormats = []string{"2006-01-02T15:04", "2006-01-02T15:04:05"}
+
+	// Rules are checked on every redirect, and loading a location reads
+	// the zoneinfo database each time
+	locations sync.Map
+)
+
+// schedule holds the time conditions of a rule. Zero fields match any
+// time.
+type schedule struct {
+	location *time.Location
+	startsAt time.Time
+	endsAt   time.Time
+	days     []time.Weekday
+	// Minutes since midnight. A window that ends before it starts runs
+	// past midnight.
+	daily      bool
+	dailyStart int
+	dailyEnd   int
+}
+
+func (s schedule) isZero() bool {
+	return s.startsAt.IsZero() && s.endsAt.IsZero() && len(s.days) == 0 && !s.daily
+}
+
+func (s schedule) matches(now time.Time) bool {
+	if !s.startsAt.IsZero() && now.Before(s.startsAt) {
+		return false
+	}
+	if !s.endsAt.IsZero() && !now.Before(s.endsAt) {
+		return false
+	}
+
+	local := now.In(s.location)
+
+	if len(s.days) > 0 && !slices.Contains(s.days, local.Weekday()) {
+		return false
+	}
+
+	if s.daily {
+		minute := local.Hour()*60 + local.Minute()
+		if s.dailyStart < s.dailyEnd {
+			return minute >= s.dailyStart && minute < s.dailyEnd
+		}
+		return minute >= s.dailyStart || minute < s.dailyEnd
+	}
+
+	return true
+}
+
+type ScheduleParams struct {
+	// IANA name, UTC when empty
+	Timezone string
+	// RFC 3339, or local to Timezone like 2006-01-02T15:04
+	StartsAt string
+	EndsAt   string
+	// mon, tue, ...
+	Days []string
+	// HH:MM, both or neither
+	DailyStart string
+	DailyEnd   string
+}
+
+func parseSchedule(args ScheduleParams) (schedule, error) {
+	location := time.UTC
+	if args.Timezone != "" {
+		loaded, err := loadLocation(args.Timezone)
+		if err != nil {
+			return schedule{}, ErrInvalidSchedule
+		}
+		location = loaded
+	}
+
+	s := schedule{location: location}
+
+	var err error
+	if s.startsAt, err = parseScheduleTime(args.StartsAt, location); err != nil {
+		return schedule{}, err
+	}
+	if s.endsAt, err = parseScheduleTime(args.EndsAt, location); err != nil {
+		return schedule{}, err
+	}
+	if !s.startsAt.IsZero() && !s.endsAt.IsZero() && !s.endsAt.After(s.startsAt) {
+		return schedule{}, ErrInvalidSchedule
+	}
+
+	for _, day := range args.Days {
+		index := slices.Index(scheduleDays, strings.ToLower(strings.TrimSpace(day)))
+		if index < 0 {
+			return schedule{}, ErrInvalidSchedule
+		}
+		if !slices.Contains(s.days, time.Weekday(index)) {
+			s.days = append(s.days, time.Weekday(index))
+		}
+	}
+	slices.Sort(s.days)
+
+	if args.DailyStart != "" || args.DailyEnd != "" {
+		start, err := time.Parse(dailyTimeFormat, args.DailyStart)
+		if err != nil {
+			return schedule{}, ErrInvalidSchedule
+		}
+		end, err := time.Parse(dailyTimeFormat, args.DailyEnd)
+		if err != nil || end.Equal(start) {
+			return schedule{}, ErrInvalidSchedule
+		}
+		s.daily = true
+		s.dailyStart = start.Hour()*60 + start.Minute()
+		s.dailyEnd = end.Hour()*60 + end.Minute()
+	}
+
+	return s, nil
+}
+
+func parseScheduleTime(value string, location *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	for _, format := range localTimeFormats {
+		if t, err := time.ParseInLocation(format, value, location); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, ErrInvalidSchedule
+}
+
+func loadLocation(name string) (*time.Location, error) {
+	if cached, ok := locations.Load(name); ok {
+		return cached.(*time.Location), nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, location)
+	return location, nil
+}
+
+// scheduleOf reads the time conditions of a stored rule.
+func scheduleOf(dbRule db.LinkRule) schedule {
+	location := time.UTC
+	if dbRule.Timezone.Valid {
+		// Names were checked when the rule was saved
+		if loaded, err := loadLocation(dbRule.Timezone.String); err == nil {
+			location = loaded
+		}
+	}
+
+	s := schedule{
+		location: location,
+		startsAt: dbRule.StartsAt.Time,
+		endsAt:   dbRule.EndsAt.Time,
+	}
+
+	for _, day := range splitRuleValues(dbRule.Days) {
+		if index := slices.Index(scheduleDays, day); index >= 0 {
+			s.days = append(s.days, time.Weekday(index))
+		}
+	}
+
+	start, startErr := time.Parse(dailyTimeFormat, dbRule.DailyStart.String)
+	end, endErr := time.Parse(dailyTimeFormat, dbRule.DailyEnd.String)
+	if startErr == nil && endErr == nil {
+		s.daily = true
+		s.dailyStart = start.Hour()*60 + start.Minute()
+		s.dailyEnd = end.Hour()*60 + end.Minute()
+	}
+
+	return s
+}
+
+// scheduleColumns is how a schedule is stored in a rule's row.
+type scheduleColumns struct {
+	timezone   sql.NullString
+	startsAt   sql.NullTime
+	endsAt     sql.NullTime
+	days       sql.NullString
+	dailyStart sql.NullString
+	dailyEnd   sql.NullString
+}
+
+func (s schedule) columns() scheduleColumns {
+	if s.isZero() {
+		return scheduleColumns{}
+	}
+
+	days := make([]string, len(s.days))
+	for i, day := range s.days {
+		days[i] = scheduleDays[day]
+	}
+
+	columns := scheduleColumns{
+		timezone: nullString(s.location.String()),
+		startsAt: sql.NullTime{Time: s.startsAt, Valid: !s.startsAt.IsZero()},
+		endsAt:   sql.NullTime{Time: s.endsAt, Valid: !s.endsAt.IsZero()},
+		days:     nullString(strings.Join(days, ",")),
+	}
+	if s.daily {
+		columns.dailyStart = nullString(formatDailyTime(s.dailyStart))
+		columns.dailyEnd = nullString(formatDailyTime(s.dailyEnd))
+	}
+
+	return columns
+}
+
+func formatDailyTime(minutes int) string {
+	return time.Date(0, 1, 1, minutes/60, minutes%60, 0, 0, time.UTC).Format(dailyTimeFormat)
+}
@@ -0,0 +1,92 @@
+package link
+
+import (
+	"context"
+	"html"
+	"log/slog"
+	"strings"
+	"unicode"
+	db "url-shortener/db/sqlc"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 50
+
+	// Longer queries are cut down to this many terms
+	maxSearchTerms = 10
+)
+
+var snippetMarks = strings.NewReplacer("\x02", "<mark>", "\x03", "</mark>")
+
+type SearchLinksParams struct {
+	UserID string
+	Query  string
+	Limit  int
+}
+
+// SearchResult is a link matching a search. Snippet is HTML-escaped text
+// from the best matching field, with the matched terms wrapped in <mark>.
+type SearchResult struct {
+	Link    Link
+	Snippet string
+}
+
+// SearchLinks finds the user's links whose destination, code, title or tags
+// contain every word of the query. The last characters of each word may be
+// missing, so results show up while the query is still being typed.
+func (s *LinkService) SearchLinks(ctx context.Context, args SearchLinksParams) ([]SearchResult, error) {
+	const serviceID = "service.link.SearchLinks"
+
+	match := matchExpression(args.Query)
+	if match == "" {
+		return nil, ErrInvalidSearchQuery
+	}
+
+	limit := args.Limit
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	limit = min(limit, MaxSearchLimit)
+
+	rows, err := s.queries.SearchLinks(ctx, db.SearchLinksParams{
+		Query:      match,
+		UserID:     args.UserID,
+		MaxResults: int64(limit),
+	})
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't search links", "error", err)
+		return nil, ErrUnknownError
+	}
+
+	results := make([]SearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = SearchResult{
+			Link:    fromDBLink(row.Link),
+			Snippet: snippetMarks.Replace(html.EscapeString(row.Snippet)),
+		}
+	}
+
+	return results, nil
+}
+
+// matchExpression turns free text into a MATCH expression that prefix-matches
+// every word. Anything but letters and digits separates words, so none of the
+// query syntax gets through. Lowercasing keeps words like "or" and "near" from
+// being read as operators.
+func matchExpression(query string) string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	if len(words) > maxSearchTerms {
+		words = words[:maxSearchTerms]
+	}
+
+	for i, word := range words {
+		words[i] = word + "*"
+	}
+
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,391 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	db "url-shortener/db/sqlc"
+	"url-shortener/internal/utils"
+)
+
+const maxCodeAttempts = 5
+
+type LinkService struct {
+	store          *db.Store
+	queries        *db.Queries
+	codeGenerator  CodeGenerator
+	resolver       TXTResolver
+	trashRetention time.Duration
+}
+
+// NewLinkService builds the service. A nil resolver verifies domains with
+// the system's DNS resolver.
+func NewLinkService(store *db.Store, codeGenerator CodeGenerator, resolver TXTResolver, trashRetention time.Duration) *LinkService {
+	if trashRetention <= 0 {
+		trashRetention = DefaultTrashRetention
+	}
+
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	return &LinkService{
+		store:          store,
+		queries:        store.Queries,
+		codeGenerator:  codeGenerator,
+		resolver:       resolver,
+		trashRetention: trashRetention,
+	}
+}
+
+type GetLinkParams struct {
+	UserID string
+	LinkID string
+}
+
+func (s *LinkService) GetLink(ctx context.Context, args GetLinkParams) (Link, error) {
+	const serviceID = "service.link.GetLink"
+
+	dbLink, err := s.queries.GetShortLinkById(ctx, db.GetShortLinkByIdParams{
+		UserID: args.UserID,
+		ID:     args.LinkID,
+	})
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Link{}, ErrLinkNotFound
+		}
+		slog.Error(serviceID, "message", "couldn't get link", "link", args.LinkID, "error", err)
+		return Link{}, ErrUnknownError
+	}
+
+	return fromDBLink(dbLink), nil
+}
+
+// ResolveLink finds the link a public short code or pretty ID points to on
+// the domain hostname belongs to. It isn't scoped to a user since anyone can
+// follow a short link.
+func (s *LinkService) ResolveLink(ctx context.Context, hostname string, code string) (Link, error) {
+	const serviceID = "service.link.ResolveLink"
+
+	if code == "" {
+		return Link{}, ErrLinkNotFound
+	}
+
+	domainID, err := s.domainForHost(ctx, hostname)
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't get domain by hostname", "hostname", hostname, "error", err)
+		return Link{}, ErrUnknownError
+	}
+
+	dbLink, err := s.queries.GetLinkByCode(ctx, db.GetLinkByCodeParams{
+		DomainID: domainID,
+		Code:     code,
+	})
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Link{}, ErrLinkNotFound
+		}
+		slog.Error(serviceID, "message", "couldn't get link by code", "code", code, "error", err)
+		return Link{}, ErrUnknownError
+	}
+
+	if dbLink.DeletedAt.Valid {
+		return Link{}, ErrLinkNotFound
+	}
+
+	now := time.Now()
+
+	if dbLink.ActivatesAt.Valid && now.Before(dbLink.ActivatesAt.Time) {
+		return fromDBLink(dbLink), ErrLinkNotActive
+	}
+
+	if isExpired(dbLink, now) {
+		return fromDBLink(dbLink), ErrLinkExpired
+	}
+
+	resolved := fromDBLink(dbLink)
+
+	if dbLink.CampaignID.Valid {
+		// Redirects go ahead without the campaign's UTM values rather than
+		// fail
+		row, err := s.queries.GetCampaignUTM(ctx, dbLink.CampaignID.String)
+		if err != nil && err != sql.ErrNoRows {
+			slog.Error(serviceID, "message", "couldn't get campaign utm", "campaign", dbLink.CampaignID.String, "error", err)
+		}
+		resolved.campaignUTM = UTM{
+			Source:   row.UtmSource.String,
+			Medium:   row.UtmMedium.String,
+			Campaign: row.UtmCampaign.String,
+			Term:     row.UtmTerm.String,
+			Content:  row.UtmContent.String,
+		}
+	}
+
+	return resolved, nil
+}
+
+// validActivation checks that a link activates in the future and, if it
+// expires, before it does.
+func validActivation(activatesAt time.Time, expiresAt sql.NullTime) bool {
+	if !activatesAt.After(time.Now()) {
+		return false
+	}
+	return !expiresAt.Valid || activatesAt.Before(expiresAt.Time)
+}
+
+type CreateLinkParams struct {
+	UserID       string
+	OriginalURL  string
+	RedirectType int
+	// Optional
+	Alias string
+	// Optional. A zero ExpiresAt or MaxClicks means the link never expires
+	// that way.
+	ExpiresAt   time.Time
+	MaxClicks   int64
+	FallbackURL string
+	// Optional. Before it, the link doesn't redirect.
+	ActivatesAt time.Time
+	// Optional. One of the user's verified domains; links are on the shared
+	// domain without one.
+	DomainID string
+}
+
+// newLink is a validated CreateLinkParams, ready to be inserted.
+type newLink struct {
+	userID       string
+	destination  string
+	alias        string
+	redirectType int64
+	expiresAt    sql.NullTime
+	maxClicks    sql.NullInt64
+	fallbackURL  string
+	activatesAt  sql.NullTime
+	domainID     sql.NullString
+}
+
+func prepareLink(args CreateLinkParams) (newLink, error) {
+	destination, err := NormalizeURL(args.OriginalURL)
+	if err != nil {
+		return newLink{}, err
+	}
+
+	var alias string
+	if args.Alias != "" {
+		alias, err = NormalizeAlias(args.Alias)
+		if err != nil {
+			return newLink{}, err
+		}
+	}
+
+	redirectType := args.RedirectType
+	if redirectType == 0 {
+		redirectType = http.StatusFound
+	}
+
+	expiresAt := sql.NullTime{Time: args.ExpiresAt.UTC(), Valid: !args.ExpiresAt.IsZero()}
+	if expiresAt.Valid && !args.ExpiresAt.After(time.Now()) {
+		return newLink{}, ErrInvalidExpiry
+	}
+
+	activatesAt := sql.NullTime{Time: args.ActivatesAt.UTC(), Valid: !args.ActivatesAt.IsZero()}
+	if activatesAt.Valid && !validActivation(args.ActivatesAt, expiresAt) {
+		return newLink{}, ErrInvalidActivation
+	}
+
+	var fallbackURL string
+	if args.FallbackURL != "" {
+		fallbackURL, err = NormalizeURL(args.FallbackURL)
+		if err != nil {
+			return newLink{}, ErrInvalidFallbackURL
+		}
+	}
+
+	return newLink{
+		userID:       args.UserID,
+		destination:  destination,
+		alias:        alias,
+		redirectType: int64(redirectType),
+		expiresAt:    expiresAt,
+		maxClicks:    sql.NullInt64{Int64: args.MaxClicks, Valid: args.MaxClicks > 0},
+		fallbackURL:  fallbackURL,
+		activatesAt:  activatesAt,
+		domainID:     nullString(args.DomainID),
+	}, nil
+}
+
+func (s *LinkService) CreateLink(ctx context.Context, args CreateLinkParams) (Link, error) {
+	const serviceID = "service.link.CreateLink"
+
+	input, err := prepareLink(args)
+
+	if err != nil {
+		slog.Info(serviceID, "message", "invalid link", "url", args.OriginalURL, "error", err)
+		return Link{}, err
+	}
+
+	var createdLink db.Link
+
+	err = s.store.ExecTx(ctx, func(q *db.Queries) error {
+		var err error
+		createdLink, err = s.insertLink(ctx, q, input)
+		return err
+	})
+
+	if err != nil {
+		return Link{}, createError(serviceID, err)
+	}
+
+	return fromDBLink(createdLink), nil
+}
+
+// isSameLink tells whether a new link can be answered with an existing one
+// to the same destination. Links that expire, activate later, have an alias
+// or a password, or are in the trash are never shared, or one would change
+// the other.
+func isSameLink(existing db.Link, input newLink) bool {
+	return existing.OriginalUrl == input.destination && existing.DomainID == input.domainID &&
+		input.alias == "" && !input.expiresAt.Valid && !input.maxClicks.Valid && !input.activatesAt.Valid &&
+		existing.PrettyID == "" && !existing.ExpiresAt.Valid && !existing.MaxClicks.Valid && !existing.ActivatesAt.Valid &&
+		existing.PasswordHash == "" && !existing.DeletedAt.Valid
+}
+
+// insertLink finds a free short code for the link and inserts it. It runs
+// inside a transaction, so everything including code generation goes
+// through q.
+func (s *LinkService) insertLink(ctx context.Context, q *db.Queries, input newLink) (db.Link, error) {
+	const serviceID = "service.link.insertLink"
+
+	if err := checkLinkDomain(ctx, q, input.userID, input.domainID); err != nil {
+		return db.Link{}, err
+	}
+
+	if input.alias != "" {
+		isTaken, err := q.IsCodeTaken(ctx, db.IsCodeTakenParams{
+			DomainID: input.domainID,
+			Code:     input.alias,
+		})
+		if err != nil {
+			return db.Link{}, err
+		}
+		if isTaken == 1 {
+			return db.Link{}, ErrAliasTaken
+		}
+	}
+
+	for attempt := 0; attempt < maxCodeAttempts; attempt++ {
+		code, err := s.codeGenerator.Generate(ctx, q, input.destination, attempt)
+
+		if err != nil {
+			slog.Error(serviceID, "message", "couldn't generate short code", "error", err)
+			if err == ErrCodeSpaceExhausted {
+				return db.Link{}, err
+			}
+			return db.Link{}, ErrGeneratingCode
+		}
+
+		isTaken, err := q.IsCodeTaken(ctx, db.IsCodeTakenParams{
+			DomainID: input.domainID,
+			Code:     code,
+		})
+
+		if err != nil {
+			return db.Link{}, err
+		}
+
+		if isTaken == 1 {
+			// The hash strategy hands out the same code for the same
+			// destination, so the code may be the user's own link to it
+			if _, ok := s.codeGenerator.(*hashGenerator); ok {
+				existingLink, err := q.GetShortLinkByShortUrlId(ctx, db.GetShortLinkByShortUrlIdParams{
+					UserID:     input.userID,
+					ShortUrlID: code,
+				})
+				if err == nil && isSameLink(existingLink, input) {
+					return existingLink, nil
+				}
+				if err != nil && err != sql.ErrNoRows {
+					return db.Link{}, err
+				}
+			}
+
+			slog.Warn(serviceID, "message", "short code collision, retrying", "code", code, "attempt", attempt)
+			continue
+		}
+
+		createdLink, err := q.CreateShortLink(ctx, db.CreateShortLinkParams{
+			ID:              utils.NewULID().String(),
+			UserID:          input.userID,
+			OriginalUrl:     input.destination,
+			ShortUrlID:      code,
+			PrettyID:        input.alias,
+			RedirectType:    input.redirectType,
+			DestinationHost: DestinationHost(input.destination),
+			ExpiresAt:       input.expiresAt,
+			MaxClicks:       input.maxClicks,
+			FallbackUrl:     input.fallbackURL,
+			ActivatesAt:     input.activatesAt,
+			DomainID:        input.domainID,
+		})
+
+		if err != nil {
+			// The alias was free when it was checked, but someone claimed
+			// it since
+			if utils.IsIndexConflictError(err, aliasIndex) {
+				return db.Link{}, ErrAliasTaken
+			}
+			if !utils.IsConflictError(err) {
+				return db.Link{}, err
+			}
+			slog.Warn(serviceID, "message", "short code claimed concurrently, retrying", "code", code, "attempt", attempt)
+			continue
+		}
+
+		if err := recordRevision(ctx, q, input.userID, createdLink.ID, RevisionCreate, LinkValues{}, valuesOf(createdLink)); err != nil {
+			return db.Link{}, err
+		}
+
+		return createdLink, nil
+	}
+
+	slog.Error(serviceID, "message", "ran out of attempts generating a short code")
+	return db.Link{}, ErrGeneratingCode
+}
+
+// createError maps errors from inside a create transaction to the errors the
+// service exposes.
+func createError(serviceID string, err error) error {
+	switch err {
+	case ErrCodeSpaceExhausted, ErrGeneratingCode, ErrAliasTaken, ErrDomainNotFound, ErrDomainNotVerified:
+		return err
+	}
+	slog.Error(serviceID, "message", "couldn't create link", "error", err)
+	return ErrCreatingLink
+}
+
+type ClaimAliasParams struct {
+	UserID string
+	LinkID string
+	Alias  string
+}
+
+// ClaimAlias gives a link a human-readable pretty ID. Aliases share the
+// namespace of generated short codes, so they can't shadow an existing code.
+func (s *LinkService) ClaimAlias(ctx context.Context, args ClaimAliasParams) (Link, error) {
+	if strings.TrimSpace(args.Alias) == "" {
+		return Link{}, ErrInvalidAlias
+	}
+
+	return s.UpdateLink(ctx, UpdateLinkParams{
+		UserID: args.UserID,
+		LinkID: args.LinkID,
+		Alias:  &args.Alias,
+	})
+}
@@ -0,0 +1,268 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+	db "url-shortener/db/sqlc"
+	"url-shortener/internal/utils"
+)
+
+const maxTagNameLength = 50
+
+type Tag struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	LinkCount int64  `json:"link_count"`
+	CreatedAt string `json:"created_at"`
+}
+
+func fromDBTag(dbTag db.Tag) Tag {
+	return Tag{
+		ID:        dbTag.ID,
+		Name:      dbTag.Name,
+		CreatedAt: utils.ConvertTimeToString(dbTag.CreatedAt),
+	}
+}
+
+// NormalizeTagName lower-cases a tag and collapses its whitespace, so "Summer
+// Sale" and " summer  sale" are the same tag.
+func NormalizeTagName(name string) (string, error) {
+	name = strings.Join(strings.Fields(strings.ToLower(name)), " ")
+
+	if name == "" || utf8.RuneCountInString(name) > maxTagNameLength || strings.Contains(name, ",") {
+		return "", ErrInvalidTagName
+	}
+
+	return name, nil
+}
+
+func normalizeTagNames(names []string) ([]string, error) {
+	seen := make(map[string]bool, len(names))
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		tag, err := NormalizeTagName(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, nil
+}
+
+func (s *LinkService) ListTags(ctx context.Context, userID string) ([]Tag, error) {
+	const serviceID = "service.link.ListTags"
+
+	rows, err := s.queries.ListTags(ctx, userID)
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't list tags", "error", err)
+		return nil, ErrUnknownError
+	}
+
+	tags := make([]Tag, 0, len(rows))
+	for _, row := range rows {
+		tags = append(tags, Tag{
+			ID:        row.ID,
+			Name:      row.Name,
+			LinkCount: row.LinkCount,
+			CreatedAt: utils.ConvertTimeToString(row.CreatedAt),
+		})
+	}
+
+	return tags, nil
+}
+
+type CreateTagParams struct {
+	UserID string
+	Name   string
+}
+
+func (s *LinkService) CreateTag(ctx context.Context, args CreateTagParams) (Tag, error) {
+	const serviceID = "service.link.CreateTag"
+
+	name, err := NormalizeTagName(args.Name)
+	if err != nil {
+		return Tag{}, err
+	}
+
+	createdTag, err := s.queries.CreateTag(ctx, db.CreateTagParams{
+		ID:     utils.NewULID().String(),
+		UserID: args.UserID,
+		Name:   name,
+	})
+
+	if err != nil {
+		if utils.IsConflictError(err) {
+			return Tag{}, ErrTagExists
+		}
+		slog.Error(serviceID, "message", "couldn't create tag", "error", err)
+		return Tag{}, ErrUnknownError
+	}
+
+	return fromDBTag(createdTag), nil
+}
+
+type RenameTagParams struct {
+	UserID string
+	TagID  string
+	Name   string
+}
+
+func (s *LinkService) RenameTag(ctx context.Context, args RenameTagParams) (Tag, error) {
+	const serviceID = "service.link.RenameTag"
+
+	name, err := NormalizeTagName(args.Name)
+	if err != nil {
+		return Tag{}, err
+	}
+
+	renamedTag, err := s.queries.RenameTag(ctx, db.RenameTagParams{
+		Name:   name,
+		ID:     args.TagID,
+		UserID: args.UserID,
+	})
+
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			return Tag{}, ErrTagNotFound
+		case utils.IsConflictError(err):
+			return Tag{}, ErrTagExists
+		}
+		slog.Error(serviceID, "message", "couldn't rename tag", "tag", args.TagID, "error", err)
+		return Tag{}, ErrUnknownError
+	}
+
+	return fromDBTag(renamedTag), nil
+}
+
+type DeleteTagParams struct {
+	UserID string
+	TagID  string
+}
+
+// DeleteTag removes a tag from every link that has it, then the tag itself.
+func (s *LinkService) DeleteTag(ctx context.Context, args DeleteTagParams) error {
+	const serviceID = "service.link.DeleteTag"
+
+	err := s.store.ExecTx(ctx, func(q *db.Queries) error {
+		if _, err := q.GetTag(ctx, db.GetTagParams{ID: args.TagID, UserID: args.UserID}); err != nil {
+			return err
+		}
+		if err := q.DeleteTagLinks(ctx, args.TagID); err != nil {
+			return err
+		}
+		return q.DeleteTag(ctx, db.DeleteTagParams{ID: args.TagID, UserID: args.UserID})
+	})
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrTagNotFound
+		}
+		slog.Error(serviceID, "message", "couldn't delete tag", "tag", args.TagID, "error", err)
+		return ErrUnknownError
+	}
+
+	return nil
+}
+
+// LinkBatchResult is the outcome of changing many links at once. Links that
+// don't exist or belong to someone else are skipped and listed.
+type LinkBatchResult struct {
+	Updated  int      `json:"updated"`
+	NotFound []string `json:"not_found"`
+}
+
+type TagLinksParams struct {
+	UserID  string
+	LinkIDs []string
+	// Tags to add, created if they don't exist yet
+	Add []string
+	// Tags to remove
+	Remove []string
+}
+
+// TagLinks adds and removes tags on many links in one transaction.
+func (s *LinkService) TagLinks(ctx context.Context, args TagLinksParams) (LinkBatchResult, error) {
+	const serviceID = "service.link.TagLinks"
+
+	add, err := normalizeTagNames(args.Add)
+	if err != nil {
+		return LinkBatchResult{}, err
+	}
+
+	remove, err := normalizeTagNames(args.Remove)
+	if err != nil {
+		return LinkBatchResult{}, err
+	}
+
+	result := LinkBatchResult{NotFound: []string{}}
+
+	err = s.store.ExecTx(ctx, func(q *db.Queries) error {
+		addIDs := make([]string, 0, len(add))
+		for _, name := range add {
+			tag, err := q.GetTagByName(ctx, db.GetTagByNameParams{UserID: args.UserID, Name: name})
+			if err == sql.ErrNoRows {
+				tag, err = q.CreateTag(ctx, db.CreateTagParams{
+					ID:     utils.NewULID().String(),
+					UserID: args.UserID,
+					Name:   name,
+				})
+			}
+			if err != nil {
+				return err
+			}
+			addIDs = append(addIDs, tag.ID)
+		}
+
+		removeIDs := make([]string, 0, len(remove))
+		for _, name := range remove {
+			tag, err := q.GetTagByName(ctx, db.GetTagByNameParams{UserID: args.UserID, Name: name})
+			if err == sql.ErrNoRows {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			removeIDs = append(removeIDs, tag.ID)
+		}
+
+		for _, linkID := range args.LinkIDs {
+			_, err := q.GetShortLinkById(ctx, db.GetShortLinkByIdParams{UserID: args.UserID, ID: linkID})
+			if err == sql.ErrNoRows {
+				result.NotFound = append(result.NotFound, linkID)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			for _, tagID := range addIDs {
+				if err := q.TagLink(ctx, db.TagLinkParams{LinkID: linkID, TagID: tagID}); err != nil {
+					return err
+				}
+			}
+			for _, tagID := range removeIDs {
+				if err := q.UntagLink(ctx, db.UntagLinkParams{LinkID: linkID, TagID: tagID}); err != nil {
+					return err
+				}
+			}
+			result.Updated++
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't tag links", "error", err)
+		return LinkBatchResult{}, ErrUnknownError
+	}
+
+	return result, nil
+}
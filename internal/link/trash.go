@@ -0,0 +1,186 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+	db "url-shortener/db/sqlc"
+)
+
+const (
+	DefaultTrashRetention     = 30 * 24 * time.Hour
+	defaultTrashPurgeInterval = time.Hour
+	purgeBatchSize            = 100
+)
+
+type TrashLinkParams struct {
+	UserID string
+	LinkID string
+}
+
+// TrashLink takes a link out of service without deleting it. Its code stays
+// taken, and it can be restored until the retention window runs out.
+func (s *LinkService) TrashLink(ctx context.Context, args TrashLinkParams) (Link, error) {
+	const serviceID = "service.link.TrashLink"
+
+	trashedLink, err := s.queries.TrashLink(ctx, db.TrashLinkParams{
+		DeletedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:        args.LinkID,
+		UserID:    args.UserID,
+	})
+
+	if err != nil {
+		// Links that are already in the trash don't match either
+		if err == sql.ErrNoRows {
+			return Link{}, ErrLinkNotFound
+		}
+		slog.Error(serviceID, "message", "couldn't trash link", "link", args.LinkID, "error", err)
+		return Link{}, ErrUnknownError
+	}
+
+	return fromDBLink(trashedLink), nil
+}
+
+type RestoreLinkParams struct {
+	UserID string
+	LinkID string
+}
+
+func (s *LinkService) RestoreLink(ctx context.Context, args RestoreLinkParams) (Link, error) {
+	const serviceID = "service.link.RestoreLink"
+
+	var restored db.Link
+
+	err := s.store.ExecTx(ctx, func(q *db.Queries) error {
+		current, err := q.GetShortLinkById(ctx, db.GetShortLinkByIdParams{
+			UserID: args.UserID,
+			ID:     args.LinkID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if !current.DeletedAt.Valid {
+			return ErrLinkNotTrashed
+		}
+
+		if time.Since(current.DeletedAt.Time) > s.trashRetention {
+			return ErrRestoreWindowPassed
+		}
+
+		// The link may have expired while it was in the trash
+		status := StatusActive
+		current.Status = StatusActive
+		if isExpired(current, time.Now()) {
+			status = StatusExpired
+		}
+
+		restored, err = q.RestoreLink(ctx, db.RestoreLinkParams{
+			Status: status,
+			ID:     current.ID,
+			UserID: current.UserID,
+		})
+		return err
+	})
+
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return Link{}, ErrLinkNotFound
+		case ErrLinkNotTrashed, ErrRestoreWindowPassed:
+			return Link{}, err
+		}
+		slog.Error(serviceID, "message", "couldn't restore link", "link", args.LinkID, "error", err)
+		return Link{}, ErrUnknownError
+	}
+
+	return fromDBLink(restored), nil
+}
+
+// RunTrashPurger permanently deletes links that have been in the trash for
+// longer than the retention window, every interval until ctx is cancelled.
+func (s *LinkService) RunTrashPurger(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultTrashPurgeInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.PurgeTrashedLinks(ctx)
+		}
+	}
+}
+
+// PurgeTrashedLinks deletes a link along with its clicks, stats, tags and
+// history. The short code and alias are retired so they're never issued
+// again on the link's domain. Each link is purged in its own transaction to keep the database
+// lock short.
+func (s *LinkService) PurgeTrashedLinks(ctx context.Context) {
+	const serviceID = "service.link.PurgeTrashedLinks"
+
+	for {
+		links, err := s.queries.ListPurgeableLinks(ctx, db.ListPurgeableLinksParams{
+			DeletedBefore: time.Now().UTC().Add(-s.trashRetention),
+			MaxLinks:      purgeBatchSize,
+		})
+
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error(serviceID, "message", "couldn't list purgeable links", "error", err)
+			}
+			return
+		}
+
+		for _, purgeable := range links {
+			err := s.store.ExecTx(ctx, func(q *db.Queries) error {
+				if err := q.RetireCode(ctx, db.RetireCodeParams{DomainID: purgeable.DomainID, Code: purgeable.ShortUrlID}); err != nil {
+					return err
+				}
+				if purgeable.PrettyID != "" {
+					if err := q.RetireCode(ctx, db.RetireCodeParams{DomainID: purgeable.DomainID, Code: purgeable.PrettyID}); err != nil {
+						return err
+					}
+				}
+
+				for _, deleteRows := range []func(context.Context, string) error{
+					q.DeleteLinkClicks,
+					q.DeleteLinkHourlyRollups,
+					q.DeleteLinkDailyRollups,
+					q.DeleteLinkDimensionRollups,
+					q.DeleteLinkTags,
+					q.DeleteLinkRevisions,
+					q.DeleteLinkRules,
+					q.DeleteLinkVariants,
+				} {
+					if err := deleteRows(ctx, purgeable.ID); err != nil {
+						return err
+					}
+				}
+
+				return q.PurgeLink(ctx, purgeable.ID)
+			})
+
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.Error(serviceID, "message", "couldn't purge link", "link", purgeable.ID, "error", err)
+				}
+				return
+			}
+		}
+
+		if len(links) > 0 {
+			slog.Info(serviceID, "message", "purged links", "count", len(links))
+		}
+
+		if len(links) < purgeBatchSize {
+			return
+		}
+	}
+}
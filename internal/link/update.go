@@ -0,0 +1,119 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+	db "url-shortener/db/sqlc"
+)
+
+// UpdateLinkParams only changes the fields that are set. Setting Alias to
+// "", ExpiresAt or ActivatesAt to the zero time or MaxClicks to 0 removes
+// them.
+type UpdateLinkParams struct {
+	UserID       string
+	LinkID       string
+	OriginalURL  *string
+	Alias        *string
+	RedirectType *int
+	ExpiresAt    *time.Time
+	MaxClicks    *int64
+	ActivatesAt  *time.Time
+}
+
+func (s *LinkService) UpdateLink(ctx context.Context, args UpdateLinkParams) (Link, error) {
+	const serviceID = "service.link.UpdateLink"
+
+	var destination string
+	if args.OriginalURL != nil {
+		normalized, err := NormalizeURL(*args.OriginalURL)
+		if err != nil {
+			slog.Info(serviceID, "message", "invalid destination", "url", *args.OriginalURL, "error", err)
+			return Link{}, err
+		}
+		destination = normalized
+	}
+
+	var alias string
+	if args.Alias != nil && *args.Alias != "" {
+		normalized, err := NormalizeAlias(*args.Alias)
+		if err != nil {
+			slog.Info(serviceID, "message", "alias rejected", "alias", *args.Alias, "error", err)
+			return Link{}, err
+		}
+		alias = normalized
+	}
+
+	if args.ExpiresAt != nil && !args.ExpiresAt.IsZero() && !args.ExpiresAt.After(time.Now()) {
+		return Link{}, ErrInvalidExpiry
+	}
+
+	var updated db.Link
+
+	err := s.store.ExecTx(ctx, func(q *db.Queries) error {
+		current, err := q.GetShortLinkById(ctx, db.GetShortLinkByIdParams{
+			UserID: args.UserID,
+			ID:     args.LinkID,
+		})
+		if err != nil {
+			return err
+		}
+		// Trashed links have to be restored before they can be edited
+		if current.DeletedAt.Valid {
+			return ErrLinkNotFound
+		}
+
+		values := valuesOf(current)
+		if args.OriginalURL != nil {
+			values.OriginalURL = destination
+		}
+		if args.Alias != nil {
+			values.PrettyID = alias
+		}
+		if args.RedirectType != nil {
+			values.RedirectType = int64(*args.RedirectType)
+		}
+		if args.ExpiresAt != nil {
+			values.ExpiresAt = nil
+			if !args.ExpiresAt.IsZero() {
+				expiresAt := args.ExpiresAt.UTC()
+				values.ExpiresAt = &expiresAt
+			}
+		}
+		if args.MaxClicks != nil {
+			values.MaxClicks = nil
+			if *args.MaxClicks > 0 {
+				values.MaxClicks = args.MaxClicks
+			}
+		}
+		if args.ActivatesAt != nil {
+			values.ActivatesAt = nil
+			if !args.ActivatesAt.IsZero() {
+				activatesAt := args.ActivatesAt.UTC()
+				values.ActivatesAt = &activatesAt
+			}
+		}
+
+		// Checked against the expiry the link ends up with, which may be
+		// the one it already had
+		if args.ActivatesAt != nil && values.ActivatesAt != nil {
+			expiresAt := sql.NullTime{}
+			if values.ExpiresAt != nil {
+				expiresAt = sql.NullTime{Time: *values.ExpiresAt, Valid: true}
+			}
+			if !validActivation(*values.ActivatesAt, expiresAt) {
+				return ErrInvalidActivation
+			}
+		}
+
+		updated, err = applyValues(ctx, q, current, values, args.UserID, RevisionUpdate)
+		return err
+	})
+
+	if err != nil {
+		return Link{}, editError(serviceID, err)
+	}
+
+	return fromDBLink(updated), nil
+}
@@ -0,0 +1,304 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"log/slog"
+	db "url-shortener/db/sqlc"
+	"url-shortener/internal/utils"
+)
+
+const (
+	MaxVariantsPerLink = 10
+	MaxVariantWeight   = 1000
+)
+
+// Variant is one of the destinations an A/B test splits a link's visitors
+// between. Each gets Weight out of the total weight of the link's variants.
+type Variant struct {
+	ID             string `json:"id"`
+	DestinationURL string `json:"url"`
+	Weight         int    `json:"weight"`
+	CreatedAt      string `json:"created_at"`
+}
+
+func fromDBVariant(dbVariant db.LinkVariant) Variant {
+	return Variant{
+		ID:             dbVariant.ID,
+		DestinationURL: dbVariant.DestinationUrl,
+		Weight:         int(dbVariant.Weight),
+		CreatedAt:      utils.ConvertTimeToString(dbVariant.CreatedAt),
+	}
+}
+
+// chooseVariant splits a visitor to one of variants by weight. Visitors
+// keep the variant they got before while it isn't paused. New visitors are
+// placed by a hash of who they are rather than at random, so those who
+// don't keep cookies mostly land on the same variant every time too.
+func chooseVariant(linkID string, variants []db.LinkVariant, visitor Visitor) (db.LinkVariant, bool) {
+	var total int64
+	for _, variant := range variants {
+		if variant.ID == visitor.VariantID && variant.Weight > 0 {
+			return variant, true
+		}
+		total += variant.Weight
+	}
+
+	if total == 0 {
+		return db.LinkVariant{}, false
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(linkID + "\x00" + visitor.IP + "\x00" + visitor.UserAgent))
+	bucket := int64(hash.Sum64() % uint64(total))
+
+	for _, variant := range variants {
+		if bucket < variant.Weight {
+			return variant, true
+		}
+		bucket -= variant.Weight
+	}
+
+	return db.LinkVariant{}, false
+}
+
+type ListLinkVariantsParams struct {
+	UserID string
+	LinkID string
+}
+
+func (s *LinkService) ListLinkVariants(ctx context.Context, args ListLinkVariantsParams) ([]Variant, error) {
+	const serviceID = "service.link.ListLinkVariants"
+
+	_, err := s.queries.GetShortLinkById(ctx, db.GetShortLinkByIdParams{
+		UserID: args.UserID,
+		ID:     args.LinkID,
+	})
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrLinkNotFound
+		}
+		slog.Error(serviceID, "message", "couldn't get link", "link", args.LinkID, "error", err)
+		return nil, ErrUnknownError
+	}
+
+	dbVariants, err := s.queries.ListLinkVariants(ctx, args.LinkID)
+
+	if err != nil {
+		slog.Error(serviceID, "message", "couldn't list link variants", "link", args.LinkID, "error", err)
+		return nil, ErrUnknownError
+	}
+
+	variants := make([]Variant, len(dbVariants))
+	for i, dbVariant := range dbVariants {
+		variants[i] = fromDBVariant(dbVariant)
+	}
+
+	return variants, nil
+}
+
+type AddLinkVariantParams struct {
+	UserID         string
+	LinkID         string
+	DestinationURL string
+	Weight         int
+}
+
+func (s *LinkService) AddLinkVariant(ctx context.Context, args AddLinkVariantParams) (Variant, error) {
+	const serviceID = "service.link.AddLinkVariant"
+
+	if args.Weight < 0 || args.Weight > MaxVariantWeight {
+		return Variant{}, ErrInvalidVariantWeight
+	}
+
+	destination, err := NormalizeURL(args.DestinationURL)
+	if err != nil {
+		return Variant{}, ErrInvalidURL
+	}
+
+	var created db.LinkVariant
+
+	err = s.store.ExecTx(ctx, func(q *db.Queries) error {
+		_, err := q.GetShortLinkById(ctx, db.GetShortLinkByIdParams{
+			UserID: args.UserID,
+			ID:     args.LinkID,
+		})
+		if err != nil {
+			return err
+		}
+
+		existing, err := q.ListLinkVariants(ctx, args.LinkID)
+		if err != nil {
+			return err
+		}
+		if len(existing) >= MaxVariantsPerLink {
+			return ErrTooManyVariants
+		}
+
+		created, err = q.CreateLinkVariant(ctx, db.CreateLinkVariantParams{
+			ID:             utils.NewULID().String(),
+			LinkID:         args.LinkID,
+			DestinationUrl: destination,
+			Weight:         int64(args.Weight),
+		})
+		return err
+	})
+
+	if err != nil {
+		return Variant{}, variantError(serviceID, err)
+	}
+
+	return fromDBVariant(created), nil
+}
+
+type SetVariantWeightParams struct {
+	UserID    string
+	LinkID    string
+	VariantID string
+	// 0 pauses the variant: visitors who had it are split again
+	Weight int
+}
+
+func (s *LinkService) SetVariantWeight(ctx context.Context, args SetVariantWeightParams) (Variant, error) {
+	const serviceID = "service.link.SetVariantWeight"
+
+	if args.Weight < 0 || args.Weight > MaxVariantWeight {
+		return Variant{}, ErrInvalidVariantWeight
+	}
+
+	var updated db.LinkVariant
+
+	err := s.store.ExecTx(ctx, func(q *db.Queries) error {
+		_, err := q.GetShortLinkById(ctx, db.GetShortLinkByIdParams{
+			UserID: args.UserID,
+			ID:     args.LinkID,
+		})
+		if err != nil {
+			return err
+		}
+
+		updated, err = q.SetLinkVariantWeight(ctx, db.SetLinkVariantWeightParams{
+			Weight: int64(args.Weight),
+			ID:     args.VariantID,
+			LinkID: args.LinkID,
+		})
+		if err == sql.ErrNoRows {
+			return ErrVariantNotFound
+		}
+		return err
+	})
+
+	if err != nil {
+		return Variant{}, variantError(serviceID, err)
+	}
+
+	return fromDBVariant(updated), nil
+}
+
+type DeleteLinkVariantParams struct {
+	UserID    string
+	LinkID    string
+	VariantID string
+}
+
+// DeleteLinkVariant removes a variant. Its clicks keep pointing at it, so
+// its results stay in the link's stats.
+func (s *LinkService) DeleteLinkVariant(ctx context.Context, args DeleteLinkVariantParams) error {
+	const serviceID = "service.link.DeleteLinkVariant"
+
+	err := s.store.ExecTx(ctx, func(q *db.Queries) error {
+		_, err := q.GetShortLinkById(ctx, db.GetShortLinkByIdParams{
+			UserID: args.UserID,
+			ID:     args.LinkID,
+		})
+		if err != nil {
+			return err
+		}
+
+		deleted, err := q.DeleteLinkVariant(ctx, db.DeleteLinkVariantParams{
+			ID:     args.VariantID,
+			LinkID: args.LinkID,
+		})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return ErrVariantNotFound
+		}
+		return nil
+	})
+
+	if err != nil {
+		return variantError(serviceID, err)
+	}
+
+	return nil
+}
+
+type DeclareWinnerParams struct {
+	UserID    string
+	LinkID    string
+	VariantID string
+}
+
+// DeclareWinner ends a link's A/B test: the winning variant becomes the
+// link's destination and every variant is removed. The new destination is
+// recorded as a revision like any other edit.
+func (s *LinkService) DeclareWinner(ctx context.Context, args DeclareWinnerParams) (Link, error) {
+	const serviceID = "service.link.DeclareWinner"
+
+	var updated db.Link
+
+	err := s.store.ExecTx(ctx, func(q *db.Queries) error {
+		current, err := q.GetShortLinkById(ctx, db.GetShortLinkByIdParams{
+			UserID: args.UserID,
+			ID:     args.LinkID,
+		})
+		if err != nil {
+			return err
+		}
+
+		winner, err := q.GetLinkVariant(ctx, db.GetLinkVariantParams{
+			ID:     args.VariantID,
+			LinkID: args.LinkID,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrVariantNotFound
+			}
+			return err
+		}
+
+		values := valuesOf(current)
+		values.OriginalURL = winner.DestinationUrl
+
+		updated, err = applyValues(ctx, q, current, values, args.UserID, RevisionUpdate)
+		if err != nil {
+			return err
+		}
+
+		return q.DeleteLinkVariants(ctx, args.LinkID)
+	})
+
+	if err != nil {
+		return Link{}, variantError(serviceID, err)
+	}
+
+	return fromDBLink(updated), nil
+}
+
+// variantError maps errors from inside a variant transaction to the errors
+// the service exposes.
+func variantError(serviceID string, err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return ErrLinkNotFound
+	case ErrTooManyVariants, ErrVariantNotFound:
+		return err
+	default:
+		slog.Error(serviceID, "message", "couldn't change link variants", "error", err)
+		return ErrUnknownError
+	}
+}
@@ -0,0 +1,142 @@
+// Package metadata fetches the title, description, favicon and Open Graph
+// image of web pages. It's safe to point at user supplied URLs: requests
+// have a deadline, only the start of a page is read, and private networks
+// can't be reached.
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	DefaultTimeout     = 5 * time.Second
+	DefaultMaxBodySize = 512 << 10
+
+	maxRedirects = 5
+	userAgent    = "Mozilla/5.0 (compatible; url-shortener-preview/1.0)"
+)
+
+var (
+	ErrInvalidURL       = errors.New("only http and https pages can be fetched")
+	ErrBlockedAddress   = errors.New("destination resolves to a private address")
+	ErrTooManyRedirects = errors.New("too many redirects")
+	ErrUnexpectedStatus = errors.New("destination didn't respond with a page")
+	ErrNotHTML          = errors.New("destination isn't an html page")
+)
+
+type Options struct {
+	// How long a whole fetch may take, redirects included
+	Timeout time.Duration
+	// Most bytes read from a page. Everything needed is in the head, so
+	// there's no reason to read the rest.
+	MaxBodySize int64
+	// Lets the fetcher reach loopback and private addresses, for tests
+	// against a local server
+	AllowPrivateIPs bool
+}
+
+// Metadata describes a page. Fields the page doesn't provide are empty, and
+// URLs are absolute.
+type Metadata struct {
+	Title       string
+	Description string
+	FaviconURL  string
+	ImageURL    string
+}
+
+type Fetcher struct {
+	client      *http.Client
+	maxBodySize int64
+}
+
+func NewFetcher(opts Options) *Fetcher {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	if !opts.AllowPrivateIPs {
+		// Checked on the resolved address of every connection, so neither
+		// DNS names nor redirects can get around it
+		dialer.Control = blockPrivateAddresses
+	}
+
+	transport := &http.Transport{
+		// Proxies would hide the address actually being connected to
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   opts.Timeout,
+		ResponseHeaderTimeout: opts.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   opts.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return ErrTooManyRedirects
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return ErrInvalidURL
+			}
+			return nil
+		},
+	}
+
+	return &Fetcher{client: client, maxBodySize: opts.MaxBodySize}
+}
+
+// Fetch downloads the page at rawURL and reads its metadata.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (Metadata, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return Metadata{}, ErrInvalidURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return Metadata{}, ErrInvalidURL
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			switch {
+			case errors.Is(urlErr.Err, ErrBlockedAddress):
+				return Metadata{}, ErrBlockedAddress
+			case errors.Is(urlErr.Err, ErrTooManyRedirects), errors.Is(urlErr.Err, ErrInvalidURL):
+				return Metadata{}, urlErr.Err
+			}
+		}
+		return Metadata{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Metadata{}, fmt.Errorf("%w: %s", ErrUnexpectedStatus, resp.Status)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return Metadata{}, ErrNotHTML
+	}
+
+	// Relative links resolve against wherever the redirects ended up
+	return parse(io.LimitReader(resp.Body, f.maxBodySize), resp.Request.URL), nil
+}
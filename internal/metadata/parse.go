@@ -0,0 +1,154 @@
+package metadata
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	maxTitleLength       = 300
+	maxDescriptionLength = 1000
+	maxURLLength         = 2048
+)
+
+// parse reads metadata from the head of an HTML document, stopping as soon
+// as the body starts.
+func parse(r io.Reader, base *url.URL) Metadata {
+	z := html.NewTokenizer(r)
+
+	var (
+		title, ogTitle, description, ogDescription, image string
+		icon, touchIcon                                   string
+		inTitle                                           bool
+	)
+
+	// Picks up the page's <base> once it's been seen
+	resolve := func(ref string) string {
+		return resolveURL(base, ref)
+	}
+
+loop:
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			break loop
+		case html.TextToken:
+			if inTitle {
+				title += string(z.Text())
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Title:
+				inTitle = false
+			case atom.Head:
+				break loop
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := atom.Lookup(name)
+
+			if tag == atom.Body {
+				break loop
+			}
+			if tag == atom.Title {
+				inTitle = title == ""
+				continue
+			}
+
+			attrs := map[string]string{}
+			for hasAttr {
+				var key, value []byte
+				key, value, hasAttr = z.TagAttr()
+				attrs[string(key)] = string(value)
+			}
+
+			switch tag {
+			case atom.Base:
+				if href, err := url.Parse(strings.TrimSpace(attrs["href"])); err == nil && attrs["href"] != "" {
+					base = base.ResolveReference(href)
+				}
+			case atom.Meta:
+				content := attrs["content"]
+				switch strings.ToLower(attrs["property"] + attrs["name"]) {
+				case "og:title":
+					ogTitle = firstNonEmpty(ogTitle, content)
+				case "og:description":
+					ogDescription = firstNonEmpty(ogDescription, content)
+				case "description":
+					description = firstNonEmpty(description, content)
+				case "og:image", "og:image:url", "og:image:secure_url":
+					image = firstNonEmpty(image, resolve(content))
+				}
+			case atom.Link:
+				for _, rel := range strings.Fields(strings.ToLower(attrs["rel"])) {
+					switch rel {
+					case "icon":
+						icon = firstNonEmpty(icon, resolve(attrs["href"]))
+					case "apple-touch-icon":
+						touchIcon = firstNonEmpty(touchIcon, resolve(attrs["href"]))
+					}
+				}
+			}
+		}
+	}
+
+	favicon := firstNonEmpty(icon, touchIcon)
+	if favicon == "" {
+		// Browsers look here when a page doesn't name a favicon
+		favicon = resolve("/favicon.ico")
+	}
+
+	return Metadata{
+		Title:       clean(firstNonEmpty(title, ogTitle), maxTitleLength),
+		Description: clean(firstNonEmpty(ogDescription, description), maxDescriptionLength),
+		FaviconURL:  favicon,
+		ImageURL:    image,
+	}
+}
+
+// resolveURL makes ref absolute, dropping anything that isn't a reasonably
+// sized http or https URL.
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	u = base.ResolveReference(u)
+
+	if (u.Scheme != "http" && u.Scheme != "https") || len(u.String()) > maxURLLength {
+		return ""
+	}
+
+	return u.String()
+}
+
+// clean collapses whitespace and cuts s to at most max characters. Pages
+// that aren't UTF-8 come through with their invalid bytes dropped.
+func clean(s string, max int) string {
+	s = strings.Join(strings.Fields(strings.ToValidUTF8(s, "")), " ")
+
+	if runes := []rune(s); len(runes) > max {
+		s = strings.TrimSpace(string(runes[:max-1])) + "…"
+	}
+
+	return s
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
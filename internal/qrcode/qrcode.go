@@ -0,0 +1,204 @@
+// Package qrcode renders QR codes as PNG or SVG without any external
+// service.
+package qrcode
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/boombuler/barcode/qr"
+)
+
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+
+	DefaultSize   = 256
+	MinSize       = 64
+	MaxSize       = 2048
+	DefaultMargin = 4
+	MaxMargin     = 16
+	DefaultLevel  = "M"
+)
+
+var (
+	ErrInvalidColor = errors.New("colors must be 6 digit hex values")
+	ErrInvalidLevel = errors.New("error correction level must be one of L, M, Q or H")
+)
+
+var levels = map[string]qr.ErrorCorrectionLevel{
+	"L": qr.L,
+	"M": qr.M,
+	"Q": qr.Q,
+	"H": qr.H,
+}
+
+type Options struct {
+	Format string
+	// Width and height of the image in pixels
+	Size int
+	// Error correction level: L, M, Q or H
+	Level string
+	// Quiet zone around the code, in modules
+	Margin     int
+	Foreground color.RGBA
+	Background color.RGBA
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Format:     FormatPNG,
+		Size:       DefaultSize,
+		Level:      DefaultLevel,
+		Margin:     DefaultMargin,
+		Foreground: color.RGBA{A: 0xff},
+		Background: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	}
+}
+
+// ParseColor reads a color written as `rrggbb`, with or without a leading #.
+func ParseColor(value string) (color.RGBA, error) {
+	value = strings.TrimPrefix(value, "#")
+	if len(value) != 6 {
+		return color.RGBA{}, ErrInvalidColor
+	}
+	rgb, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return color.RGBA{}, ErrInvalidColor
+	}
+	return color.RGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 0xff}, nil
+}
+
+// Code is an encoded QR code, ready to be drawn.
+type Code struct {
+	modules [][]bool
+	options Options
+	etag    string
+}
+
+func Encode(content string, options Options) (*Code, error) {
+	level, ok := levels[options.Level]
+	if !ok {
+		return nil, ErrInvalidLevel
+	}
+
+	barcode, err := qr.Encode(content, level, qr.Auto)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := barcode.Bounds()
+	modules := make([][]bool, bounds.Dy())
+	for y := range modules {
+		modules[y] = make([]bool, bounds.Dx())
+		for x := range modules[y] {
+			r, _, _, _ := barcode.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			modules[y][x] = r == 0
+		}
+	}
+
+	// Rendering is deterministic, so the inputs identify the output
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s|%+v", content, options))
+
+	return &Code{
+		modules: modules,
+		options: options,
+		etag:    `"` + hex.EncodeToString(sum[:16]) + `"`,
+	}, nil
+}
+
+// ETag is a strong validator for the rendered image.
+func (c *Code) ETag() string {
+	return c.etag
+}
+
+func (c *Code) ContentType() string {
+	if c.options.Format == FormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+func (c *Code) Write(w io.Writer) error {
+	if c.options.Format == FormatSVG {
+		return c.writeSVG(w)
+	}
+	return c.writePNG(w)
+}
+
+// dark reports whether a cell of the code, quiet zone included, is dark.
+func (c *Code) dark(x, y int) bool {
+	x -= c.options.Margin
+	y -= c.options.Margin
+	if y < 0 || y >= len(c.modules) || x < 0 || x >= len(c.modules[y]) {
+		return false
+	}
+	return c.modules[y][x]
+}
+
+func (c *Code) cells() int {
+	return len(c.modules) + 2*c.options.Margin
+}
+
+func (c *Code) writePNG(w io.Writer) error {
+	size := c.options.Size
+	cells := c.cells()
+
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{c.options.Background, c.options.Foreground})
+	for py := 0; py < size; py++ {
+		y := py * cells / size
+		for px := 0; px < size; px++ {
+			if c.dark(px*cells/size, y) {
+				img.SetColorIndex(px, py, 1)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// writeSVG draws one path for all dark modules, merging horizontal runs to
+// keep the file small. The viewBox is in modules so the image scales cleanly.
+func (c *Code) writeSVG(w io.Writer) error {
+	cells := c.cells()
+
+	var path strings.Builder
+	for y := 0; y < cells; y++ {
+		for x := 0; x < cells; x++ {
+			if !c.dark(x, y) {
+				continue
+			}
+			start := x
+			for x < cells && c.dark(x, y) {
+				x++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+
+	_, err := fmt.Fprintf(w,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+			`<rect width="100%%" height="100%%" fill="%s"/><path fill="%s" d="%s"/></svg>`,
+		c.options.Size, c.options.Size, cells, cells,
+		hexColor(c.options.Background), hexColor(c.options.Foreground), path.String(),
+	)
+	return err
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
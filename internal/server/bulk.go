@@ -0,0 +1,245 @@
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"url-shortener/internal/link"
+	"url-shortener/internal/utils"
+	"url-shortener/internal/validation"
+)
+
+// bulkMaxBodySize caps bulk uploads regardless of how many items they hold.
+const bulkMaxBodySize = 4 << 20
+
+type bulkItem struct {
+	URL          string `json:"url" validate:"required,url,max=2048"`
+	PrettyID     string `json:"pretty_id" validate:"omitempty,max=64"`
+	RedirectType int    `json:"redirect_type" validate:"omitempty,oneof=301 302 307 308"`
+	ExpiresAt    string `json:"expires_at"`
+	MaxClicks    int64  `json:"max_clicks" validate:"omitempty,min=1"`
+	FallbackURL  string `json:"fallback_url" validate:"omitempty,url,max=2048"`
+	DomainID     string `json:"domain_id" validate:"omitempty,max=64"`
+}
+
+type bulkItemResult struct {
+	Index  int                         `json:"index"`
+	Data   *linkResponse               `json:"data,omitempty"`
+	Errors validation.ValidationErrors `json:"errors,omitempty"`
+}
+
+// HandleBulkCreateLinks creates up to maxItems links from a JSON array, a
+// CSV body or a multipart CSV upload in the "file" field. CSV input needs a
+// header row naming the columns, using the JSON field names.
+func HandleBulkCreateLinks(ctx context.Context, validator validation.Validator, linkService *link.LinkService, maxItems int) http.Handler {
+	handlerID := "handler.link.HandleBulkCreateLinks"
+
+	if maxItems <= 0 {
+		maxItems = link.DefaultBulkMaxItems
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, bulkMaxBodySize)
+
+		items, err := decodeBulkItems(r)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{http.StatusText(http.StatusBadRequest)},
+			})
+			return
+		}
+
+		if len(items) == 0 || len(items) > maxItems {
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{fmt.Sprintf("send between 1 and %d links", maxItems)},
+			})
+			return
+		}
+
+		results := make([]bulkItemResult, len(items))
+		var params []link.CreateLinkParams
+		// Position in params of each item that passed validation
+		var indexes []int
+
+		for i, item := range items {
+			results[i].Index = i
+
+			errs := validator.Validate(item)
+
+			expiresAt, err := parseTimeParam(item.ExpiresAt)
+			if err != nil {
+				errs = append(errs, validation.ValidationError{Field: "expires_at", Message: err.Error()})
+			}
+
+			if errs != nil {
+				results[i].Errors = errs
+				continue
+			}
+
+			indexes = append(indexes, i)
+			params = append(params, link.CreateLinkParams{
+				OriginalURL:  item.URL,
+				RedirectType: item.RedirectType,
+				Alias:        item.PrettyID,
+				ExpiresAt:    expiresAt,
+				MaxClicks:    item.MaxClicks,
+				FallbackURL:  item.FallbackURL,
+				DomainID:     item.DomainID,
+			})
+		}
+
+		if len(params) > 0 {
+			created, err := linkService.BulkCreateLinks(ctx, link.BulkCreateLinksParams{
+				UserID: userIDFromContext(r.Context()),
+				Items:  params,
+			})
+
+			if err != nil {
+				slog.Error(handlerID, "message", "couldn't create links", "error", err)
+				utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+					"errors": []string{http.StatusText(http.StatusInternalServerError)},
+				})
+				return
+			}
+
+			for j, result := range created {
+				i := indexes[j]
+				if result.Err != nil {
+					results[i].Errors = validation.ValidationErrors{bulkItemError(result.Err)}
+					continue
+				}
+				response := newLinkResponse(result.Link)
+				results[i].Data = &response
+			}
+		}
+
+		failed := 0
+		for _, result := range results {
+			if result.Errors != nil {
+				failed++
+			}
+		}
+
+		status := http.StatusCreated
+		if failed > 0 {
+			status = http.StatusMultiStatus
+		}
+
+		utils.RespondWithJSON(w, status, map[string]any{
+			"data":    results,
+			"created": len(results) - failed,
+			"failed":  failed,
+		})
+	})
+}
+
+// bulkItemError names the field a service error is about.
+func bulkItemError(err error) validation.ValidationError {
+	switch err {
+	case link.ErrInvalidURL:
+		return validation.ValidationError{Field: "url", Message: err.Error()}
+	case link.ErrInvalidExpiry:
+		return validation.ValidationError{Field: "expires_at", Message: err.Error()}
+	case link.ErrInvalidFallbackURL:
+		return validation.ValidationError{Field: "fallback_url", Message: err.Error()}
+	case link.ErrInvalidAlias, link.ErrReservedAlias, link.ErrBlockedAlias, link.ErrAliasTaken:
+		return validation.ValidationError{Field: "pretty_id", Message: err.Error()}
+	case link.ErrDomainNotFound, link.ErrDomainNotVerified:
+		return validation.ValidationError{Field: "domain_id", Message: err.Error()}
+	}
+	return validation.ValidationError{Field: "short_url_id", Message: err.Error()}
+}
+
+func decodeBulkItems(r *http.Request) ([]bulkItem, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case "text/csv":
+		return decodeBulkCSV(r.Body)
+	case "multipart/form-data":
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return decodeBulkCSV(file)
+	}
+
+	var items []bulkItem
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func decodeBulkCSV(body io.Reader) ([]bulkItem, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, column := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(column))
+		switch header[i] {
+		case "url", "pretty_id", "redirect_type", "expires_at", "max_clicks", "fallback_url", "domain_id":
+		default:
+			return nil, fmt.Errorf("unknown column %q", column)
+		}
+	}
+
+	var items []bulkItem
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var item bulkItem
+		for i, value := range record {
+			switch header[i] {
+			case "url":
+				item.URL = value
+			case "pretty_id":
+				item.PrettyID = value
+			case "redirect_type":
+				if value != "" {
+					if item.RedirectType, err = strconv.Atoi(value); err != nil {
+						return nil, fmt.Errorf("row %d: invalid redirect_type", len(items)+1)
+					}
+				}
+			case "expires_at":
+				item.ExpiresAt = value
+			case "max_clicks":
+				if value != "" {
+					if item.MaxClicks, err = strconv.ParseInt(value, 10, 64); err != nil {
+						return nil, fmt.Errorf("row %d: invalid max_clicks", len(items)+1)
+					}
+				}
+			case "fallback_url":
+				item.FallbackURL = value
+			case "domain_id":
+				item.DomainID = value
+			}
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
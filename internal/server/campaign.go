@@ -0,0 +1,310 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+	"url-shortener/internal/link"
+	"url-shortener/internal/utils"
+	"url-shortener/internal/validation"
+)
+
+func HandleListCampaigns(ctx context.Context, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.campaign.HandleListCampaigns"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		campaigns, err := linkService.ListCampaigns(ctx, userIDFromContext(r.Context()))
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't list campaigns", "error", err)
+			respondWithCampaignError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": campaigns,
+		})
+	})
+}
+
+func HandleCreateCampaign(ctx context.Context, validator validation.Validator, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.campaign.HandleCreateCampaign"
+
+	type request struct {
+		Name string `json:"name" validate:"required,max=100"`
+		// Optional RFC 3339 timestamps
+		StartsAt string     `json:"starts_at"`
+		EndsAt   string     `json:"ends_at"`
+		UTM      utmRequest `json:"utm"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := utils.DecodeToJSON[request](r)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{http.StatusText(http.StatusBadRequest)},
+			})
+			return
+		}
+
+		errs := validator.Validate(req)
+
+		startsAt, err := parseTimeParam(req.StartsAt)
+		if err != nil {
+			errs = append(errs, validation.ValidationError{Field: "starts_at", Message: err.Error()})
+		}
+
+		endsAt, err := parseTimeParam(req.EndsAt)
+		if err != nil {
+			errs = append(errs, validation.ValidationError{Field: "ends_at", Message: err.Error()})
+		}
+
+		if errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		createdCampaign, err := linkService.CreateCampaign(ctx, link.CreateCampaignParams{
+			UserID:   userIDFromContext(r.Context()),
+			Name:     req.Name,
+			StartsAt: startsAt,
+			EndsAt:   endsAt,
+			UTM:      req.UTM.toUTM(),
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't create campaign", "error", err)
+			respondWithCampaignError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusCreated, map[string]any{
+			"data": createdCampaign,
+		})
+	})
+}
+
+func HandleUpdateCampaign(ctx context.Context, validator validation.Validator, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.campaign.HandleUpdateCampaign"
+
+	// Fields left out of the payload aren't changed. Empty dates remove
+	// them, and utm replaces all of the campaign's UTM values.
+	type request struct {
+		Name     *string     `json:"name" validate:"omitempty,max=100"`
+		StartsAt *string     `json:"starts_at"`
+		EndsAt   *string     `json:"ends_at"`
+		UTM      *utmRequest `json:"utm"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := utils.DecodeToJSON[request](r)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{http.StatusText(http.StatusBadRequest)},
+			})
+			return
+		}
+
+		errs := validator.Validate(req)
+
+		var startsAt *time.Time
+		if req.StartsAt != nil {
+			parsed, err := parseTimeParam(*req.StartsAt)
+			if err != nil {
+				errs = append(errs, validation.ValidationError{Field: "starts_at", Message: err.Error()})
+			}
+			startsAt = &parsed
+		}
+
+		var endsAt *time.Time
+		if req.EndsAt != nil {
+			parsed, err := parseTimeParam(*req.EndsAt)
+			if err != nil {
+				errs = append(errs, validation.ValidationError{Field: "ends_at", Message: err.Error()})
+			}
+			endsAt = &parsed
+		}
+
+		if errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		updateCampaignArgs := link.UpdateCampaignParams{
+			UserID:     userIDFromContext(r.Context()),
+			CampaignID: r.PathValue("id"),
+			Name:       req.Name,
+			StartsAt:   startsAt,
+			EndsAt:     endsAt,
+		}
+		if req.UTM != nil {
+			utm := req.UTM.toUTM()
+			updateCampaignArgs.UTM = &utm
+		}
+
+		updatedCampaign, err := linkService.UpdateCampaign(ctx, updateCampaignArgs)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't update campaign", "error", err)
+			respondWithCampaignError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": updatedCampaign,
+		})
+	})
+}
+
+func HandleDeleteCampaign(ctx context.Context, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.campaign.HandleDeleteCampaign"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := linkService.DeleteCampaign(ctx, link.DeleteCampaignParams{
+			UserID:     userIDFromContext(r.Context()),
+			CampaignID: r.PathValue("id"),
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't delete campaign", "error", err)
+			respondWithCampaignError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func HandleListCampaignLinks(ctx context.Context, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.campaign.HandleListCampaignLinks"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		links, err := linkService.ListCampaignLinks(ctx, link.ListCampaignLinksParams{
+			UserID:     userIDFromContext(r.Context()),
+			CampaignID: r.PathValue("id"),
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't list campaign links", "error", err)
+			respondWithCampaignError(w, err)
+			return
+		}
+
+		data := make([]linkResponse, 0, len(links))
+		for _, l := range links {
+			data = append(data, newLinkResponse(l))
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": data,
+		})
+	})
+}
+
+// HandleAddCampaignLinks puts many links in a campaign at once, taking them
+// out of any other campaign.
+func HandleAddCampaignLinks(ctx context.Context, validator validation.Validator, linkService *link.LinkService, maxLinks int) http.Handler {
+	handlerID := "handler.campaign.HandleAddCampaignLinks"
+
+	if maxLinks <= 0 {
+		maxLinks = link.DefaultBulkMaxItems
+	}
+
+	type request struct {
+		LinkIDs []string `json:"link_ids" validate:"required,min=1,dive,required,max=64"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := utils.DecodeToJSON[request](r)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{http.StatusText(http.StatusBadRequest)},
+			})
+			return
+		}
+
+		errs := validator.Validate(req)
+
+		if len(req.LinkIDs) > maxLinks {
+			errs = append(errs, validation.ValidationError{Field: "link_ids", Message: "too many links"})
+		}
+
+		if errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		result, err := linkService.AddCampaignLinks(ctx, link.AddCampaignLinksParams{
+			UserID:     userIDFromContext(r.Context()),
+			CampaignID: r.PathValue("id"),
+			LinkIDs:    req.LinkIDs,
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't add campaign links", "error", err)
+			respondWithCampaignError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": result,
+		})
+	})
+}
+
+func HandleRemoveCampaignLink(ctx context.Context, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.campaign.HandleRemoveCampaignLink"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := linkService.RemoveCampaignLink(ctx, link.RemoveCampaignLinkParams{
+			UserID:     userIDFromContext(r.Context()),
+			CampaignID: r.PathValue("id"),
+			LinkID:     r.PathValue("link_id"),
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't remove campaign link", "error", err)
+			respondWithCampaignError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func respondWithCampaignError(w http.ResponseWriter, err error) {
+	switch err {
+	case link.ErrInvalidCampaignName, link.ErrInvalidCampaignDates, link.ErrInvalidUTM:
+		utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+			"errors": []string{err.Error()},
+		})
+	case link.ErrCampaignNotFound, link.ErrLinkNotFound:
+		utils.RespondWithJSON(w, http.StatusNotFound, map[string]any{
+			"errors": []string{err.Error()},
+		})
+	case link.ErrCampaignExists:
+		utils.RespondWithJSON(w, http.StatusConflict, map[string]any{
+			"errors": []string{err.Error()},
+		})
+	default:
+		utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+			"errors": []string{http.StatusText(http.StatusInternalServerError)},
+		})
+	}
+}
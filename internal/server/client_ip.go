@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// clientIP returns the visitor's IP address. The proxy header is only
+// consulted when one is configured since anyone can send it otherwise.
+func clientIP(r *http.Request, proxyHeader string) string {
+	if proxyHeader != "" {
+		if http.CanonicalHeaderKey(proxyHeader) == "X-Forwarded-For" {
+			if ip := forwardedForIP(r.Header.Values(proxyHeader)); ip != "" {
+				return ip
+			}
+		} else if value := strings.TrimSpace(r.Header.Get(proxyHeader)); value != "" {
+			return value
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// forwardedForIP picks the client out of X-Forwarded-For. Each proxy appends
+// the address it was reached from, so everything left of the last untrusted
+// hop could have been sent by the client itself. Proxies on loopback or
+// private addresses are ours and get skipped.
+func forwardedForIP(values []string) string {
+	hops := strings.Split(strings.Join(values, ","), ",")
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		ip := net.ParseIP(hop)
+		if ip == nil {
+			// Whatever's left of this can't be trusted either
+			return ""
+		}
+		if ip.IsLoopback() || ip.IsPrivate() {
+			continue
+		}
+		return hop
+	}
+
+	return ""
+}
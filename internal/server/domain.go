@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"url-shortener/internal/link"
+	"url-shortener/internal/utils"
+	"url-shortener/internal/validation"
+)
+
+func HandleListDomains(ctx context.Context, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.domain.HandleListDomains"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		domains, err := linkService.ListDomains(ctx, userIDFromContext(r.Context()))
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't list domains", "error", err)
+			respondWithDomainError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": domains,
+		})
+	})
+}
+
+// HandleCreateDomain adds a domain and answers with the TXT record to
+// publish before verifying it.
+func HandleCreateDomain(ctx context.Context, validator validation.Validator, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.domain.HandleCreateDomain"
+
+	type request struct {
+		Hostname string `json:"hostname" validate:"required,max=253"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := utils.DecodeToJSON[request](r)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{http.StatusText(http.StatusBadRequest)},
+			})
+			return
+		}
+
+		if errs := validator.Validate(req); errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		createdDomain, err := linkService.CreateDomain(ctx, link.CreateDomainParams{
+			UserID:   userIDFromContext(r.Context()),
+			Hostname: req.Hostname,
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't create domain", "error", err)
+			respondWithDomainError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusCreated, map[string]any{
+			"data": createdDomain,
+		})
+	})
+}
+
+func HandleVerifyDomain(ctx context.Context, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.domain.HandleVerifyDomain"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verifiedDomain, err := linkService.VerifyDomain(ctx, link.VerifyDomainParams{
+			UserID:   userIDFromContext(r.Context()),
+			DomainID: r.PathValue("id"),
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't verify domain", "error", err)
+			respondWithDomainError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": verifiedDomain,
+		})
+	})
+}
+
+func HandleDeleteDomain(ctx context.Context, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.domain.HandleDeleteDomain"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := linkService.DeleteDomain(ctx, link.DeleteDomainParams{
+			UserID:   userIDFromContext(r.Context()),
+			DomainID: r.PathValue("id"),
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't delete domain", "error", err)
+			respondWithDomainError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func respondWithDomainError(w http.ResponseWriter, err error) {
+	switch err {
+	case link.ErrInvalidHostname:
+		utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+			"errors": []string{err.Error()},
+		})
+	case link.ErrDomainNotFound:
+		utils.RespondWithJSON(w, http.StatusNotFound, map[string]any{
+			"errors": []string{err.Error()},
+		})
+	case link.ErrDomainExists, link.ErrDomainInUse:
+		utils.RespondWithJSON(w, http.StatusConflict, map[string]any{
+			"errors": []string{err.Error()},
+		})
+	case link.ErrDomainVerificationFailed:
+		utils.RespondWithJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"errors": []string{err.Error()},
+		})
+	default:
+		utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+			"errors": []string{http.StatusText(http.StatusInternalServerError)},
+		})
+	}
+}
+
+// requestHostname is the host a request was sent to, without the port,
+// which picks the domain short codes are looked up on.
+func requestHostname(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		return r.Host
+	}
+	return host
+}
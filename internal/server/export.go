@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+	"url-shortener/internal/export"
+	"url-shortener/internal/job"
+	"url-shortener/internal/utils"
+)
+
+// HandleStartExport queues an export of everything stored about the user.
+// They're emailed a download link once it's built; the job shows progress
+// and the link too.
+func HandleStartExport(ctx context.Context, jobService *job.JobService, baseURL string) http.Handler {
+	handlerID := "handler.export.HandleStartExport"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queuedJob, err := queueExport(ctx, jobService, userIDFromContext(r.Context()), baseURL)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't queue export", "error", err)
+			utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+				"errors": []string{http.StatusText(http.StatusInternalServerError)},
+			})
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusAccepted, map[string]any{
+			"data": queuedJob,
+		})
+	})
+}
+
+func queueExport(ctx context.Context, jobService *job.JobService, userID string, baseURL string) (job.Job, error) {
+	payload, err := export.JobPayload(baseURL)
+	if err != nil {
+		return job.Job{}, err
+	}
+	return jobService.Enqueue(ctx, job.EnqueueParams{
+		UserID:  userID,
+		Kind:    export.JobKind,
+		Payload: payload,
+	})
+}
+
+// exportDownloadTimeout replaces the server's write timeout for downloads,
+// since archives of big accounts take far longer to send.
+const exportDownloadTimeout = 10 * time.Minute
+
+func HandleDownloadExport(ctx context.Context, exportService *export.ExportService) http.Handler {
+	handlerID := "handler.export.HandleDownloadExport"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		getDownloadArgs := export.GetDownloadParams{
+			ExportID: r.PathValue("id"),
+			Token:    r.URL.Query().Get("token"),
+		}
+
+		download, err := exportService.GetDownload(ctx, getDownloadArgs)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't get export", "error", err)
+			switch err {
+			case export.ErrInvalidDownloadToken:
+				utils.RespondWithJSON(w, http.StatusForbidden, map[string]any{
+					"errors": []string{err.Error()},
+				})
+			case export.ErrExportNotFound, export.ErrExportExpired:
+				utils.RespondWithJSON(w, http.StatusGone, map[string]any{
+					"errors": []string{err.Error()},
+				})
+			default:
+				utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+					"errors": []string{http.StatusText(http.StatusInternalServerError)},
+				})
+			}
+			return
+		}
+
+		defer download.File.Close()
+
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(exportDownloadTimeout)); err != nil {
+			slog.Warn(handlerID, "message", "couldn't extend write deadline", "error", err)
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", download.Filename))
+		w.Header().Set("Cache-Control", "private, no-store")
+		http.ServeContent(w, r, download.Filename, download.CreatedAt, download.File)
+	})
+}
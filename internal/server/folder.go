@@ -0,0 +1,196 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"url-shortener/internal/link"
+	"url-shortener/internal/utils"
+	"url-shortener/internal/validation"
+)
+
+func HandleListFolders(ctx context.Context, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.folder.HandleListFolders"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		folders, err := linkService.ListFolders(ctx, userIDFromContext(r.Context()))
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't list folders", "error", err)
+			respondWithOrganizeError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": folders,
+		})
+	})
+}
+
+func HandleCreateFolder(ctx context.Context, validator validation.Validator, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.folder.HandleCreateFolder"
+
+	type request struct {
+		Name     string `json:"name" validate:"required,max=100"`
+		ParentID string `json:"parent_id" validate:"omitempty,max=64"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := utils.DecodeToJSON[request](r)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{http.StatusText(http.StatusBadRequest)},
+			})
+			return
+		}
+
+		if errs := validator.Validate(req); errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		createdFolder, err := linkService.CreateFolder(ctx, link.CreateFolderParams{
+			UserID:   userIDFromContext(r.Context()),
+			Name:     req.Name,
+			ParentID: req.ParentID,
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't create folder", "error", err)
+			respondWithOrganizeError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusCreated, map[string]any{
+			"data": createdFolder,
+		})
+	})
+}
+
+func HandleUpdateFolder(ctx context.Context, validator validation.Validator, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.folder.HandleUpdateFolder"
+
+	// Fields left out of the payload aren't changed
+	type request struct {
+		Name     *string `json:"name" validate:"omitempty,max=100"`
+		ParentID *string `json:"parent_id" validate:"omitempty,max=64"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := utils.DecodeToJSON[request](r)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{http.StatusText(http.StatusBadRequest)},
+			})
+			return
+		}
+
+		if errs := validator.Validate(req); errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		updatedFolder, err := linkService.UpdateFolder(ctx, link.UpdateFolderParams{
+			UserID:   userIDFromContext(r.Context()),
+			FolderID: r.PathValue("id"),
+			Name:     req.Name,
+			ParentID: req.ParentID,
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't update folder", "error", err)
+			respondWithOrganizeError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": updatedFolder,
+		})
+	})
+}
+
+func HandleDeleteFolder(ctx context.Context, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.folder.HandleDeleteFolder"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := linkService.DeleteFolder(ctx, link.DeleteFolderParams{
+			UserID:   userIDFromContext(r.Context()),
+			FolderID: r.PathValue("id"),
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't delete folder", "error", err)
+			respondWithOrganizeError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// HandleMoveLinks puts many links in a folder at once. An empty folder_id
+// takes them out of their folders.
+func HandleMoveLinks(ctx context.Context, validator validation.Validator, linkService *link.LinkService, maxLinks int) http.Handler {
+	handlerID := "handler.folder.HandleMoveLinks"
+
+	if maxLinks <= 0 {
+		maxLinks = link.DefaultBulkMaxItems
+	}
+
+	type request struct {
+		LinkIDs  []string `json:"link_ids" validate:"required,min=1,dive,required,max=64"`
+		FolderID string   `json:"folder_id" validate:"omitempty,max=64"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := utils.DecodeToJSON[request](r)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{http.StatusText(http.StatusBadRequest)},
+			})
+			return
+		}
+
+		errs := validator.Validate(req)
+
+		if len(req.LinkIDs) > maxLinks {
+			errs = append(errs, validation.ValidationError{Field: "link_ids", Message: "too many links"})
+		}
+
+		if errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		result, err := linkService.MoveLinks(ctx, link.MoveLinksParams{
+			UserID:   userIDFromContext(r.Context()),
+			LinkIDs:  req.LinkIDs,
+			FolderID: req.FolderID,
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't move links", "error", err)
+			respondWithOrganizeError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": result,
+		})
+	})
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"time"
+	"url-shortener/internal/job"
+	"url-shortener/internal/link"
+	"url-shortener/internal/utils"
+)
+
+const (
+	// importMaxBodySize caps uploaded exports.
+	importMaxBodySize = 32 << 20
+	// importTimeout replaces the server's read and write timeouts, which
+	// are far too short to upload and import a file that size.
+	importTimeout = 2 * time.Minute
+)
+
+// HandleImportLinks imports a CSV export from another shortener, sent as the
+// body or as a multipart upload in the "file" field. Small files are
+// imported straight away; files with more than syncMaxRows links, or any
+// file when async=true, are queued as a job instead.
+func HandleImportLinks(ctx context.Context, linkService *link.LinkService, jobService *job.JobService, syncMaxRows int) http.Handler {
+	handlerID := "handler.link.HandleImportLinks"
+
+	if syncMaxRows <= 0 {
+		syncMaxRows = link.DefaultBulkMaxItems
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		controller := http.NewResponseController(w)
+		deadline := time.Now().Add(importTimeout)
+		if err := controller.SetReadDeadline(deadline); err != nil {
+			slog.Warn(handlerID, "message", "couldn't extend read deadline", "error", err)
+		}
+		if err := controller.SetWriteDeadline(deadline); err != nil {
+			slog.Warn(handlerID, "message", "couldn't extend write deadline", "error", err)
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, importMaxBodySize)
+
+		var body io.Reader = r.Body
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+			file, _, err := r.FormFile("file")
+			if err != nil {
+				slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+				utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+					"errors": []string{http.StatusText(http.StatusBadRequest)},
+				})
+				return
+			}
+			defer file.Close()
+			body = file
+		}
+
+		records, err := link.ParseImport(body, r.URL.Query().Get("format"))
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't parse import", "error", err)
+			message := link.ErrInvalidImport.Error()
+			if err == link.ErrInvalidImportFormat {
+				message = err.Error()
+			}
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{message},
+			})
+			return
+		}
+
+		userID := userIDFromContext(r.Context())
+
+		if r.URL.Query().Get("async") == "true" || len(records) > syncMaxRows {
+			queuedJob, err := queueImport(ctx, jobService, userID, records)
+
+			if err != nil {
+				slog.Error(handlerID, "message", "couldn't queue import", "error", err)
+				utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+					"errors": []string{http.StatusText(http.StatusInternalServerError)},
+				})
+				return
+			}
+
+			utils.RespondWithJSON(w, http.StatusAccepted, map[string]any{
+				"data": queuedJob,
+			})
+			return
+		}
+
+		report, err := linkService.ImportLinks(ctx, link.ImportLinksParams{
+			UserID:  userID,
+			Records: records,
+		}, nil)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't import links", "error", err)
+			utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+				"errors": []string{http.StatusText(http.StatusInternalServerError)},
+			})
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": report,
+		})
+	})
+}
+
+func queueImport(ctx context.Context, jobService *job.JobService, userID string, records []link.ImportRecord) (job.Job, error) {
+	payload, err := link.ImportJobPayload(records)
+	if err != nil {
+		return job.Job{}, err
+	}
+	return jobService.Enqueue(ctx, job.EnqueueParams{
+		UserID:  userID,
+		Kind:    link.ImportJobKind,
+		Payload: payload,
+	})
+}
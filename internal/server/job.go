@@ -0,0 +1,40 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"url-shortener/internal/job"
+	"url-shortener/internal/utils"
+)
+
+func HandleGetJob(ctx context.Context, jobService *job.JobService) http.Handler {
+	handlerID := "handler.job.HandleGetJob"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		getJobArgs := job.GetJobParams{
+			UserID: userIDFromContext(r.Context()),
+			JobID:  r.PathValue("id"),
+		}
+
+		foundJob, err := jobService.GetJob(ctx, getJobArgs)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't get job", "error", err)
+			if err == job.ErrJobNotFound {
+				utils.RespondWithJSON(w, http.StatusNotFound, map[string]any{
+					"errors": []string{err.Error()},
+				})
+				return
+			}
+			utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+				"errors": []string{http.StatusText(http.StatusInternalServerError)},
+			})
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": foundJob,
+		})
+	})
+}
@@ -3,30 +3,484 @@ package server
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 	"url-shortener/internal/link"
+	"url-shortener/internal/utils"
+	"url-shortener/internal/validation"
 )
 
 type linkResponse struct {
-	ID          string `json:"id"`
-	OriginalURL string `json:"original_url"`
-	ShortURLID  string `json:"short_url_id"`
-	UpdatedAt   string `json:"last_login_at"`
-	CreatedAt   string `json:"created_at"`
+	ID                 string    `json:"id"`
+	OriginalURL        string    `json:"original_url"`
+	ShortURLID         string    `json:"short_url_id"`
+	PrettyID           string    `json:"pretty_id"`
+	RedirectType       int       `json:"redirect_type"`
+	Status             string    `json:"status"`
+	ClickCount         int64     `json:"click_count"`
+	ExpiresAt          string    `json:"expires_at,omitempty"`
+	DeletedAt          string    `json:"deleted_at,omitempty"`
+	MaxClicks          int64     `json:"max_clicks,omitempty"`
+	FallbackURL        string    `json:"fallback_url,omitempty"`
+	PasswordProtected  bool      `json:"password_protected"`
+	FolderID           string    `json:"folder_id,omitempty"`
+	Title              string    `json:"title,omitempty"`
+	Description        string    `json:"description,omitempty"`
+	FaviconURL         string    `json:"favicon_url,omitempty"`
+	ImageURL           string    `json:"image_url,omitempty"`
+	PreviewTitle       string    `json:"preview_title,omitempty"`
+	PreviewDescription string    `json:"preview_description,omitempty"`
+	PreviewImageURL    string    `json:"preview_image_url,omitempty"`
+	ActivatesAt        string    `json:"activates_at,omitempty"`
+	UTM                *link.UTM `json:"utm,omitempty"`
+	QueryPassthrough   string    `json:"query_passthrough"`
+	CampaignID         string    `json:"campaign_id,omitempty"`
+	DomainID           string    `json:"domain_id,omitempty"`
+	UpdatedAt          string    `json:"updated_at"`
+	CreatedAt          string    `json:"created_at"`
 }
 
 func newLinkResponse(link link.Link) linkResponse {
-	return linkResponse{
-		ID:          link.ID,
-		OriginalURL: link.OriginalUrl,
-		ShortURLID:  link.ShortUrlID,
-		UpdatedAt:   link.UpdatedAt,
-		CreatedAt:   link.CreatedAt,
+	response := linkResponse{
+		ID:                 link.ID,
+		OriginalURL:        link.OriginalUrl,
+		ShortURLID:         link.ShortUrlID,
+		PrettyID:           link.PrettyID,
+		RedirectType:       link.RedirectType,
+		Status:             link.Status,
+		ClickCount:         link.ClickCount,
+		ExpiresAt:          link.ExpiresAt,
+		DeletedAt:          link.DeletedAt,
+		MaxClicks:          link.MaxClicks,
+		FallbackURL:        link.FallbackUrl,
+		PasswordProtected:  link.IsProtected(),
+		FolderID:           link.FolderID,
+		Title:              link.Title,
+		Description:        link.Description,
+		FaviconURL:         link.FaviconUrl,
+		ImageURL:           link.ImageUrl,
+		PreviewTitle:       link.PreviewTitle,
+		PreviewDescription: link.PreviewDescription,
+		PreviewImageURL:    link.PreviewImageUrl,
+		ActivatesAt:        link.ActivatesAt,
+		QueryPassthrough:   link.QueryPassthrough,
+		CampaignID:         link.CampaignID,
+		DomainID:           link.DomainID,
+		UpdatedAt:          link.UpdatedAt,
+		CreatedAt:          link.CreatedAt,
 	}
+	if !link.UTM.IsZero() {
+		utm := link.UTM
+		response.UTM = &utm
+	}
+	return response
+}
+
+func userIDFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value("user_id").(string)
+	return userID
+}
+
+func HandleCreateShortLink(ctx context.Context, validator validation.Validator, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.link.HandleCreateShortLink"
+
+	type request struct {
+		URL          string `json:"url" validate:"required,url,max=2048"`
+		RedirectType int    `json:"redirect_type" validate:"omitempty,oneof=301 302 307 308"`
+		ExpiresAt    string `json:"expires_at"`
+		MaxClicks    int64  `json:"max_clicks" validate:"omitempty,min=1"`
+		FallbackURL  string `json:"fallback_url" validate:"omitempty,url,max=2048"`
+		ActivatesAt  string `json:"activates_at"`
+		// Optional. One of the user's verified domains.
+		DomainID string `json:"domain_id" validate:"omitempty,max=64"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := utils.DecodeToJSON[request](r)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{http.StatusText(http.StatusBadRequest)},
+			})
+			return
+		}
+
+		errs := validator.Validate(req)
+
+		expiresAt, err := parseTimeParam(req.ExpiresAt)
+		if err != nil {
+			errs = append(errs, validation.ValidationError{Field: "expires_at", Message: err.Error()})
+		}
+
+		activatesAt, err := parseTimeParam(req.ActivatesAt)
+		if err != nil {
+			errs = append(errs, validation.ValidationError{Field: "activates_at", Message: err.Error()})
+		}
+
+		if errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		createLinkArgs := link.CreateLinkParams{
+			UserID:       userIDFromContext(r.Context()),
+			OriginalURL:  req.URL,
+			RedirectType: req.RedirectType,
+			ExpiresAt:    expiresAt,
+			MaxClicks:    req.MaxClicks,
+			FallbackURL:  req.FallbackURL,
+			ActivatesAt:  activatesAt,
+			DomainID:     req.DomainID,
+		}
+
+		createdLink, err := linkService.CreateLink(ctx, createLinkArgs)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't create link", "error", err)
+			if err == link.ErrInvalidURL || err == link.ErrInvalidExpiry || err == link.ErrInvalidFallbackURL || err == link.ErrInvalidActivation ||
+				err == link.ErrDomainNotFound || err == link.ErrDomainNotVerified {
+				utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+					"errors": []string{err.Error()},
+				})
+				return
+			}
+			utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+				"errors": []string{http.StatusText(http.StatusInternalServerError)},
+			})
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusCreated, map[string]any{
+			"data": newLinkResponse(createdLink),
+		})
+	})
+}
+
+func HandlePrettifyShortLink(ctx context.Context, validator validation.Validator, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.link.HandlePrettifyShortLink"
+
+	type request struct {
+		ID       string `json:"id" validate:"required"`
+		PrettyID string `json:"pretty_id" validate:"required"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := utils.DecodeToJSON[request](r)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{http.StatusText(http.StatusBadRequest)},
+			})
+			return
+		}
+
+		if errs := validator.Validate(req); errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		claimAliasArgs := link.ClaimAliasParams{
+			UserID: userIDFromContext(r.Context()),
+			LinkID: req.ID,
+			Alias:  req.PrettyID,
+		}
+
+		updatedLink, err := linkService.ClaimAlias(ctx, claimAliasArgs)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't claim alias", "error", err)
+			respondWithEditError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": newLinkResponse(updatedLink),
+		})
+	})
+}
+
+func HandleUpdateLink(ctx context.Context, validator validation.Validator, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.link.HandleUpdateLink"
+
+	// Fields left out of the payload aren't changed
+	type request struct {
+		URL          *string `json:"url" validate:"omitempty,url,max=2048"`
+		PrettyID     *string `json:"pretty_id" validate:"omitempty,max=64"`
+		RedirectType *int    `json:"redirect_type" validate:"omitempty,oneof=301 302 307 308"`
+		ExpiresAt    *string `json:"expires_at"`
+		MaxClicks    *int64  `json:"max_clicks" validate:"omitempty,min=0"`
+		ActivatesAt  *string `json:"activates_at"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := utils.DecodeToJSON[request](r)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{http.StatusText(http.StatusBadRequest)},
+			})
+			return
+		}
+
+		errs := validator.Validate(req)
+
+		var expiresAt *time.Time
+		if req.ExpiresAt != nil {
+			parsed, err := parseTimeParam(*req.ExpiresAt)
+			if err != nil {
+				errs = append(errs, validation.ValidationError{Field: "expires_at", Message: err.Error()})
+			}
+			expiresAt = &parsed
+		}
+
+		var activatesAt *time.Time
+		if req.ActivatesAt != nil {
+			parsed, err := parseTimeParam(*req.ActivatesAt)
+			if err != nil {
+				errs = append(errs, validation.ValidationError{Field: "activates_at", Message: err.Error()})
+			}
+			activatesAt = &parsed
+		}
+
+		if errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		updateLinkArgs := link.UpdateLinkParams{
+			UserID:       userIDFromContext(r.Context()),
+			LinkID:       r.PathValue("id"),
+			OriginalURL:  req.URL,
+			Alias:        req.PrettyID,
+			RedirectType: req.RedirectType,
+			ExpiresAt:    expiresAt,
+			MaxClicks:    req.MaxClicks,
+			ActivatesAt:  activatesAt,
+		}
+
+		updatedLink, err := linkService.UpdateLink(ctx, updateLinkArgs)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't update link", "error", err)
+			respondWithEditError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": newLinkResponse(updatedLink),
+		})
+	})
 }
 
-func HandleCreateShortLink(ctx context.Context) http.Handler {
+// respondWithEditError maps the errors shared by the endpoints that edit a
+// link to their status codes.
+func respondWithEditError(w http.ResponseWriter, err error) {
+	switch err {
+	case link.ErrInvalidURL, link.ErrInvalidExpiry, link.ErrInvalidActivation, link.ErrInvalidAlias, link.ErrReservedAlias, link.ErrBlockedAlias:
+		utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+			"errors": []string{err.Error()},
+		})
+	case link.ErrAliasTaken, link.ErrLinkNotTrashed:
+		utils.RespondWithJSON(w, http.StatusConflict, map[string]any{
+			"errors": []string{err.Error()},
+		})
+	case link.ErrLinkNotFound, link.ErrRevisionNotFound:
+		utils.RespondWithJSON(w, http.StatusNotFound, map[string]any{
+			"errors": []string{err.Error()},
+		})
+	case link.ErrRestoreWindowPassed:
+		utils.RespondWithJSON(w, http.StatusGone, map[string]any{
+			"errors": []string{err.Error()},
+		})
+	default:
+		utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+			"errors": []string{http.StatusText(http.StatusInternalServerError)},
+		})
+	}
+}
+
+func HandleSetLinkPassword(ctx context.Context, validator validation.Validator, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.link.HandleSetLinkPassword"
+
+	type request struct {
+		ID       string `json:"id" validate:"required"`
+		Password string `json:"password" validate:"max=128"`
+	}
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		fmt.Fprintf(w, "Hello link")
+		req, err := utils.DecodeToJSON[request](r)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{http.StatusText(http.StatusBadRequest)},
+			})
+			return
+		}
+
+		if errs := validator.Validate(req); errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		setLinkPasswordArgs := link.SetLinkPasswordParams{
+			UserID:   userIDFromContext(r.Context()),
+			LinkID:   req.ID,
+			Password: req.Password,
+		}
+
+		updatedLink, err := linkService.SetLinkPassword(ctx, setLinkPasswordArgs)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't set link password", "error", err)
+			switch err {
+			case link.ErrInvalidPassword:
+				utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+					"errors": []string{err.Error()},
+				})
+			case link.ErrLinkNotFound:
+				utils.RespondWithJSON(w, http.StatusNotFound, map[string]any{
+					"errors": []string{err.Error()},
+				})
+			default:
+				utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+					"errors": []string{http.StatusText(http.StatusInternalServerError)},
+				})
+			}
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": newLinkResponse(updatedLink),
+		})
 	})
 }
+
+func HandleListShortLinks(ctx context.Context, validator validation.Validator, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.link.HandleListShortLinks"
+
+	type request struct {
+		Sort     string   `json:"sort" validate:"omitempty,oneof=created updated clicks"`
+		Status   string   `json:"status" validate:"omitempty,oneof=active expired trashed"`
+		Domain   string   `json:"domain" validate:"omitempty,hostname"`
+		Tags     []string `json:"tag" validate:"max=10,dive,max=64"`
+		FolderID string   `json:"folder_id" validate:"omitempty,max=64"`
+		Cursor   string   `json:"cursor"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		req := request{
+			Sort:     query.Get("sort"),
+			Status:   query.Get("status"),
+			Domain:   query.Get("domain"),
+			Tags:     query["tag"],
+			FolderID: query.Get("folder_id"),
+			Cursor:   query.Get("cursor"),
+		}
+
+		errs := validator.Validate(req)
+
+		pageSize, err := parseLimit(query.Get("limit"))
+		if err != nil {
+			errs = append(errs, validation.ValidationError{Field: "limit", Message: err.Error()})
+		}
+
+		createdAfter, err := parseTimeParam(query.Get("created_after"))
+		if err != nil {
+			errs = append(errs, validation.ValidationError{Field: "created_after", Message: err.Error()})
+		}
+
+		createdBefore, err := parseTimeParam(query.Get("created_before"))
+		if err != nil {
+			errs = append(errs, validation.ValidationError{Field: "created_before", Message: err.Error()})
+		}
+
+		if errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		listLinksArgs := link.ListLinksParams{
+			UserID:        userIDFromContext(r.Context()),
+			Status:        req.Status,
+			Domain:        req.Domain,
+			FolderID:      req.FolderID,
+			Tags:          req.Tags,
+			CreatedAfter:  createdAfter,
+			CreatedBefore: createdBefore,
+			Sort:          req.Sort,
+			Cursor:        req.Cursor,
+			PageSize:      pageSize,
+		}
+
+		page, err := linkService.ListLinks(ctx, listLinksArgs)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't list links", "error", err)
+			if err == link.ErrInvalidCursor || err == link.ErrInvalidSort || err == link.ErrInvalidTagName {
+				utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+					"errors": []string{err.Error()},
+				})
+				return
+			}
+			utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+				"errors": []string{http.StatusText(http.StatusInternalServerError)},
+			})
+			return
+		}
+
+		data := make([]linkResponse, 0, len(page.Links))
+		for _, l := range page.Links {
+			data = append(data, newLinkResponse(l))
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data":        data,
+			"next_cursor": page.NextCursor,
+		})
+	})
+}
+
+func parseLimit(value string) (int, error) {
+	if value == "" {
+		return link.DefaultPageSize, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > link.MaxPageSize {
+		return 0, fmt.Errorf("limit must be a number between 1 and %d", link.MaxPageSize)
+	}
+	return limit, nil
+}
+
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("must be an RFC 3339 timestamp")
+	}
+	return t, nil
+}
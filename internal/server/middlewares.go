@@ -39,6 +39,13 @@ func VerifyAuth(tokenMaker token.Maker) func(http.Handler) http.Handler {
 				return
 			}
 
+			// Scoped tokens don't grant access to the account they're for
+			if claims.Purpose != "" {
+				slog.Error(middlewareID, "error", "scoped token used as access token", "purpose", claims.Purpose)
+				http.Error(w, "invalid token", http.StatusUnauthorized)
+				return
+			}
+
 			ctx := r.Context()
 			ctx = context.WithValue(ctx, "user_id", claims.UserID)
 			next.ServeHTTP(w, r.WithContext(ctx))
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	_ "embed"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"url-shortener/internal/link"
+	"url-shortener/internal/utils"
+	"url-shortener/internal/validation"
+)
+
+//go:embed templates/preview.html
+var previewPageHTML string
+
+var previewPage = template.Must(template.New("preview").Parse(previewPageHTML))
+
+type previewPageData struct {
+	URL         string
+	Title       string
+	Description string
+	ImageURL    string
+}
+
+// crawlerAgents are user agent fragments of the bots that unfurl links in
+// chats and social feeds. Search engine crawlers aren't on the list: they
+// should follow the redirect like a visitor.
+var crawlerAgents = []string{
+	"facebookexternalhit",
+	"facebookcatalog",
+	"facebot",
+	"twitterbot",
+	"slackbot",
+	"slack-imgproxy",
+	"linkedinbot",
+	"discordbot",
+	"telegrambot",
+	"whatsapp",
+	"skypeuripreview",
+	"pinterest",
+	"redditbot",
+	"embedly",
+	"iframely",
+	"vkshare",
+	"mastodon",
+	"bluesky",
+}
+
+func isCrawler(userAgent string) bool {
+	userAgent = strings.ToLower(userAgent)
+	for _, agent := range crawlerAgents {
+		if strings.Contains(userAgent, agent) {
+			return true
+		}
+	}
+	return false
+}
+
+func renderPreviewPage(w http.ResponseWriter, r *http.Request, data previewPageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if err := previewPage.Execute(w, data); err != nil {
+		slog.Error("handler.link.renderPreviewPage", "error", err)
+	}
+}
+
+func HandleSetLinkPreview(ctx context.Context, validator validation.Validator, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.link.HandleSetLinkPreview"
+
+	// Fields left empty fall back to the destination page's own
+	type request struct {
+		Title       string `json:"title" validate:"max=200"`
+		Description string `json:"description" validate:"max=500"`
+		ImageURL    string `json:"image_url" validate:"omitempty,url,max=2048"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := utils.DecodeToJSON[request](r)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{http.StatusText(http.StatusBadRequest)},
+			})
+			return
+		}
+
+		if errs := validator.Validate(req); errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		updatedLink, err := linkService.SetLinkPreview(ctx, link.SetLinkPreviewParams{
+			UserID:      userIDFromContext(r.Context()),
+			LinkID:      r.PathValue("id"),
+			Title:       req.Title,
+			Description: req.Description,
+			ImageURL:    req.ImageURL,
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't set link preview", "error", err)
+			switch err {
+			case link.ErrInvalidPreview, link.ErrInvalidPreviewImage:
+				utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+					"errors": []string{err.Error()},
+				})
+			case link.ErrLinkNotFound:
+				utils.RespondWithJSON(w, http.StatusNotFound, map[string]any{
+					"errors": []string{err.Error()},
+				})
+			default:
+				utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+					"errors": []string{http.StatusText(http.StatusInternalServerError)},
+				})
+			}
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": newLinkResponse(updatedLink),
+		})
+	})
+}
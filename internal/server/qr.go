@@ -0,0 +1,214 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"url-shortener/internal/link"
+	"url-shortener/internal/qrcode"
+	"url-shortener/internal/utils"
+	"url-shortener/internal/validation"
+)
+
+const qrSuffix = ".qr"
+
+// HandleGetLinkQRCode renders the QR code of one of the user's links.
+func HandleGetLinkQRCode(ctx context.Context, validator validation.Validator, linkService *link.LinkService, baseURL string) http.Handler {
+	handlerID := "handler.link.HandleGetLinkQRCode"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		options, errs := parseQROptions(validator, r.URL.Query())
+
+		if errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		getLinkArgs := link.GetLinkParams{
+			UserID: userIDFromContext(r.Context()),
+			LinkID: r.PathValue("id"),
+		}
+
+		foundLink, err := linkService.GetLink(ctx, getLinkArgs)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't get link", "error", err)
+			if err == link.ErrLinkNotFound {
+				utils.RespondWithJSON(w, http.StatusNotFound, map[string]any{
+					"errors": []string{err.Error()},
+				})
+				return
+			}
+			utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+				"errors": []string{http.StatusText(http.StatusInternalServerError)},
+			})
+			return
+		}
+
+		var hostname string
+		if foundLink.DomainID != "" {
+			foundDomain, err := linkService.GetDomain(ctx, link.GetDomainParams{
+				UserID:   getLinkArgs.UserID,
+				DomainID: foundLink.DomainID,
+			})
+			if err != nil {
+				slog.Error(handlerID, "message", "couldn't get link domain", "error", err)
+				utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+					"errors": []string{http.StatusText(http.StatusInternalServerError)},
+				})
+				return
+			}
+			hostname = foundDomain.Hostname
+		}
+
+		serveQRCode(w, r, shortURL(baseURL, hostname, foundLink), options, "private, max-age=3600")
+	})
+}
+
+// HandlePublicQRCode serves `/{code}.qr` so a short link's QR code can be
+// embedded anywhere without an API token.
+func HandlePublicQRCode(ctx context.Context, validator validation.Validator, linkService *link.LinkService, baseURL string) http.Handler {
+	handlerID := "handler.link.HandlePublicQRCode"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code := strings.TrimSuffix(r.PathValue("code"), qrSuffix)
+
+		options, errs := parseQROptions(validator, r.URL.Query())
+
+		if errs != nil {
+			http.Error(w, errs[0].Error(), http.StatusBadRequest)
+			return
+		}
+
+		resolvedLink, err := linkService.ResolveLink(ctx, requestHostname(r), code)
+
+		// An expired or not yet active link's code still points at it, so
+		// its QR code is still accurate
+		if err != nil && err != link.ErrLinkExpired && err != link.ErrLinkNotActive {
+			if err == link.ErrLinkNotFound {
+				http.NotFound(w, r)
+				return
+			}
+			slog.Error(handlerID, "message", "couldn't resolve link", "code", code, "error", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		// The link was found on the domain the request came in on
+		var hostname string
+		if resolvedLink.DomainID != "" {
+			hostname = requestHostname(r)
+		}
+
+		serveQRCode(w, r, shortURL(baseURL, hostname, resolvedLink), options, "public, max-age=86400")
+	})
+}
+
+// routeShortCode sends `/{code}.qr` to the QR code handler and every other
+// top-level path to the redirect handler. ServeMux wildcards have to span a
+// whole path segment, so the suffix can't be matched by a pattern.
+func routeShortCode(qrHandler http.Handler, redirectHandler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.PathValue("code"), qrSuffix) {
+			qrHandler.ServeHTTP(w, r)
+			return
+		}
+		redirectHandler.ServeHTTP(w, r)
+	})
+}
+
+func parseQROptions(validator validation.Validator, query url.Values) (qrcode.Options, []validation.ValidationError) {
+	type request struct {
+		Format string `json:"format" validate:"omitempty,oneof=png svg"`
+		Level  string `json:"ecc" validate:"omitempty,oneof=L M Q H"`
+	}
+
+	req := request{
+		Format: query.Get("format"),
+		Level:  strings.ToUpper(query.Get("ecc")),
+	}
+
+	errs := validator.Validate(req)
+
+	options := qrcode.DefaultOptions()
+	if req.Format != "" {
+		options.Format = req.Format
+	}
+	if req.Level != "" {
+		options.Level = req.Level
+	}
+
+	if value := query.Get("size"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < qrcode.MinSize || size > qrcode.MaxSize {
+			errs = append(errs, validation.ValidationError{Field: "size", Message: "size must be a number between 64 and 2048"})
+		}
+		options.Size = size
+	}
+
+	if value := query.Get("margin"); value != "" {
+		margin, err := strconv.Atoi(value)
+		if err != nil || margin < 0 || margin > qrcode.MaxMargin {
+			errs = append(errs, validation.ValidationError{Field: "margin", Message: "margin must be a number between 0 and 16"})
+		}
+		options.Margin = margin
+	}
+
+	if value := query.Get("fg"); value != "" {
+		fg, err := qrcode.ParseColor(value)
+		if err != nil {
+			errs = append(errs, validation.ValidationError{Field: "fg", Message: err.Error()})
+		}
+		options.Foreground = fg
+	}
+
+	if value := query.Get("bg"); value != "" {
+		bg, err := qrcode.ParseColor(value)
+		if err != nil {
+			errs = append(errs, validation.ValidationError{Field: "bg", Message: err.Error()})
+		}
+		options.Background = bg
+	}
+
+	return options, errs
+}
+
+func serveQRCode(w http.ResponseWriter, r *http.Request, content string, options qrcode.Options, cacheControl string) {
+	code, err := qrcode.Encode(content, options)
+
+	if err != nil {
+		slog.Error("handler.link.serveQRCode", "message", "couldn't encode qr code", "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", code.ETag())
+	w.Header().Set("Cache-Control", cacheControl)
+
+	if match := r.Header.Get("If-None-Match"); match != "" && match == code.ETag() {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", code.ContentType())
+	if err := code.Write(w); err != nil {
+		slog.Error("handler.link.serveQRCode", "message", "couldn't write qr code", "error", err)
+	}
+}
+
+// shortURL builds the URL a QR code points to. It always uses the generated
+// code rather than the alias: printed codes can't be updated if the alias
+// changes later. Links on a branded domain use its hostname, which is
+// served over HTTPS.
+func shortURL(baseURL string, hostname string, l link.Link) string {
+	if hostname != "" {
+		return "https://" + hostname + "/" + l.ShortUrlID
+	}
+	return strings.TrimRight(baseURL, "/") + "/" + l.ShortUrlID
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"url-shortener/internal/link"
+	"url-shortener/internal/utils"
+	"url-shortener/internal/validation"
+)
+
+// utmRequest is how UTM values are sent. Empty values aren't added.
+type utmRequest struct {
+	Source   string `json:"source" validate:"max=200"`
+	Medium   string `json:"medium" validate:"max=200"`
+	Campaign string `json:"campaign" validate:"max=200"`
+	Term     string `json:"term" validate:"max=200"`
+	Content  string `json:"content" validate:"max=200"`
+}
+
+func (u utmRequest) toUTM() link.UTM {
+	return link.UTM{
+		Source:   u.Source,
+		Medium:   u.Medium,
+		Campaign: u.Campaign,
+		Term:     u.Term,
+		Content:  u.Content,
+	}
+}
+
+// HandleSetLinkQueryParams sets the UTM parameters a link adds to its
+// destination and what it does with the query string visitors come with.
+func HandleSetLinkQueryParams(ctx context.Context, validator validation.Validator, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.link.HandleSetLinkQueryParams"
+
+	type request struct {
+		UTM utmRequest `json:"utm"`
+		// drop when empty
+		QueryPassthrough string `json:"query_passthrough" validate:"omitempty,oneof=drop merge override"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := utils.DecodeToJSON[request](r)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{http.StatusText(http.StatusBadRequest)},
+			})
+			return
+		}
+
+		if errs := validator.Validate(req); errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		updatedLink, err := linkService.SetLinkQueryParams(ctx, link.SetLinkQueryParamsParams{
+			UserID:      userIDFromContext(r.Context()),
+			LinkID:      r.PathValue("id"),
+			UTM:         req.UTM.toUTM(),
+			Passthrough: req.QueryPassthrough,
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't set link query params", "error", err)
+			switch err {
+			case link.ErrInvalidUTM, link.ErrInvalidPassthrough:
+				utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+					"errors": []string{err.Error()},
+				})
+			case link.ErrLinkNotFound:
+				utils.RespondWithJSON(w, http.StatusNotFound, map[string]any{
+					"errors": []string{err.Error()},
+				})
+			default:
+				utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+					"errors": []string{http.StatusText(http.StatusInternalServerError)},
+				})
+			}
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": newLinkResponse(updatedLink),
+		})
+	})
+}
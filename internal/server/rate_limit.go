@@ -0,0 +1,68 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter allows a fixed number of attempts per key in each window.
+// State only lives in memory, which is fine for a single instance.
+type rateLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	windows   map[string]*rateWindow
+	lastSweep time.Time
+}
+
+type rateWindow struct {
+	start    time.Time
+	attempts int
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:   limit,
+		window:  window,
+		windows: map[string]*rateWindow{},
+	}
+}
+
+// Allow records an attempt for key and reports whether it's within the
+// limit.
+func (l *rateLimiter) Allow(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Drop finished windows now and then so the map doesn't grow forever
+	if now.Sub(l.lastSweep) > l.window {
+		for k, w := range l.windows {
+			if now.Sub(w.start) > l.window {
+				delete(l.windows, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) > l.window {
+		w = &rateWindow{start: now}
+		l.windows[key] = w
+	}
+
+	w.attempts++
+
+	return w.attempts <= l.limit
+}
+
+// RetryAfter is how long until key gets a fresh window.
+func (l *rateLimiter) RetryAfter(key string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w, ok := l.windows[key]
+	if !ok {
+		return 0
+	}
+	return max(w.start.Add(l.window).Sub(now), 0)
+}
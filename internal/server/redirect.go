@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+	"url-shortener/internal/analytics"
+	"url-shortener/internal/geo"
+	"url-shortener/internal/link"
+	"url-shortener/internal/token"
+)
+
+const (
+	variantCookiePrefix   = "link_variant_"
+	variantCookieDuration = 90 * 24 * time.Hour
+)
+
+// HandleRedirect resolves a short code or pretty ID to its destination on
+// the domain the request was sent to. Hosts that aren't a verified branded
+// domain get the shared domain's links.
+// Paths that don't belong to a link are handed to the SPA file server so
+// top-level assets like `/favicon.ico` keep working. Expired links answer
+// 410 Gone unless they have a fallback URL to send visitors to, and links
+// that haven't activated yet answer 404 the same way. Password
+// protected links show the unlock page until the visitor has unlocked them.
+// Links with targeting rules send each visitor to the first rule they match,
+// and links with variants split the rest between them. The link's UTM
+// parameters and, if it allows, the visitor's query string are added to
+// wherever they end up.
+func HandleRedirect(ctx context.Context, linkService *link.LinkService, tokenMaker token.Maker, clickRecorder *analytics.Recorder, locator geo.Locator, clientIPHeader string, fs http.Handler) http.Handler {
+	handlerID := "handler.link.HandleRedirect"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code := r.PathValue("code")
+
+		resolvedLink, err := linkService.ResolveLink(ctx, requestHostname(r), code)
+
+		if err != nil {
+			if err == link.ErrLinkNotFound {
+				fs.ServeHTTP(w, r)
+				return
+			}
+			if err == link.ErrLinkExpired {
+				serveExpiredLink(w, r, resolvedLink)
+				return
+			}
+			if err == link.ErrLinkNotActive {
+				serveInactiveLink(w, r, resolvedLink)
+				return
+			}
+			slog.Error(handlerID, "message", "couldn't resolve link", "code", code, "error", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		if resolvedLink.IsProtected() && !isUnlocked(r, tokenMaker, resolvedLink.ID) {
+			renderUnlockPage(w, http.StatusOK, unlockPageData{})
+			return
+		}
+
+		if preview, ok := resolvedLink.SocialPreview(); ok {
+			// Caches mustn't hand the preview page to visitors, or the
+			// redirect to crawlers
+			w.Header().Add("Vary", "User-Agent")
+			if isCrawler(r.UserAgent()) {
+				renderPreviewPage(w, r, previewPageData{
+					URL:         resolvedLink.OriginalUrl,
+					Title:       preview.Title,
+					Description: preview.Description,
+					ImageURL:    preview.ImageURL,
+				})
+				return
+			}
+		}
+
+		// HEAD requests come from link checkers and prefetchers, not visitors
+		isVisit := r.Method == http.MethodGet
+
+		if isVisit {
+			if err := linkService.SpendClick(ctx, resolvedLink); err != nil {
+				if err == link.ErrLinkExpired {
+					serveExpiredLink(w, r, resolvedLink)
+					return
+				}
+				slog.Error(handlerID, "message", "couldn't spend click", "code", code, "error", err)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		ip := clientIP(r, clientIPHeader)
+		location := locator.Locate(ip)
+
+		visitor := link.Visitor{
+			UserAgent: r.UserAgent(),
+			IP:        ip,
+			Country:   location.Country,
+			Region:    location.Region,
+		}
+		if cookie, err := r.Cookie(variantCookiePrefix + resolvedLink.ID); err == nil {
+			visitor.VariantID = cookie.Value
+		}
+
+		destination := linkService.ChooseDestination(ctx, resolvedLink, visitor)
+
+		if destination.VariantID != "" && destination.VariantID != visitor.VariantID {
+			// Keeps the visitor on the same variant on their next visit
+			http.SetCookie(w, &http.Cookie{
+				Name:     variantCookiePrefix + resolvedLink.ID,
+				Value:    destination.VariantID,
+				Path:     "/",
+				MaxAge:   int(variantCookieDuration.Seconds()),
+				HttpOnly: true,
+				Secure:   r.TLS != nil,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		status := redirectStatus(resolvedLink.RedirectType)
+
+		if destination.Personalized {
+			// Rules can also depend on where the visitor is, when they come
+			// and variants on who they are, none of which a header says, so
+			// shared caches mustn't keep the redirect
+			w.Header().Add("Vary", "User-Agent")
+			w.Header().Set("Cache-Control", "private")
+			// Browsers keep permanent redirects for good, which would pin
+			// the visitor to whatever they got first
+			status = temporaryRedirectStatus(status)
+		}
+
+		if isVisit {
+			clickRecorder.Record(analytics.Click{
+				LinkID:    resolvedLink.ID,
+				ClickedAt: time.Now(),
+				Referrer:  r.Referer(),
+				UserAgent: r.UserAgent(),
+				IP:        ip,
+				Country:   location.Country,
+				City:      location.City,
+				VariantID: destination.VariantID,
+				Counted:   resolvedLink.MaxClicks > 0,
+			})
+		}
+
+		http.Redirect(w, r, resolvedLink.BuildDestination(destination.URL, r.URL.RawQuery), status)
+	})
+}
+
+func serveExpiredLink(w http.ResponseWriter, r *http.Request, expiredLink link.Link) {
+	if expiredLink.FallbackUrl != "" {
+		http.Redirect(w, r, expiredLink.FallbackUrl, http.StatusFound)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusGone), http.StatusGone)
+}
+
+// serveInactiveLink answers for links that haven't activated yet. The
+// answer changes once they do, so it mustn't be cached.
+func serveInactiveLink(w http.ResponseWriter, r *http.Request, inactiveLink link.Link) {
+	w.Header().Set("Cache-Control", "no-store")
+	if inactiveLink.FallbackUrl != "" {
+		http.Redirect(w, r, inactiveLink.FallbackUrl, http.StatusFound)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func redirectStatus(redirectType int) int {
+	switch redirectType {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return redirectType
+	default:
+		return http.StatusFound
+	}
+}
+
+// temporaryRedirectStatus is the temporary counterpart of status, keeping
+// whether the method is preserved.
+func temporaryRedirectStatus(status int) int {
+	switch status {
+	case http.StatusMovedPermanently:
+		return http.StatusFound
+	case http.StatusPermanentRedirect:
+		return http.StatusTemporaryRedirect
+	default:
+		return status
+	}
+}
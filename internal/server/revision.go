@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"url-shortener/internal/link"
+	"url-shortener/internal/utils"
+)
+
+func HandleListLinkRevisions(ctx context.Context, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.link.HandleListLinkRevisions"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		listRevisionsArgs := link.ListRevisionsParams{
+			UserID: userIDFromContext(r.Context()),
+			LinkID: r.PathValue("id"),
+		}
+
+		revisions, err := linkService.ListRevisions(ctx, listRevisionsArgs)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't list revisions", "error", err)
+			respondWithEditError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": revisions,
+		})
+	})
+}
+
+func HandleRollbackLink(ctx context.Context, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.link.HandleRollbackLink"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		revisionID, err := strconv.ParseInt(r.PathValue("revision_id"), 10, 64)
+
+		if err != nil {
+			utils.RespondWithJSON(w, http.StatusNotFound, map[string]any{
+				"errors": []string{link.ErrRevisionNotFound.Error()},
+			})
+			return
+		}
+
+		rollbackLinkArgs := link.RollbackLinkParams{
+			UserID:     userIDFromContext(r.Context()),
+			LinkID:     r.PathValue("id"),
+			RevisionID: revisionID,
+		}
+
+		updatedLink, err := linkService.RollbackLink(ctx, rollbackLinkArgs)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't roll back link", "error", err)
+			respondWithEditError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": newLinkResponse(updatedLink),
+		})
+	})
+}
@@ -4,14 +4,19 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"url-shortener/internal/analytics"
 	"url-shortener/internal/auth"
 	emailverification "url-shortener/internal/email_verification"
+	"url-shortener/internal/export"
+	"url-shortener/internal/geo"
+	"url-shortener/internal/job"
+	"url-shortener/internal/link"
 	"url-shortener/internal/token"
 	"url-shortener/internal/user"
 	"url-shortener/internal/validation"
 )
 
-func routes(ctx context.Context, mux *http.ServeMux, fs http.Handler, validator validation.Validator, tokenMaker token.Maker, userService *user.UserService, _ *auth.AuthService, emailVerificationService *emailverification.EmailVerificationService) {
+func routes(ctx context.Context, mux *http.ServeMux, fs http.Handler, validator validation.Validator, tokenMaker token.Maker, userService *user.UserService, _ *auth.AuthService, emailVerificationService *emailverification.EmailVerificationService, linkService *link.LinkService, statsService *analytics.StatsService, jobService *job.JobService, exportService *export.ExportService, clickRecorder *analytics.Recorder, locator geo.Locator, clientIPHeader string, baseURL string, bulkMaxItems int) {
 	// AUTH
 	apiMux := NewRouteGroup("/api", mux)
 	apiMux.Handle("POST /auth/signup", HandleSignup(ctx, validator, *userService, *emailVerificationService))
@@ -25,17 +30,88 @@ func routes(ctx context.Context, mux *http.ServeMux, fs http.Handler, validator
 	userMux.Use(VerifyAuth(tokenMaker))
 	userMux.Handle("GET /me", handleNoop())
 	userMux.Handle("GET /change-password", handleNoop())
+	userMux.Handle("POST /export", HandleStartExport(ctx, jobService, baseURL))
 
 	linkMux := apiMux.Group("/links")
 	linkMux.Use(VerifyAuth(tokenMaker))
-	linkMux.Handle("GET /links", handleNoop())
-	linkMux.Handle("POST /links", HandleCreateShortLink(ctx))
-	linkMux.Handle("POST /links/pretty", handleNoop())
-	linkMux.Handle("DELETE /links/:id", handleNoop())
+	linkMux.Handle("GET /links", HandleListShortLinks(ctx, validator, linkService))
+	linkMux.Handle("POST /links", HandleCreateShortLink(ctx, validator, linkService))
+	linkMux.Handle("GET /search", HandleSearchLinks(ctx, validator, linkService))
+	linkMux.Handle("POST /bulk", HandleBulkCreateLinks(ctx, validator, linkService, bulkMaxItems))
+	linkMux.Handle("POST /import", HandleImportLinks(ctx, linkService, jobService, bulkMaxItems))
+	linkMux.Handle("POST /tags", HandleTagLinks(ctx, validator, linkService, bulkMaxItems))
+	linkMux.Handle("POST /move", HandleMoveLinks(ctx, validator, linkService, bulkMaxItems))
+	linkMux.Handle("POST /links/pretty", HandlePrettifyShortLink(ctx, validator, linkService))
+	linkMux.Handle("POST /links/password", HandleSetLinkPassword(ctx, validator, linkService))
+	linkMux.Handle("PATCH /{id}", HandleUpdateLink(ctx, validator, linkService))
+	linkMux.Handle("DELETE /{id}", HandleTrashLink(ctx, linkService))
+	linkMux.Handle("POST /{id}/restore", HandleRestoreLink(ctx, linkService))
+	linkMux.Handle("GET /{id}/stats", HandleGetLinkStats(ctx, validator, statsService))
+	linkMux.Handle("PUT /{id}/preview", HandleSetLinkPreview(ctx, validator, linkService))
+	linkMux.Handle("PUT /{id}/query", HandleSetLinkQueryParams(ctx, validator, linkService))
+	linkMux.Handle("GET /{id}/rules", HandleListLinkRules(ctx, linkService))
+	linkMux.Handle("PUT /{id}/rules", HandleSetLinkRules(ctx, validator, linkService))
+	linkMux.Handle("GET /{id}/variants", HandleListLinkVariants(ctx, linkService))
+	linkMux.Handle("POST /{id}/variants", HandleAddLinkVariant(ctx, validator, linkService))
+	linkMux.Handle("PATCH /{id}/variants/{variant_id}", HandleUpdateLinkVariant(ctx, validator, linkService))
+	linkMux.Handle("DELETE /{id}/variants/{variant_id}", HandleDeleteLinkVariant(ctx, linkService))
+	linkMux.Handle("POST /{id}/variants/{variant_id}/winner", HandleDeclareVariantWinner(ctx, linkService))
+	linkMux.Handle("GET /{id}/qr", HandleGetLinkQRCode(ctx, validator, linkService, baseURL))
+	linkMux.Handle("GET /{id}/revisions", HandleListLinkRevisions(ctx, linkService))
+	linkMux.Handle("POST /{id}/revisions/{revision_id}/rollback", HandleRollbackLink(ctx, linkService))
+
+	tagMux := apiMux.Group("/tags")
+	tagMux.Use(VerifyAuth(tokenMaker))
+	tagMux.Handle("GET /", HandleListTags(ctx, linkService))
+	tagMux.Handle("POST /", HandleCreateTag(ctx, validator, linkService))
+	tagMux.Handle("PATCH /{id}", HandleRenameTag(ctx, validator, linkService))
+	tagMux.Handle("DELETE /{id}", HandleDeleteTag(ctx, linkService))
+
+	folderMux := apiMux.Group("/folders")
+	folderMux.Use(VerifyAuth(tokenMaker))
+	folderMux.Handle("GET /", HandleListFolders(ctx, linkService))
+	folderMux.Handle("POST /", HandleCreateFolder(ctx, validator, linkService))
+	folderMux.Handle("PATCH /{id}", HandleUpdateFolder(ctx, validator, linkService))
+	folderMux.Handle("DELETE /{id}", HandleDeleteFolder(ctx, linkService))
+
+	campaignMux := apiMux.Group("/campaigns")
+	campaignMux.Use(VerifyAuth(tokenMaker))
+	campaignMux.Handle("GET /", HandleListCampaigns(ctx, linkService))
+	campaignMux.Handle("POST /", HandleCreateCampaign(ctx, validator, linkService))
+	campaignMux.Handle("PATCH /{id}", HandleUpdateCampaign(ctx, validator, linkService))
+	campaignMux.Handle("DELETE /{id}", HandleDeleteCampaign(ctx, linkService))
+	campaignMux.Handle("GET /{id}/links", HandleListCampaignLinks(ctx, linkService))
+	campaignMux.Handle("POST /{id}/links", HandleAddCampaignLinks(ctx, validator, linkService, bulkMaxItems))
+	campaignMux.Handle("DELETE /{id}/links/{link_id}", HandleRemoveCampaignLink(ctx, linkService))
+	campaignMux.Handle("GET /{id}/stats", HandleGetCampaignStats(ctx, validator, statsService))
+
+	domainMux := apiMux.Group("/domains")
+	domainMux.Use(VerifyAuth(tokenMaker))
+	domainMux.Handle("GET /", HandleListDomains(ctx, linkService))
+	domainMux.Handle("POST /", HandleCreateDomain(ctx, validator, linkService))
+	domainMux.Handle("POST /{id}/verify", HandleVerifyDomain(ctx, linkService))
+	domainMux.Handle("DELETE /{id}", HandleDeleteDomain(ctx, linkService))
+
+	jobMux := apiMux.Group("/jobs")
+	jobMux.Use(VerifyAuth(tokenMaker))
+	jobMux.Handle("GET /{id}", HandleGetJob(ctx, jobService))
+
+	// Signed links from export emails, so no auth
+	apiMux.Handle("GET /exports/{id}", HandleDownloadExport(ctx, exportService))
 
 	// OTHERS
 	mux.Handle("GET /", fs)
+	mux.HandleFunc("GET /health", HandleHealth())
 	mux.HandleFunc("GET /health/", HandleHealth())
+
+	// REDIRECTS
+	// Every host shares these routes. The handlers pick the domain codes are
+	// looked up on from the Host header.
+	mux.Handle("GET /{code}", routeShortCode(
+		HandlePublicQRCode(ctx, validator, linkService, baseURL),
+		HandleRedirect(ctx, linkService, tokenMaker, clickRecorder, locator, clientIPHeader, fs),
+	))
+	mux.Handle("POST /{code}", HandleUnlockLink(ctx, linkService, tokenMaker, newRateLimiter(unlockAttempts, unlockWindow), clientIPHeader))
 }
 
 func handleNoop() http.Handler {
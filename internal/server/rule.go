@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"url-shortener/internal/link"
+	"url-shortener/internal/utils"
+	"url-shortener/internal/validation"
+)
+
+func HandleListLinkRules(ctx context.Context, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.link.HandleListLinkRules"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rules, err := linkService.ListLinkRules(ctx, link.ListLinkRulesParams{
+			UserID: userIDFromContext(r.Context()),
+			LinkID: r.PathValue("id"),
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't list link rules", "error", err)
+			respondWithRuleError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": rules,
+		})
+	})
+}
+
+// HandleSetLinkRules replaces a link's targeting rules. Visitors go to the
+// first rule they match, in the order given, and to the link's own
+// destination when they match none.
+func HandleSetLinkRules(ctx context.Context, validator validation.Validator, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.link.HandleSetLinkRules"
+
+	type rule struct {
+		OS      []string `json:"os" validate:"dive,oneof=ios android windows macos linux chromeos"`
+		Devices []string `json:"devices" validate:"dive,oneof=mobile tablet desktop"`
+		// ISO 3166-1 alpha-2 country codes, like "US"
+		Countries []string `json:"countries" validate:"max=250,dive,len=2,alpha"`
+		// ISO 3166-2 region codes, like "US-CA"
+		Regions []string `json:"regions" validate:"max=250,dive,min=4,max=6"`
+		// IANA timezone the times below are in, UTC when empty
+		Timezone string `json:"timezone" validate:"omitempty,max=64"`
+		// RFC 3339, or local times like "2025-12-24T18:00"
+		StartsAt string   `json:"starts_at" validate:"max=64"`
+		EndsAt   string   `json:"ends_at" validate:"max=64"`
+		Days     []string `json:"days" validate:"max=7,dive,oneof=mon tue wed thu fri sat sun"`
+		// HH:MM, ending before it starts to run past midnight
+		DailyStart string `json:"daily_start" validate:"omitempty,len=5"`
+		DailyEnd   string `json:"daily_end" validate:"omitempty,len=5"`
+		URL        string `json:"url" validate:"required,url,max=2048"`
+	}
+
+	type request struct {
+		Rules []rule `json:"rules" validate:"max=20,dive"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := utils.DecodeToJSON[request](r)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{http.StatusText(http.StatusBadRequest)},
+			})
+			return
+		}
+
+		if errs := validator.Validate(req); errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		params := make([]link.RuleParams, len(req.Rules))
+		for i, rule := range req.Rules {
+			params[i] = link.RuleParams{
+				OS:        rule.OS,
+				Devices:   rule.Devices,
+				Countries: rule.Countries,
+				Regions:   rule.Regions,
+				Schedule: link.ScheduleParams{
+					Timezone:   rule.Timezone,
+					StartsAt:   rule.StartsAt,
+					EndsAt:     rule.EndsAt,
+					Days:       rule.Days,
+					DailyStart: rule.DailyStart,
+					DailyEnd:   rule.DailyEnd,
+				},
+				DestinationURL: rule.URL,
+			}
+		}
+
+		rules, err := linkService.SetLinkRules(ctx, link.SetLinkRulesParams{
+			UserID: userIDFromContext(r.Context()),
+			LinkID: r.PathValue("id"),
+			Rules:  params,
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't set link rules", "error", err)
+			respondWithRuleError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": rules,
+		})
+	})
+}
+
+func respondWithRuleError(w http.ResponseWriter, err error) {
+	switch err {
+	case link.ErrInvalidRule, link.ErrInvalidSchedule, link.ErrTooManyRules, link.ErrInvalidURL:
+		utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+			"errors": []string{err.Error()},
+		})
+	case link.ErrLinkNotFound:
+		utils.RespondWithJSON(w, http.StatusNotFound, map[string]any{
+			"errors": []string{err.Error()},
+		})
+	default:
+		utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+			"errors": []string{http.StatusText(http.StatusInternalServerError)},
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"url-shortener/internal/link"
+	"url-shortener/internal/utils"
+	"url-shortener/internal/validation"
+)
+
+type searchResultResponse struct {
+	linkResponse
+	Snippet string `json:"snippet"`
+}
+
+// HandleSearchLinks runs a full-text search over the user's links. The best
+// matches come first, each with an HTML snippet highlighting the matched
+// words in <mark> tags.
+func HandleSearchLinks(ctx context.Context, validator validation.Validator, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.link.HandleSearchLinks"
+
+	type request struct {
+		Query string `json:"q" validate:"required,max=256"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		req := request{
+			Query: query.Get("q"),
+		}
+
+		errs := validator.Validate(req)
+
+		limit, err := parseLimit(query.Get("limit"))
+		if err != nil {
+			errs = append(errs, validation.ValidationError{Field: "limit", Message: err.Error()})
+		}
+
+		if errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		results, err := linkService.SearchLinks(ctx, link.SearchLinksParams{
+			UserID: userIDFromContext(r.Context()),
+			Query:  req.Query,
+			Limit:  limit,
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't search links", "error", err)
+			if err == link.ErrInvalidSearchQuery {
+				utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+					"errors": []string{err.Error()},
+				})
+				return
+			}
+			utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+				"errors": []string{http.StatusText(http.StatusInternalServerError)},
+			})
+			return
+		}
+
+		data := make([]searchResultResponse, len(results))
+		for i, result := range results {
+			data[i] = searchResultResponse{
+				linkResponse: newLinkResponse(result.Link),
+				Snippet:      result.Snippet,
+			}
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": data,
+		})
+	})
+}
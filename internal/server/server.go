@@ -4,16 +4,22 @@ import (
 	"context"
 	"net/http"
 	db "url-shortener/db/sqlc"
+	"url-shortener/internal/analytics"
 	"url-shortener/internal/auth"
 	"url-shortener/internal/config"
 	"url-shortener/internal/email"
 	emailverification "url-shortener/internal/email_verification"
+	"url-shortener/internal/export"
+	"url-shortener/internal/geo"
+	"url-shortener/internal/job"
+	"url-shortener/internal/link"
+	"url-shortener/internal/metadata"
 	"url-shortener/internal/token"
 	"url-shortener/internal/user"
 	"url-shortener/internal/validation"
 )
 
-func New(ctx context.Context, cfg config.Config, fs http.Handler, queries *db.Queries, tokenMaker token.Maker) http.Handler {
+func New(ctx context.Context, cfg config.Config, fs http.Handler, store *db.Store, tokenMaker token.Maker, codeGenerator link.CodeGenerator, resolver link.TXTResolver, clickRecorder *analytics.Recorder) http.Handler {
 	mux := http.NewServeMux()
 
 	validator := validation.NewValidationService()
@@ -23,10 +29,29 @@ func New(ctx context.Context, cfg config.Config, fs http.Handler, queries *db.Qu
 	// } else {
 	// 	emailService = email.NewResendService(email.EmailSMTPConfig(cfg.SMTP), cfg.ResendKey)
 	// }
-	emailVerificationService := emailverification.NewEmailVerificationService(queries, emailService)
-	userService := user.NewUserService(queries, tokenMaker, emailService, emailVerificationService)
-	authService := auth.NewAuthService(queries)
+	emailVerificationService := emailverification.NewEmailVerificationService(store.Queries, emailService)
+	userService := user.NewUserService(store.Queries, tokenMaker, emailService, emailVerificationService)
+	authService := auth.NewAuthService(store.Queries)
+	linkService := link.NewLinkService(store, codeGenerator, resolver, cfg.Link.TrashRetention)
+	statsService := analytics.NewStatsService(store.Queries)
+	jobService := job.NewJobService(store.Queries)
+	exportService := export.NewExportService(store.Queries, tokenMaker, emailService, cfg.Export.DownloadTTL, cfg.Export.Directory)
+	geoDatabase := geo.NewDatabase(cfg.GeoIP.DatabasePath)
+	jobService.Register(link.ImportJobKind, linkService.ImportJob)
+	jobService.Register(export.JobKind, exportService.RunJob)
 
-	routes(ctx, mux, fs, validator, tokenMaker, userService, authService, emailVerificationService)
+	go linkService.RunExpirySweeper(ctx, cfg.Link.ExpirySweepInterval)
+	go linkService.RunTrashPurger(ctx, cfg.Link.TrashPurgeInterval)
+	go statsService.RunRollups(ctx, cfg.Analytics.RollupInterval)
+	go jobService.Run(ctx, cfg.Job.PollInterval)
+	go exportService.RunCleanup(ctx, cfg.Export.CleanupInterval)
+	go linkService.RunMetadataFetcher(ctx, metadata.NewFetcher(metadata.Options{
+		Timeout:         cfg.Metadata.FetchTimeout,
+		MaxBodySize:     cfg.Metadata.MaxBodySize,
+		AllowPrivateIPs: cfg.Metadata.AllowPrivateIPs,
+	}), cfg.Metadata.PollInterval)
+	go geoDatabase.RunReloader(ctx, cfg.GeoIP.ReloadInterval)
+
+	routes(ctx, mux, fs, validator, tokenMaker, userService, authService, emailVerificationService, linkService, statsService, jobService, exportService, clickRecorder, geoDatabase, cfg.Server.ClientIPHeader, cfg.Server.BaseURL, cfg.Link.BulkMaxItems)
 	return mux
 }
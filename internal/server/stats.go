@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+	"url-shortener/internal/analytics"
+	"url-shortener/internal/utils"
+	"url-shortener/internal/validation"
+)
+
+type statsQuery struct {
+	from     time.Time
+	to       time.Time
+	interval string
+	location *time.Location
+}
+
+// parseStatsQuery reads the from, to, interval and tz query parameters
+// stats endpoints take.
+func parseStatsQuery(validator validation.Validator, r *http.Request) (statsQuery, []validation.ValidationError) {
+	type request struct {
+		Interval string `json:"interval" validate:"omitempty,oneof=hour day week"`
+		Timezone string `json:"tz" validate:"omitempty,max=64"`
+	}
+
+	query := r.URL.Query()
+
+	req := request{
+		Interval: query.Get("interval"),
+		Timezone: query.Get("tz"),
+	}
+
+	errs := validator.Validate(req)
+
+	from, err := parseTimeParam(query.Get("from"))
+	if err != nil {
+		errs = append(errs, validation.ValidationError{Field: "from", Message: err.Error()})
+	}
+
+	to, err := parseTimeParam(query.Get("to"))
+	if err != nil {
+		errs = append(errs, validation.ValidationError{Field: "to", Message: err.Error()})
+	}
+
+	location := time.UTC
+	if req.Timezone != "" {
+		location, err = time.LoadLocation(req.Timezone)
+		if err != nil {
+			errs = append(errs, validation.ValidationError{Field: "tz", Message: "must be an IANA time zone"})
+		}
+	}
+
+	return statsQuery{from: from, to: to, interval: req.Interval, location: location}, errs
+}
+
+func HandleGetLinkStats(ctx context.Context, validator validation.Validator, statsService *analytics.StatsService) http.Handler {
+	handlerID := "handler.link.HandleGetLinkStats"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query, errs := parseStatsQuery(validator, r)
+
+		if errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		getLinkStatsArgs := analytics.GetLinkStatsParams{
+			UserID:   userIDFromContext(r.Context()),
+			LinkID:   r.PathValue("id"),
+			From:     query.from,
+			To:       query.to,
+			Interval: query.interval,
+			Location: query.location,
+		}
+
+		stats, err := statsService.GetLinkStats(ctx, getLinkStatsArgs)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't get link stats", "error", err)
+			respondWithStatsError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": stats,
+		})
+	})
+}
+
+func HandleGetCampaignStats(ctx context.Context, validator validation.Validator, statsService *analytics.StatsService) http.Handler {
+	handlerID := "handler.campaign.HandleGetCampaignStats"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query, errs := parseStatsQuery(validator, r)
+
+		if errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		stats, err := statsService.GetCampaignStats(ctx, analytics.GetCampaignStatsParams{
+			UserID:     userIDFromContext(r.Context()),
+			CampaignID: r.PathValue("id"),
+			From:       query.from,
+			To:         query.to,
+			Interval:   query.interval,
+			Location:   query.location,
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't get campaign stats", "error", err)
+			respondWithStatsError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": stats,
+		})
+	})
+}
+
+func respondWithStatsError(w http.ResponseWriter, err error) {
+	switch err {
+	case analytics.ErrInvalidRange, analytics.ErrInvalidInterval:
+		utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+			"errors": []string{err.Error()},
+		})
+	case analytics.ErrLinkNotFound, analytics.ErrCampaignNotFound:
+		utils.RespondWithJSON(w, http.StatusNotFound, map[string]any{
+			"errors": []string{err.Error()},
+		})
+	default:
+		utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+			"errors": []string{http.StatusText(http.StatusInternalServerError)},
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"url-shortener/internal/link"
+	"url-shortener/internal/utils"
+	"url-shortener/internal/validation"
+)
+
+func HandleListTags(ctx context.Context, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.tag.HandleListTags"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tags, err := linkService.ListTags(ctx, userIDFromContext(r.Context()))
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't list tags", "error", err)
+			respondWithOrganizeError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": tags,
+		})
+	})
+}
+
+func HandleCreateTag(ctx context.Context, validator validation.Validator, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.tag.HandleCreateTag"
+
+	type request struct {
+		Name string `json:"name" validate:"required,max=64"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := utils.DecodeToJSON[request](r)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{http.StatusText(http.StatusBadRequest)},
+			})
+			return
+		}
+
+		if errs := validator.Validate(req); errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		createdTag, err := linkService.CreateTag(ctx, link.CreateTagParams{
+			UserID: userIDFromContext(r.Context()),
+			Name:   req.Name,
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't create tag", "error", err)
+			respondWithOrganizeError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusCreated, map[string]any{
+			"data": createdTag,
+		})
+	})
+}
+
+func HandleRenameTag(ctx context.Context, validator validation.Validator, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.tag.HandleRenameTag"
+
+	type request struct {
+		Name string `json:"name" validate:"required,max=64"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := utils.DecodeToJSON[request](r)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{http.StatusText(http.StatusBadRequest)},
+			})
+			return
+		}
+
+		if errs := validator.Validate(req); errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		renamedTag, err := linkService.RenameTag(ctx, link.RenameTagParams{
+			UserID: userIDFromContext(r.Context()),
+			TagID:  r.PathValue("id"),
+			Name:   req.Name,
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't rename tag", "error", err)
+			respondWithOrganizeError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": renamedTag,
+		})
+	})
+}
+
+func HandleDeleteTag(ctx context.Context, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.tag.HandleDeleteTag"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := linkService.DeleteTag(ctx, link.DeleteTagParams{
+			UserID: userIDFromContext(r.Context()),
+			TagID:  r.PathValue("id"),
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't delete tag", "error", err)
+			respondWithOrganizeError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// HandleTagLinks adds and removes tags on many links at once.
+func HandleTagLinks(ctx context.Context, validator validation.Validator, linkService *link.LinkService, maxLinks int) http.Handler {
+	handlerID := "handler.tag.HandleTagLinks"
+
+	if maxLinks <= 0 {
+		maxLinks = link.DefaultBulkMaxItems
+	}
+
+	type request struct {
+		LinkIDs []string `json:"link_ids" validate:"required,min=1,dive,required,max=64"`
+		Add     []string `json:"add" validate:"max=50,dive,max=64"`
+		Remove  []string `json:"remove" validate:"max=50,dive,max=64"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := utils.DecodeToJSON[request](r)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{http.StatusText(http.StatusBadRequest)},
+			})
+			return
+		}
+
+		errs := validator.Validate(req)
+
+		if len(req.LinkIDs) > maxLinks {
+			errs = append(errs, validation.ValidationError{Field: "link_ids", Message: "too many links"})
+		}
+		if len(req.Add) == 0 && len(req.Remove) == 0 {
+			errs = append(errs, validation.ValidationError{Field: "add", Message: "add or remove at least one tag"})
+		}
+
+		if errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		result, err := linkService.TagLinks(ctx, link.TagLinksParams{
+			UserID:  userIDFromContext(r.Context()),
+			LinkIDs: req.LinkIDs,
+			Add:     req.Add,
+			Remove:  req.Remove,
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't tag links", "error", err)
+			respondWithOrganizeError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": result,
+		})
+	})
+}
+
+// respondWithOrganizeError maps the errors shared by the tag and folder
+// endpoints to their status codes.
+func respondWithOrganizeError(w http.ResponseWriter, err error) {
+	switch err {
+	case link.ErrInvalidTagName, link.ErrInvalidFolderName, link.ErrFolderCycle:
+		utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+			"errors": []string{err.Error()},
+		})
+	case link.ErrTagExists, link.ErrFolderExists:
+		utils.RespondWithJSON(w, http.StatusConflict, map[string]any{
+			"errors": []string{err.Error()},
+		})
+	case link.ErrTagNotFound, link.ErrFolderNotFound:
+		utils.RespondWithJSON(w, http.StatusNotFound, map[string]any{
+			"errors": []string{err.Error()},
+		})
+	default:
+		utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+			"errors": []string{http.StatusText(http.StatusInternalServerError)},
+		})
+	}
+}
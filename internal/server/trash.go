@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"url-shortener/internal/link"
+	"url-shortener/internal/utils"
+)
+
+func HandleTrashLink(ctx context.Context, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.link.HandleTrashLink"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trashLinkArgs := link.TrashLinkParams{
+			UserID: userIDFromContext(r.Context()),
+			LinkID: r.PathValue("id"),
+		}
+
+		trashedLink, err := linkService.TrashLink(ctx, trashLinkArgs)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't trash link", "error", err)
+			respondWithEditError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": newLinkResponse(trashedLink),
+		})
+	})
+}
+
+func HandleRestoreLink(ctx context.Context, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.link.HandleRestoreLink"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		restoreLinkArgs := link.RestoreLinkParams{
+			UserID: userIDFromContext(r.Context()),
+			LinkID: r.PathValue("id"),
+		}
+
+		restoredLink, err := linkService.RestoreLink(ctx, restoreLinkArgs)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't restore link", "error", err)
+			respondWithEditError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": newLinkResponse(restoredLink),
+		})
+	})
+}
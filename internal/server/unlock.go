@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"time"
+	"url-shortener/internal/link"
+	"url-shortener/internal/token"
+)
+
+const (
+	unlockCookiePrefix = "link_unlock_"
+	unlockDuration     = 30 * time.Minute
+	// Guesses allowed per IP in each window, across all links
+	unlockAttempts = 10
+	unlockWindow   = 15 * time.Minute
+	maxUnlockBody  = 4 << 10
+)
+
+//go:embed templates/unlock.html
+var unlockPageHTML string
+
+var unlockPage = template.Must(template.New("unlock").Parse(unlockPageHTML))
+
+type unlockPageData struct {
+	Error string
+}
+
+func renderUnlockPage(w http.ResponseWriter, status int, data unlockPageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := unlockPage.Execute(w, data); err != nil {
+		slog.Error("handler.link.renderUnlockPage", "error", err)
+	}
+}
+
+func isUnlocked(r *http.Request, tokenMaker token.Maker, linkID string) bool {
+	cookie, err := r.Cookie(unlockCookiePrefix + linkID)
+	if err != nil {
+		return false
+	}
+
+	// The token's own expiry is enforced while it's verified
+	claims, err := tokenMaker.VerifyToken(cookie.Value)
+	if err != nil {
+		return false
+	}
+
+	return claims.Purpose == token.PurposeUnlockLink && claims.UserID == linkID
+}
+
+// HandleUnlockLink checks the password posted from the unlock page. On
+// success the visitor gets a cookie for the link and is sent back to the
+// short URL, query string and all, which now redirects.
+func HandleUnlockLink(ctx context.Context, linkService *link.LinkService, tokenMaker token.Maker, limiter *rateLimiter, clientIPHeader string) http.Handler {
+	handlerID := "handler.link.HandleUnlockLink"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code := r.PathValue("code")
+
+		resolvedLink, err := linkService.ResolveLink(ctx, requestHostname(r), code)
+
+		if err != nil {
+			switch err {
+			case link.ErrLinkNotFound:
+				http.NotFound(w, r)
+			case link.ErrLinkExpired:
+				serveExpiredLink(w, r, resolvedLink)
+			case link.ErrLinkNotActive:
+				serveInactiveLink(w, r, resolvedLink)
+			default:
+				slog.Error(handlerID, "message", "couldn't resolve link", "code", code, "error", err)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		if !resolvedLink.IsProtected() {
+			http.Redirect(w, r, r.URL.RequestURI(), http.StatusSeeOther)
+			return
+		}
+
+		ip := clientIP(r, clientIPHeader)
+		now := time.Now()
+
+		if !limiter.Allow(ip, now) {
+			slog.Warn(handlerID, "message", "too many unlock attempts", "ip", ip, "link", resolvedLink.ID)
+			retryAfter := limiter.RetryAfter(ip, now)
+			w.Header().Set("Retry-After", fmt.Sprintf("%d", int(retryAfter.Seconds())+1))
+			renderUnlockPage(w, http.StatusTooManyRequests, unlockPageData{Error: "Too many attempts. Try again later."})
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxUnlockBody)
+		if err := r.ParseForm(); err != nil {
+			renderUnlockPage(w, http.StatusBadRequest, unlockPageData{Error: "Something went wrong. Please try again."})
+			return
+		}
+
+		if !linkService.VerifyLinkPassword(resolvedLink, r.PostFormValue("password")) {
+			renderUnlockPage(w, http.StatusUnauthorized, unlockPageData{Error: "Incorrect password."})
+			return
+		}
+
+		unlockToken, _, err := tokenMaker.CreateScopedToken(resolvedLink.ID, token.PurposeUnlockLink, unlockDuration)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't create unlock token", "error", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     unlockCookiePrefix + resolvedLink.ID,
+			Value:    unlockToken,
+			Path:     "/",
+			MaxAge:   int(unlockDuration.Seconds()),
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		http.Redirect(w, r, r.URL.RequestURI(), http.StatusSeeOther)
+	})
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"url-shortener/internal/link"
+	"url-shortener/internal/utils"
+	"url-shortener/internal/validation"
+)
+
+func HandleListLinkVariants(ctx context.Context, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.link.HandleListLinkVariants"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		variants, err := linkService.ListLinkVariants(ctx, link.ListLinkVariantsParams{
+			UserID: userIDFromContext(r.Context()),
+			LinkID: r.PathValue("id"),
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't list link variants", "error", err)
+			respondWithVariantError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": variants,
+		})
+	})
+}
+
+// HandleAddLinkVariant adds a destination to a link's A/B test. Once a link
+// has variants with weight, visitors no targeting rule matches are split
+// between them instead of going to the link's own destination.
+func HandleAddLinkVariant(ctx context.Context, validator validation.Validator, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.link.HandleAddLinkVariant"
+
+	type request struct {
+		URL    string `json:"url" validate:"required,url,max=2048"`
+		Weight *int   `json:"weight" validate:"required,min=0,max=1000"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := utils.DecodeToJSON[request](r)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{http.StatusText(http.StatusBadRequest)},
+			})
+			return
+		}
+
+		if errs := validator.Validate(req); errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		variant, err := linkService.AddLinkVariant(ctx, link.AddLinkVariantParams{
+			UserID:         userIDFromContext(r.Context()),
+			LinkID:         r.PathValue("id"),
+			DestinationURL: req.URL,
+			Weight:         *req.Weight,
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't add link variant", "error", err)
+			respondWithVariantError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusCreated, map[string]any{
+			"data": variant,
+		})
+	})
+}
+
+func HandleUpdateLinkVariant(ctx context.Context, validator validation.Validator, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.link.HandleUpdateLinkVariant"
+
+	type request struct {
+		Weight *int `json:"weight" validate:"required,min=0,max=1000"`
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := utils.DecodeToJSON[request](r)
+
+		if err != nil {
+			slog.Error(handlerID, "message", "Invalid Request Payload", "error", err)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": []string{http.StatusText(http.StatusBadRequest)},
+			})
+			return
+		}
+
+		if errs := validator.Validate(req); errs != nil {
+			slog.Error(handlerID, "message", "couldn't validate request", "errors", errs)
+			utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+				"errors": errs,
+			})
+			return
+		}
+
+		variant, err := linkService.SetVariantWeight(ctx, link.SetVariantWeightParams{
+			UserID:    userIDFromContext(r.Context()),
+			LinkID:    r.PathValue("id"),
+			VariantID: r.PathValue("variant_id"),
+			Weight:    *req.Weight,
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't update link variant", "error", err)
+			respondWithVariantError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": variant,
+		})
+	})
+}
+
+func HandleDeleteLinkVariant(ctx context.Context, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.link.HandleDeleteLinkVariant"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := linkService.DeleteLinkVariant(ctx, link.DeleteLinkVariantParams{
+			UserID:    userIDFromContext(r.Context()),
+			LinkID:    r.PathValue("id"),
+			VariantID: r.PathValue("variant_id"),
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't delete link variant", "error", err)
+			respondWithVariantError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// HandleDeclareVariantWinner ends a link's A/B test, making the variant the
+// link's destination.
+func HandleDeclareVariantWinner(ctx context.Context, linkService *link.LinkService) http.Handler {
+	handlerID := "handler.link.HandleDeclareVariantWinner"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		updatedLink, err := linkService.DeclareWinner(ctx, link.DeclareWinnerParams{
+			UserID:    userIDFromContext(r.Context()),
+			LinkID:    r.PathValue("id"),
+			VariantID: r.PathValue("variant_id"),
+		})
+
+		if err != nil {
+			slog.Error(handlerID, "message", "couldn't declare variant winner", "error", err)
+			respondWithVariantError(w, err)
+			return
+		}
+
+		utils.RespondWithJSON(w, http.StatusOK, map[string]any{
+			"data": newLinkResponse(updatedLink),
+		})
+	})
+}
+
+func respondWithVariantError(w http.ResponseWriter, err error) {
+	switch err {
+	case link.ErrInvalidURL, link.ErrInvalidVariantWeight, link.ErrTooManyVariants:
+		utils.RespondWithJSON(w, http.StatusBadRequest, map[string]any{
+			"errors": []string{err.Error()},
+		})
+	case link.ErrLinkNotFound, link.ErrVariantNotFound:
+		utils.RespondWithJSON(w, http.StatusNotFound, map[string]any{
+			"errors": []string{err.Error()},
+		})
+	default:
+		utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]any{
+			"errors": []string{http.StatusText(http.StatusInternalServerError)},
+		})
+	}
+}
@@ -9,8 +9,17 @@ var (
 	ErrExpiredToken = errors.New("token has expired")
 )
 
+// Purposes of scoped tokens, which grant a single thing instead of access
+// to a user's account.
+const (
+	PurposeUnlockLink     = "unlock_link"
+	PurposeDownloadExport = "download_export"
+)
+
 type Claims struct {
-	UserID    string    `json:"user_id"`
+	UserID string `json:"user_id"`
+	// Empty on access tokens
+	Purpose   string    `json:"purpose"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiresAt time.Time `json:"expires_at"`
 }
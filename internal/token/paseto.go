@@ -30,11 +30,22 @@ func NewPasetoMaker(symmetricKey string) (Maker, error) {
 }
 
 func (maker *PasetoMaker) CreateToken(userID string, duration time.Duration) (string, *Claims, error) {
-	claims := NewClaims(userID, duration)
+	return maker.createToken(NewClaims(userID, duration))
+}
+
+func (maker *PasetoMaker) CreateScopedToken(subject string, purpose string, duration time.Duration) (string, *Claims, error) {
+	claims := NewClaims(subject, duration)
+	claims.Purpose = purpose
+	return maker.createToken(claims)
+}
 
+func (maker *PasetoMaker) createToken(claims *Claims) (string, *Claims, error) {
 	token := paseto.NewToken()
 
 	token.Set("user_id", claims.UserID)
+	if claims.Purpose != "" {
+		token.Set("purpose", claims.Purpose)
+	}
 	token.SetSubject(claims.UserID)
 	token.SetExpiration(claims.ExpiresAt)
 	token.SetIssuedAt(claims.IssuedAt)
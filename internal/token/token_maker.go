@@ -7,6 +7,9 @@ import (
 type Maker interface {
 	// CreateToken creates a new token for a specific user id and duration
 	CreateToken(userID string, duration time.Duration) (string, *Claims, error)
+	// CreateScopedToken creates a token for subject that's only good for
+	// purpose, and never as an access token
+	CreateScopedToken(subject string, purpose string, duration time.Duration) (string, *Claims, error)
 	// VerifyToken checks if the token is valid or not
 	VerifyToken(token string) (*Claims, error)
 }
@@ -1,6 +1,10 @@
 package utils
 
-import "github.com/mattn/go-sqlite3"
+import (
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
 
 func IsConflictError(err error) bool {
 	if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
@@ -8,3 +12,10 @@ func IsConflictError(err error) bool {
 	}
 	return false
 }
+
+// IsIndexConflictError reports whether err is a unique violation of the
+// index named index. SQLite only names indexes on expressions, others are
+// reported by their columns.
+func IsIndexConflictError(err error, index string) bool {
+	return IsConflictError(err) && strings.HasSuffix(err.Error(), "index '"+index+"'")
+}
@@ -12,6 +12,8 @@ var (
 
 const tokenChars = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // Removed 0, O, 1, I
 
+const Base62Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
 func GenerateAlphanum(length int) (string, error) {
 	tokenLength := 8
 	if length != 0 {
@@ -34,3 +36,53 @@ func GenerateAlphanum(length int) (string, error) {
 
 	return result.String(), nil
 }
+
+// GenerateBase62 returns a random string drawn from [0-9A-Za-z].
+// Bytes that would skew the distribution towards the start of the alphabet
+// are discarded rather than wrapped with a modulo.
+func GenerateBase62(length int) (string, error) {
+	tokenLength := 8
+	if length != 0 {
+		tokenLength = length
+	}
+
+	// Largest multiple of 62 that fits in a byte
+	const maxByte = 256 - (256 % len(Base62Chars))
+
+	var result strings.Builder
+	buf := make([]byte, tokenLength)
+
+	for result.Len() < tokenLength {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) >= maxByte {
+				continue
+			}
+			result.WriteByte(Base62Chars[int(b)%len(Base62Chars)])
+			if result.Len() == tokenLength {
+				break
+			}
+		}
+	}
+
+	return result.String(), nil
+}
+
+// EncodeBase62 encodes n with the base62 alphabet, left padding the result
+// with zeros up to length.
+func EncodeBase62(n uint64, length int) string {
+	var encoded []byte
+	for n > 0 {
+		encoded = append(encoded, Base62Chars[n%62])
+		n /= 62
+	}
+	for len(encoded) < length {
+		encoded = append(encoded, Base62Chars[0])
+	}
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+	return string(encoded)
+}
@@ -0,0 +1,2342 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	db "url-shortener/db/sqlc"
+	"url-shortener/internal/analytics"
+	"url-shortener/internal/config"
+	"url-shortener/internal/link"
+	"url-shortener/internal/token"
+	"url-shortener/tests"
+)
+
+func TestShortLinks(t *testing.T) {
+	ctx := context.Background()
+	ctx, cancel := context.WithCancel(ctx)
+
+	t.Cleanup(cancel)
+
+	cfg := tests.BuildTestConfig()
+	cfg.Server.Port = 8101
+	cfg.Server.Address = fmt.Sprintf("0.0.0.0:%d", cfg.Server.Port)
+	cfg.Server.BaseURL = fmt.Sprintf("http://127.0.0.1:%d", cfg.Server.Port)
+	// Destination pages are served by httptest on loopback
+	cfg.Metadata.PollInterval = 20 * time.Millisecond
+	cfg.Metadata.FetchTimeout = time.Second
+	cfg.Metadata.AllowPrivateIPs = true
+	// Visitors are placed by the address in X-Forwarded-For
+	cfg.Server.ClientIPHeader = "X-Forwarded-For"
+	geoIPPath := filepath.Join(t.TempDir(), "geoip.mmdb")
+	err := tests.WriteGeoIPDatabase(geoIPPath, map[string]tests.GeoIPLocation{
+		"198.51.100.0/24": {Country: "DE", City: "Berlin"},
+		"203.0.113.0/24":  {Country: "US", Region: "CA", City: "San Francisco"},
+	})
+	if err != nil {
+		t.Fatalf("couldn't write geoip database: %v", err)
+	}
+	cfg.GeoIP.DatabasePath = geoIPPath
+	cfg.GeoIP.ReloadInterval = 20 * time.Millisecond
+	cfg.Export.Directory = t.TempDir()
+	// Branded domains are verified against records the tests publish
+	resolver := tests.NewResolver()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- run(ctx, cfg, resolver)
+	}()
+
+	timeout := 5 * time.Second
+	err = tests.WaitForReady(ctx, timeout, fmt.Sprintf("http://127.0.0.1:%d/health", cfg.Server.Port), serverErr)
+
+	if err != nil {
+		log.Fatalf("couldn't start the server in %fs: %v", timeout.Seconds(), err)
+	}
+
+	accessToken := signupAndIssueToken(t, ctx, cfg.Server.TokenSymmetricKey, tests.BuildRequestUrl(cfg.Server, "/api/auth/signup"))
+
+	t.Run("it should return 400 for invalid destinations", func(t *testing.T) {
+		cases := []string{
+			"{}",
+			"{\"url\": \"not a url\"}",
+			"{\"url\": \"ftp://example.com/file\"}",
+			"{\"url\": \"https://example.com\", \"redirect_type\": 303}",
+		}
+		addr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+
+		for _, body := range cases {
+			resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPost, addr, accessToken, bytes.NewReader([]byte(body)))
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("%s: want: %d, got: %d", body, http.StatusBadRequest, resp.StatusCode)
+			}
+		}
+	})
+
+	t.Run("it should redirect short codes to their destination", func(t *testing.T) {
+		addr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+		body := bytes.NewReader([]byte("{\"url\": \"HTTPS://Example.com:443\", \"redirect_type\": 301}"))
+
+		resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPost, addr, accessToken, body)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("want: %d, got: %d", http.StatusCreated, resp.StatusCode)
+		}
+
+		var created struct {
+			Data struct {
+				OriginalURL string `json:"original_url"`
+				ShortURLID  string `json:"short_url_id"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+			t.Fatalf("couldn't decode response: %v", err)
+		}
+		if created.Data.OriginalURL != "https://example.com/" {
+			t.Fatalf("want: %s, got: %s", "https://example.com/", created.Data.OriginalURL)
+		}
+
+		redirect, err := tests.DoRequestWithoutRedirect(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/"+created.Data.ShortURLID))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		defer redirect.Body.Close()
+		if redirect.StatusCode != http.StatusMovedPermanently {
+			t.Fatalf("want: %d, got: %d", http.StatusMovedPermanently, redirect.StatusCode)
+		}
+		if location := redirect.Header.Get("Location"); location != "https://example.com/" {
+			t.Fatalf("want: %s, got: %s", "https://example.com/", location)
+		}
+	})
+
+	t.Run("it should answer with each link's redirect type", func(t *testing.T) {
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+
+		cases := []struct {
+			body string
+			want int
+		}{
+			{"{\"url\": \"https://example.com/found\"}", http.StatusFound},
+			{"{\"url\": \"https://example.com/found\", \"redirect_type\": 302}", http.StatusFound},
+			{"{\"url\": \"https://example.com/temporary\", \"redirect_type\": 307}", http.StatusTemporaryRedirect},
+			{"{\"url\": \"https://example.com/permanent\", \"redirect_type\": 308}", http.StatusPermanentRedirect},
+		}
+
+		for _, c := range cases {
+			created := createLink(t, ctx, linksAddr, accessToken, c.body)
+
+			// Visitors don't have an account
+			resp, err := tests.DoRequestWithoutRedirect(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/"+created.ShortURLID))
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != c.want {
+				t.Errorf("%s: want: %d, got: %d", c.body, c.want, resp.StatusCode)
+			}
+			if location := resp.Header.Get("Location"); location != created.OriginalURL {
+				t.Errorf("%s: want: %s, got: %s", c.body, created.OriginalURL, location)
+			}
+		}
+	})
+
+	t.Run("it should give the same destination the same hash code", func(t *testing.T) {
+		// The server hands out random codes, so this runs against its own
+		// database
+		sqliteDB, err := initDB(config.Database{Uri: ":memory:"})
+		if err != nil {
+			t.Fatalf("couldn't open database: %v", err)
+		}
+		defer sqliteDB.Close()
+		if err := runMigration(sqliteDB); err != nil {
+			t.Fatalf("couldn't migrate database: %v", err)
+		}
+		generator, err := link.NewCodeGenerator(link.StrategyHash, 0, "")
+		if err != nil {
+			t.Fatalf("couldn't create generator: %v", err)
+		}
+		linkService := link.NewLinkService(db.NewStore(sqliteDB), generator, nil, 0)
+
+		shorten := func(userID string, params link.CreateLinkParams) link.Link {
+			t.Helper()
+			params.UserID = userID
+			created, err := linkService.CreateLink(ctx, params)
+			if err != nil {
+				t.Fatalf("couldn't create link: %v", err)
+			}
+			return created
+		}
+
+		first := shorten("alice", link.CreateLinkParams{OriginalURL: "https://hash.example.org/page"})
+		second := shorten("alice", link.CreateLinkParams{OriginalURL: "https://hash.example.org/page"})
+		if second.ShortUrlID != first.ShortUrlID || second.ID != first.ID {
+			t.Errorf("want: the same link %s, got: %s (%s)", first.ShortUrlID, second.ShortUrlID, second.ID)
+		}
+
+		// Other users and links that expire get links of their own
+		for _, other := range []link.Link{
+			shorten("bob", link.CreateLinkParams{OriginalURL: "https://hash.example.org/page"}),
+			shorten("alice", link.CreateLinkParams{OriginalURL: "https://hash.example.org/page", MaxClicks: 5}),
+		} {
+			if other.ID == first.ID || other.ShortUrlID == first.ShortUrlID {
+				t.Errorf("want: a link of its own, got: %s", other.ShortUrlID)
+			}
+		}
+	})
+
+	t.Run("it should let users claim aliases", func(t *testing.T) {
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+		prettyAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links/pretty")
+		first := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://example.com/summer\"}")
+		second := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://example.com/winter\"}")
+
+		cases := []struct {
+			body string
+			want int
+		}{
+			{fmt.Sprintf("{\"id\": \"%s\", \"pretty_id\": \"Summer-Sale\"}", first.ID), http.StatusOK},
+			{fmt.Sprintf("{\"id\": \"%s\", \"pretty_id\": \"summer-sale\"}", second.ID), http.StatusConflict},
+			{fmt.Sprintf("{\"id\": \"%s\", \"pretty_id\": \"api\"}", second.ID), http.StatusBadRequest},
+			{fmt.Sprintf("{\"id\": \"%s\", \"pretty_id\": \"no spaces\"}", second.ID), http.StatusBadRequest},
+			{fmt.Sprintf("{\"id\": \"%s\", \"pretty_id\": \"sh1t-happens\"}", second.ID), http.StatusBadRequest},
+			{fmt.Sprintf("{\"id\": \"%s\", \"pretty_id\": \"fuckup\"}", second.ID), http.StatusBadRequest},
+			// Blocked words inside innocent ones are fine
+			{fmt.Sprintf("{\"id\": \"%s\", \"pretty_id\": \"scunthorpe\"}", second.ID), http.StatusOK},
+			{fmt.Sprintf("{\"id\": \"%s\", \"pretty_id\": \"flame-retardant\"}", second.ID), http.StatusOK},
+		}
+
+		for _, c := range cases {
+			resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPost, prettyAddr, accessToken, bytes.NewReader([]byte(c.body)))
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != c.want {
+				t.Fatalf("%s: want: %d, got: %d", c.body, c.want, resp.StatusCode)
+			}
+		}
+
+		redirect, err := tests.DoRequestWithoutRedirect(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/SUMMER-sale"))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		defer redirect.Body.Close()
+		if location := redirect.Header.Get("Location"); location != first.OriginalURL {
+			t.Fatalf("want: %s, got: %s", first.OriginalURL, location)
+		}
+	})
+
+	t.Run("it should page through links with a cursor", func(t *testing.T) {
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+		for i := 0; i < 3; i++ {
+			createLink(t, ctx, linksAddr, accessToken, fmt.Sprintf("{\"url\": \"https://docs.paging.dev/%d\"}", i))
+		}
+
+		seen := map[string]bool{}
+		cursor := ""
+		pages := 0
+		for {
+			addr := linksAddr + "?domain=paging.dev&limit=2"
+			if cursor != "" {
+				addr += "&cursor=" + cursor
+			}
+			resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodGet, addr, accessToken, nil)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("want: %d, got: %d", http.StatusOK, resp.StatusCode)
+			}
+
+			var page struct {
+				Data       []testLink `json:"data"`
+				NextCursor string     `json:"next_cursor"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+				t.Fatalf("couldn't decode response: %v", err)
+			}
+			for _, l := range page.Data {
+				if seen[l.ID] {
+					t.Fatalf("link %s returned twice", l.ID)
+				}
+				seen[l.ID] = true
+			}
+
+			pages++
+			cursor = page.NextCursor
+			if cursor == "" {
+				break
+			}
+		}
+
+		if len(seen) != 3 || pages != 2 {
+			t.Fatalf("want: 3 links over 2 pages, got: %d links over %d pages", len(seen), pages)
+		}
+	})
+
+	t.Run("it should record clicks in the background", func(t *testing.T) {
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+		created := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://clicks.example.org\"}")
+
+		for i := 0; i < 3; i++ {
+			resp, err := tests.DoRequestWithoutRedirect(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/"+created.ShortURLID))
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			resp.Body.Close()
+		}
+
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodGet, linksAddr+"?domain=clicks.example.org", accessToken, nil)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			var page struct {
+				Data []struct {
+					ClickCount int64 `json:"click_count"`
+				} `json:"data"`
+			}
+			err = json.NewDecoder(resp.Body).Decode(&page)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatalf("couldn't decode response: %v", err)
+			}
+			if len(page.Data) == 1 && page.Data[0].ClickCount == 3 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("want: 3 clicks, got: %+v", page.Data)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	})
+
+	t.Run("it should keep visitor hashes across restarts", func(t *testing.T) {
+		sqliteDB, err := initDB(config.Database{Uri: ":memory:"})
+		if err != nil {
+			t.Fatalf("couldn't open database: %v", err)
+		}
+		defer sqliteDB.Close()
+		if err := runMigration(sqliteDB); err != nil {
+			t.Fatalf("couldn't migrate database: %v", err)
+		}
+		queries := db.New(sqliteDB)
+
+		now := time.Now()
+		// A new hasher is what a restarted server starts with
+		before, err := analytics.NewVisitorHasher().Hash(ctx, queries, "198.51.100.7", now)
+		if err != nil {
+			t.Fatalf("couldn't hash: %v", err)
+		}
+		after, err := analytics.NewVisitorHasher().Hash(ctx, queries, "198.51.100.7", now)
+		if err != nil {
+			t.Fatalf("couldn't hash: %v", err)
+		}
+		if before != after {
+			t.Errorf("want: the same hash after a restart, got: %s and %s", before, after)
+		}
+
+		tomorrow, err := analytics.NewVisitorHasher().Hash(ctx, queries, "198.51.100.7", now.Add(24*time.Hour))
+		if err != nil {
+			t.Fatalf("couldn't hash: %v", err)
+		}
+		if tomorrow == before {
+			t.Errorf("want: a new hash the next day, got: %s", tomorrow)
+		}
+	})
+
+	t.Run("it should serve stats from the rollups", func(t *testing.T) {
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+		created := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://stats.example.org\"}")
+		statsAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/"+created.ID+"/stats")
+
+		for i := 0; i < 2; i++ {
+			resp, err := tests.DoRequestWithoutRedirect(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/"+created.ShortURLID))
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			resp.Body.Close()
+		}
+
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodGet, statsAddr+"?interval=hour&tz=Africa/Lagos&from="+time.Now().Add(-2*time.Hour).UTC().Format(time.RFC3339), accessToken, nil)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			var stats struct {
+				Data struct {
+					TotalClicks         int64 `json:"total_clicks"`
+					DailyUniqueVisitors int64 `json:"daily_unique_visitors"`
+					Series              []struct {
+						Clicks int64 `json:"clicks"`
+					} `json:"series"`
+				} `json:"data"`
+			}
+			err = json.NewDecoder(resp.Body).Decode(&stats)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatalf("couldn't decode response: %v", err)
+			}
+			if stats.Data.TotalClicks == 2 {
+				if stats.Data.DailyUniqueVisitors != 1 {
+					t.Errorf("want: 1 unique visitor, got: %d", stats.Data.DailyUniqueVisitors)
+				}
+				if len(stats.Data.Series) < 2 || len(stats.Data.Series) > 3 {
+					t.Errorf("want: 2-3 hourly buckets, got: %d", len(stats.Data.Series))
+				}
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("want: 2 clicks, got: %+v", stats.Data)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodGet, statsAddr+"?tz=Mars/Olympus", accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("want: %d for an unknown time zone, got: %d", http.StatusBadRequest, resp.StatusCode)
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/api/links/missing/stats"), accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("want: %d for an unknown link, got: %d", http.StatusNotFound, resp.StatusCode)
+		}
+	})
+
+	t.Run("it should stop redirecting expired links", func(t *testing.T) {
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+
+		resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPost, linksAddr, accessToken, bytes.NewReader([]byte("{\"url\": \"https://expired.example.org\", \"expires_at\": \"2001-01-01T00:00:00Z\"}")))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("want: %d for an expiry in the past, got: %d", http.StatusBadRequest, resp.StatusCode)
+		}
+
+		gone := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://budget.example.org\", \"max_clicks\": 1}")
+		fallback := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://budget.example.org/fallback\", \"max_clicks\": 1, \"fallback_url\": \"https://example.org/sorry\"}")
+
+		for _, l := range []testLink{gone, fallback} {
+			resp, err := tests.DoRequestWithoutRedirect(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/"+l.ShortURLID))
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			resp.Body.Close()
+			if got := resp.Header.Get("Location"); got != l.OriginalURL {
+				t.Fatalf("want: first click to reach %q, got: %q", l.OriginalURL, got)
+			}
+		}
+
+		// The click budget is spent by the redirect itself
+		resp, err = tests.DoRequestWithoutRedirect(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/"+gone.ShortURLID))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusGone {
+			t.Fatalf("want: %d, got: %d", http.StatusGone, resp.StatusCode)
+		}
+
+		resp, err = tests.DoRequestWithoutRedirect(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/"+fallback.ShortURLID))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if got := resp.Header.Get("Location"); got != "https://example.org/sorry" {
+			t.Errorf("want: expired link to redirect to its fallback, got: %q", got)
+		}
+
+		// Visitors arriving at once can't overshoot the budget
+		rush := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://rush.example.org\", \"max_clicks\": 3}")
+		followed := make(chan bool, 10)
+		var wg sync.WaitGroup
+		for i := 0; i < cap(followed); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := tests.DoRequestWithoutRedirect(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/"+rush.ShortURLID))
+				if err != nil {
+					t.Errorf("failed: %v", err)
+					return
+				}
+				resp.Body.Close()
+				followed <- resp.Header.Get("Location") == rush.OriginalURL
+			}()
+		}
+		wg.Wait()
+		close(followed)
+		redirects := 0
+		for ok := range followed {
+			if ok {
+				redirects++
+			}
+		}
+		if redirects != 3 {
+			t.Errorf("want: 3 visitors redirected, got: %d", redirects)
+		}
+
+		// Clicks that spent the budget aren't counted again when written
+		statsAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/"+rush.ID+"/stats")
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodGet, statsAddr, accessToken, nil)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			var stats struct {
+				Data struct {
+					TotalClicks int64 `json:"total_clicks"`
+				} `json:"data"`
+			}
+			err = json.NewDecoder(resp.Body).Decode(&stats)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatalf("couldn't decode response: %v", err)
+			}
+			if stats.Data.TotalClicks == 3 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("want: 3 clicks written, got: %d", stats.Data.TotalClicks)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodGet, linksAddr+"?domain=rush.example.org", accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		var page struct {
+			Data []struct {
+				ClickCount int64 `json:"click_count"`
+			} `json:"data"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("couldn't decode response: %v", err)
+		}
+		if len(page.Data) != 1 || page.Data[0].ClickCount != 3 {
+			t.Errorf("want: a click count of 3, got: %+v", page.Data)
+		}
+	})
+
+	t.Run("it should ask for the password of protected links", func(t *testing.T) {
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+		created := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://protected.example.org\"}")
+
+		body := fmt.Sprintf("{\"id\": %q, \"password\": \"open sesame\"}", created.ID)
+		resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPost, linksAddr+"/password", accessToken, bytes.NewReader([]byte(body)))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("want: %d, got: %d", http.StatusOK, resp.StatusCode)
+		}
+
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		visitor := http.Client{
+			Jar: jar,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+		shortAddr := tests.BuildRequestUrl(cfg.Server, "/"+created.ShortURLID)
+
+		resp, err = visitor.Get(shortAddr)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+			t.Fatalf("want: unlock page, got: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+
+		resp, err = visitor.PostForm(shortAddr, url.Values{"password": {"wrong"}})
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("want: %d for a wrong password, got: %d", http.StatusUnauthorized, resp.StatusCode)
+		}
+
+		resp, err = visitor.PostForm(shortAddr, url.Values{"password": {"open sesame"}})
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusSeeOther {
+			t.Fatalf("want: %d for the right password, got: %d", http.StatusSeeOther, resp.StatusCode)
+		}
+
+		resp, err = visitor.Get(shortAddr)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if got := resp.Header.Get("Location"); got != created.OriginalURL {
+			t.Errorf("want: unlocked link to redirect to %q, got: %d %q", created.OriginalURL, resp.StatusCode, got)
+		}
+
+		// The unlock cookie is no access token
+		parsedAddr, err := url.Parse(shortAddr)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		var unlockToken string
+		for _, cookie := range jar.Cookies(parsedAddr) {
+			if strings.HasPrefix(cookie.Name, "link_unlock_") {
+				unlockToken = cookie.Value
+			}
+		}
+		if unlockToken == "" {
+			t.Fatalf("want: an unlock cookie, got none")
+		}
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodGet, linksAddr, unlockToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("want: %d for the unlock token as an access token, got: %d", http.StatusUnauthorized, resp.StatusCode)
+		}
+	})
+
+	t.Run("it should keep a revision history of edits", func(t *testing.T) {
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+		created := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://history.example.org/v1\"}")
+		linkAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/"+created.ID)
+
+		resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPatch, linkAddr, accessToken, bytes.NewReader([]byte("{\"url\": \"https://history.example.org/v2\", \"redirect_type\": 301}")))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		var updated struct {
+			Data testLink `json:"data"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&updated)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("couldn't decode response: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK || updated.Data.OriginalURL != "https://history.example.org/v2" {
+			t.Fatalf("want: updated destination, got: %d %+v", resp.StatusCode, updated.Data)
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodGet, linkAddr+"/revisions", accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		var revisions struct {
+			Data []struct {
+				ID      int64    `json:"id"`
+				Action  string   `json:"action"`
+				Changed []string `json:"changed"`
+			} `json:"data"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&revisions)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("couldn't decode response: %v", err)
+		}
+		if len(revisions.Data) != 2 || revisions.Data[0].Action != "update" || revisions.Data[1].Action != "create" {
+			t.Fatalf("want: update and create revisions, got: %+v", revisions.Data)
+		}
+		if len(revisions.Data[0].Changed) != 2 {
+			t.Errorf("want: 2 changed fields, got: %v", revisions.Data[0].Changed)
+		}
+
+		rollbackAddr := fmt.Sprintf("%s/revisions/%d/rollback", linkAddr, revisions.Data[1].ID)
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodPost, rollbackAddr, accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("want: %d, got: %d", http.StatusOK, resp.StatusCode)
+		}
+
+		redirect, err := tests.DoRequestWithoutRedirect(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/"+created.ShortURLID))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		redirect.Body.Close()
+		if redirect.StatusCode != http.StatusFound || redirect.Header.Get("Location") != created.OriginalURL {
+			t.Errorf("want: rolled back link to redirect to %q, got: %d %q", created.OriginalURL, redirect.StatusCode, redirect.Header.Get("Location"))
+		}
+	})
+
+	t.Run("it should move deleted links to the trash", func(t *testing.T) {
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+		created := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://trash.example.org\"}")
+		linkAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/"+created.ID)
+		shortAddr := tests.BuildRequestUrl(cfg.Server, "/"+created.ShortURLID)
+
+		countLinks := func(query string) int {
+			resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodGet, linksAddr+"?domain=trash.example.org"+query, accessToken, nil)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			defer resp.Body.Close()
+			var page struct {
+				Data []testLink `json:"data"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+				t.Fatalf("couldn't decode response: %v", err)
+			}
+			return len(page.Data)
+		}
+
+		resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodDelete, linkAddr, accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("want: %d, got: %d", http.StatusOK, resp.StatusCode)
+		}
+
+		redirect, err := tests.DoRequestWithoutRedirect(ctx, http.MethodGet, shortAddr)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		redirect.Body.Close()
+		if redirect.Header.Get("Location") != "" {
+			t.Errorf("want: trashed link not to redirect, got: %d %q", redirect.StatusCode, redirect.Header.Get("Location"))
+		}
+
+		if got := countLinks(""); got != 0 {
+			t.Errorf("want: trashed link hidden from listing, got: %d links", got)
+		}
+		if got := countLinks("&status=trashed"); got != 1 {
+			t.Errorf("want: trashed link in the trash, got: %d links", got)
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodPatch, linkAddr, accessToken, strings.NewReader("{\"url\": \"https://trash.example.org/edited\"}"))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("want: %d editing a trashed link, got: %d", http.StatusNotFound, resp.StatusCode)
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodPost, linkAddr+"/restore", accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("want: %d, got: %d", http.StatusOK, resp.StatusCode)
+		}
+
+		redirect, err = tests.DoRequestWithoutRedirect(ctx, http.MethodGet, shortAddr)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		redirect.Body.Close()
+		if redirect.Header.Get("Location") != created.OriginalURL {
+			t.Errorf("want: restored link to redirect, got: %d %q", redirect.StatusCode, redirect.Header.Get("Location"))
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodPost, linkAddr+"/restore", accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusConflict {
+			t.Errorf("want: %d restoring a live link, got: %d", http.StatusConflict, resp.StatusCode)
+		}
+	})
+
+	t.Run("it should render qr codes", func(t *testing.T) {
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+		created := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://qr.example.org\"}")
+		qrAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/"+created.ID+"/qr?size=128&fg=%23112233")
+
+		resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodGet, qrAddr, accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		img, err := png.Decode(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("want: png, got: %v", err)
+		}
+		if img.Bounds().Dx() != 128 {
+			t.Errorf("want: 128px wide, got: %d", img.Bounds().Dx())
+		}
+
+		etag := resp.Header.Get("ETag")
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, qrAddr, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		req.Header.Set("If-None-Match", etag)
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if etag == "" || resp.StatusCode != http.StatusNotModified {
+			t.Errorf("want: %d for a matching etag, got: %d", http.StatusNotModified, resp.StatusCode)
+		}
+
+		resp, err = tests.DoRequestWithoutRedirect(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/"+created.ShortURLID+".qr?format=svg"))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "image/svg+xml" {
+			t.Errorf("want: public svg, got: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+
+		resp, err = tests.DoRequestWithoutRedirect(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/"+created.ShortURLID+".qr?size=5"))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("want: %d for a tiny size, got: %d", http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+
+	t.Run("it should create links in bulk", func(t *testing.T) {
+		bulkAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/bulk")
+		alias := fmt.Sprintf("bulk-%d", time.Now().UnixNano())
+		body := fmt.Sprintf("[{\"url\": \"https://bulk.example.org/1\", \"pretty_id\": %q}, {\"url\": \"not a url\"}, {\"url\": \"https://bulk.example.org/2\", \"pretty_id\": %q}]", alias, alias)
+
+		resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPost, bulkAddr, accessToken, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		var result struct {
+			Data []struct {
+				Index  int       `json:"index"`
+				Data   *testLink `json:"data"`
+				Errors []struct {
+					Field string `json:"field"`
+				} `json:"errors"`
+			} `json:"data"`
+			Created int `json:"created"`
+			Failed  int `json:"failed"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("couldn't decode response: %v", err)
+		}
+		if resp.StatusCode != http.StatusMultiStatus || result.Created != 1 || result.Failed != 2 {
+			t.Fatalf("want: 1 created and 2 failed, got: %d %+v", resp.StatusCode, result)
+		}
+		if result.Data[0].Data == nil || result.Data[0].Data.OriginalURL != "https://bulk.example.org/1" {
+			t.Errorf("want: first item created, got: %+v", result.Data[0])
+		}
+		if len(result.Data[1].Errors) == 0 || result.Data[1].Errors[0].Field != "url" {
+			t.Errorf("want: url error on second item, got: %+v", result.Data[1])
+		}
+		if len(result.Data[2].Errors) == 0 || result.Data[2].Errors[0].Field != "pretty_id" {
+			t.Errorf("want: pretty_id error on third item, got: %+v", result.Data[2])
+		}
+
+		csvBody := "url,redirect_type\nhttps://bulk.example.org/3,301\nhttps://bulk.example.org/4,\n"
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, bulkAddr, strings.NewReader(csvBody))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		req.Header.Set("Content-Type", "text/csv")
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Errorf("want: %d for a csv upload, got: %d", http.StatusCreated, resp.StatusCode)
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodPost, bulkAddr, accessToken, strings.NewReader("[]"))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("want: %d for an empty batch, got: %d", http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+
+	t.Run("it should import links from other shorteners", func(t *testing.T) {
+		importAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/import")
+		suffix := time.Now().UnixNano()
+		taken := fmt.Sprintf("taken-%d", suffix)
+		resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPost, tests.BuildRequestUrl(cfg.Server, "/api/links/bulk"), accessToken, strings.NewReader(fmt.Sprintf("[{\"url\": \"https://import.example.org\", \"pretty_id\": %q}]", taken)))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("want: %d, got: %d", http.StatusCreated, resp.StatusCode)
+		}
+
+		export := fmt.Sprintf("Bitlink,Long URL,Title\nbit.ly/kept-%d,https://import.example.org/1,One\nbit.ly/%s,https://import.example.org/2,Two\nbit.ly/x,not a url,Three\n", suffix, taken)
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodPost, importAddr, accessToken, strings.NewReader(export))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		var imported struct {
+			Data struct {
+				Imported  int `json:"imported"`
+				Preserved int `json:"preserved"`
+				Conflicts []struct {
+					Row int `json:"row"`
+				} `json:"conflicts"`
+				Failures []struct {
+					Row int `json:"row"`
+				} `json:"failures"`
+			} `json:"data"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&imported)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("couldn't decode response: %v", err)
+		}
+		report := imported.Data
+		if report.Imported != 2 || report.Preserved != 1 || len(report.Conflicts) != 1 || len(report.Failures) != 1 {
+			t.Fatalf("want: 2 imported, 1 preserved, 1 conflict, 1 failure, got: %+v", report)
+		}
+		if report.Conflicts[0].Row != 3 || report.Failures[0].Row != 4 {
+			t.Errorf("want: conflict on row 3 and failure on row 4, got: %+v", report)
+		}
+
+		resp, err = tests.DoRequestWithoutRedirect(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, fmt.Sprintf("/kept-%d", suffix)))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.Header.Get("Location") != "https://import.example.org/1" {
+			t.Errorf("want: back-half kept, got: %d %s", resp.StatusCode, resp.Header.Get("Location"))
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodPost, importAddr+"?async=true&format=tinyurl", accessToken, strings.NewReader("Long URL,Alias\nhttps://import.example.org/3,\n"))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		var queued struct {
+			Data struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&queued)
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("want: %d, got: %d %v", http.StatusAccepted, resp.StatusCode, err)
+		}
+
+		jobAddr := tests.BuildRequestUrl(cfg.Server, "/api/jobs/"+queued.Data.ID)
+		var finished struct {
+			Data struct {
+				Status   string `json:"status"`
+				Progress int64  `json:"progress"`
+				Result   struct {
+					Imported int `json:"imported"`
+				} `json:"result"`
+			} `json:"data"`
+		}
+		for attempt := 0; attempt < 40 && finished.Data.Status != "completed"; attempt++ {
+			time.Sleep(25 * time.Millisecond)
+			resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodGet, jobAddr, accessToken, nil)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			err = json.NewDecoder(resp.Body).Decode(&finished)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatalf("couldn't decode response: %v", err)
+			}
+		}
+		if finished.Data.Status != "completed" || finished.Data.Progress != 1 || finished.Data.Result.Imported != 1 {
+			t.Errorf("want: completed job with 1 import, got: %+v", finished.Data)
+		}
+	})
+
+	t.Run("it should export account data", func(t *testing.T) {
+		resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPost, tests.BuildRequestUrl(cfg.Server, "/api/user/export"), accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		var queued struct {
+			Data struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&queued)
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("want: %d, got: %d %v", http.StatusAccepted, resp.StatusCode, err)
+		}
+
+		jobAddr := tests.BuildRequestUrl(cfg.Server, "/api/jobs/"+queued.Data.ID)
+		var finished struct {
+			Data struct {
+				Status string `json:"status"`
+				Result struct {
+					DownloadURL string `json:"download_url"`
+				} `json:"result"`
+			} `json:"data"`
+		}
+		for attempt := 0; attempt < 40 && finished.Data.Status != "completed"; attempt++ {
+			time.Sleep(25 * time.Millisecond)
+			resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodGet, jobAddr, accessToken, nil)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			err = json.NewDecoder(resp.Body).Decode(&finished)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatalf("couldn't decode response: %v", err)
+			}
+		}
+		if finished.Data.Status != "completed" || finished.Data.Result.DownloadURL == "" {
+			t.Fatalf("want: completed job with a download url, got: %+v", finished.Data)
+		}
+
+		resp, err = tests.DoRequest(ctx, http.MethodGet, finished.Data.Result.DownloadURL, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		archive, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("want: %d, got: %d %v", http.StatusOK, resp.StatusCode, err)
+		}
+		files, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			t.Fatalf("want: zip, got: %v", err)
+		}
+		names := map[string]bool{}
+		for _, f := range files.File {
+			names[f.Name] = true
+		}
+		for _, name := range []string{"profile.json", "links.json", "links.csv", "revisions.json", "clicks.csv"} {
+			if !names[name] {
+				t.Errorf("want: %s in the archive, got: %v", name, names)
+			}
+		}
+
+		resp, err = tests.DoRequest(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/api/exports/"+queued.Data.ID+"?token=forged"), nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("want: %d for a forged token, got: %d", http.StatusForbidden, resp.StatusCode)
+		}
+
+		// The download token is no access token
+		downloadURL, err := url.Parse(finished.Data.Result.DownloadURL)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/api/links/links"), downloadURL.Query().Get("token"), nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("want: %d for the download token as an access token, got: %d", http.StatusUnauthorized, resp.StatusCode)
+		}
+	})
+
+	t.Run("it should organize links with tags and folders", func(t *testing.T) {
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+		first := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://organize.example.org/1\"}")
+		second := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://organize.example.org/2\"}")
+
+		post := func(addr string, body string, wantStatus int, out any) {
+			t.Helper()
+			resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPost, tests.BuildRequestUrl(cfg.Server, addr), accessToken, strings.NewReader(body))
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != wantStatus {
+				t.Fatalf("%s: want: %d, got: %d", addr, wantStatus, resp.StatusCode)
+			}
+			if out != nil {
+				if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+					t.Fatalf("couldn't decode response: %v", err)
+				}
+			}
+		}
+		list := func(query string) map[string]bool {
+			t.Helper()
+			resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodGet, linksAddr+"?"+query, accessToken, nil)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			defer resp.Body.Close()
+			var page struct {
+				Data []testLink `json:"data"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+				t.Fatalf("couldn't decode response: %v", err)
+			}
+			ids := map[string]bool{}
+			for _, l := range page.Data {
+				ids[l.ID] = true
+			}
+			return ids
+		}
+
+		post("/api/tags", "{\"name\": \"Launch\"}", http.StatusCreated, nil)
+		post("/api/tags", "{\"name\": \"launch\"}", http.StatusConflict, nil)
+
+		var tagged struct {
+			Data struct {
+				Updated  int      `json:"updated"`
+				NotFound []string `json:"not_found"`
+			} `json:"data"`
+		}
+		post("/api/links/tags", fmt.Sprintf("{\"link_ids\": [%q, %q, \"missing\"], \"add\": [\"launch\", \"q3\"]}", first.ID, second.ID), http.StatusOK, &tagged)
+		if tagged.Data.Updated != 2 || len(tagged.Data.NotFound) != 1 {
+			t.Errorf("want: 2 updated and 1 not found, got: %+v", tagged.Data)
+		}
+		post("/api/links/tags", fmt.Sprintf("{\"link_ids\": [%q], \"remove\": [\"q3\"]}", second.ID), http.StatusOK, nil)
+
+		if ids := list("tag=launch&tag=q3"); !ids[first.ID] || ids[second.ID] {
+			t.Errorf("want: only the first link tagged launch and q3, got: %v", ids)
+		}
+
+		var parent, child struct {
+			Data struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		post("/api/folders", "{\"name\": \"Campaigns\"}", http.StatusCreated, &parent)
+		post("/api/folders", fmt.Sprintf("{\"name\": \"Autumn\", \"parent_id\": %q}", parent.Data.ID), http.StatusCreated, &child)
+		post("/api/links/move", fmt.Sprintf("{\"link_ids\": [%q], \"folder_id\": %q}", first.ID, child.Data.ID), http.StatusOK, nil)
+
+		if ids := list("folder_id=" + child.Data.ID); !ids[first.ID] || ids[second.ID] {
+			t.Errorf("want: only the first link in the folder, got: %v", ids)
+		}
+
+		resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPatch, tests.BuildRequestUrl(cfg.Server, "/api/folders/"+parent.Data.ID), accessToken, strings.NewReader(fmt.Sprintf("{\"parent_id\": %q}", child.Data.ID)))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("want: %d for a folder cycle, got: %d", http.StatusBadRequest, resp.StatusCode)
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodDelete, tests.BuildRequestUrl(cfg.Server, "/api/folders/"+child.Data.ID), accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("want: %d, got: %d", http.StatusNoContent, resp.StatusCode)
+		}
+
+		if ids := list("folder_id=" + parent.Data.ID); !ids[first.ID] {
+			t.Errorf("want: the link moved up to the parent folder, got: %v", ids)
+		}
+	})
+
+	t.Run("it should search links", func(t *testing.T) {
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+		searchAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/search")
+		report := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://search.example.org/quarterly-report\"}")
+		createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://search.example.org/holiday-photos\"}")
+
+		resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPost, tests.BuildRequestUrl(cfg.Server, "/api/links/tags"), accessToken, strings.NewReader(fmt.Sprintf("{\"link_ids\": [%q], \"add\": [\"finance\"]}", report.ID)))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+
+		search := func(q string) []struct {
+			ID      string `json:"id"`
+			Snippet string `json:"snippet"`
+		} {
+			t.Helper()
+			resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodGet, searchAddr+"?q="+url.QueryEscape(q), accessToken, nil)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("want: %d, got: %d", http.StatusOK, resp.StatusCode)
+			}
+			var result struct {
+				Data []struct {
+					ID      string `json:"id"`
+					Snippet string `json:"snippet"`
+				} `json:"data"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatalf("couldn't decode response: %v", err)
+			}
+			return result.Data
+		}
+
+		results := search("quart")
+		if len(results) != 1 || results[0].ID != report.ID {
+			t.Fatalf("want: the report link for a prefix, got: %+v", results)
+		}
+		if !strings.Contains(results[0].Snippet, "<mark>quarterly</mark>") {
+			t.Errorf("want: highlighted snippet, got: %q", results[0].Snippet)
+		}
+
+		if results := search("finance search"); len(results) != 1 || results[0].ID != report.ID {
+			t.Errorf("want: the report link by tag, got: %+v", results)
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/api/tags"), accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		var tags struct {
+			Data []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"data"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&tags)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("couldn't decode response: %v", err)
+		}
+		for _, tag := range tags.Data {
+			if tag.Name != "finance" {
+				continue
+			}
+			resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodPatch, tests.BuildRequestUrl(cfg.Server, "/api/tags/"+tag.ID), accessToken, strings.NewReader("{\"name\": \"accounting\"}"))
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			resp.Body.Close()
+		}
+		if results := search("accounting"); len(results) != 1 || results[0].ID != report.ID {
+			t.Errorf("want: the report link by its renamed tag, got: %+v", results)
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodGet, searchAddr+"?q="+url.QueryEscape("\"*()"), accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("want: %d for a query without words, got: %d", http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+
+	t.Run("it should fetch destination page metadata", func(t *testing.T) {
+		page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, `<!doctype html><html><head>
+<title> Launch   notes &amp; more </title>
+<meta property="og:description" content="Everything that shipped this week">
+<meta property="og:image" content="/images/cover.png">
+<link rel="shortcut icon" href="/static/icon.png">
+</head><body><title>Not this one</title></body></html>`)
+		}))
+		defer page.Close()
+
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+		created := createLink(t, ctx, linksAddr, accessToken, fmt.Sprintf("{\"url\": %q}", page.URL+"/notes"))
+
+		deadline := time.Now().Add(3 * time.Second)
+		for {
+			resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodGet, linksAddr+"?limit=100", accessToken, nil)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			var links struct {
+				Data []struct {
+					ID          string `json:"id"`
+					Title       string `json:"title"`
+					Description string `json:"description"`
+					FaviconURL  string `json:"favicon_url"`
+					ImageURL    string `json:"image_url"`
+				} `json:"data"`
+			}
+			err = json.NewDecoder(resp.Body).Decode(&links)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatalf("couldn't decode response: %v", err)
+			}
+
+			found := false
+			for _, l := range links.Data {
+				if l.ID != created.ID || l.Title == "" {
+					continue
+				}
+				found = true
+				if l.Title != "Launch notes & more" || l.Description != "Everything that shipped this week" {
+					t.Errorf("want: title and description from the page, got: %+v", l)
+				}
+				if l.FaviconURL != page.URL+"/static/icon.png" || l.ImageURL != page.URL+"/images/cover.png" {
+					t.Errorf("want: absolute favicon and image urls, got: %+v", l)
+				}
+			}
+			if found {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("want: metadata for %s, got none", created.ID)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	})
+
+	t.Run("it should show crawlers the custom social preview", func(t *testing.T) {
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+		created := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://preview.example.org/launch\"}")
+		shortAddr := tests.BuildRequestUrl(cfg.Server, "/"+created.ShortURLID)
+
+		resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPut, tests.BuildRequestUrl(cfg.Server, "/api/links/"+created.ID+"/preview"), accessToken, strings.NewReader("{\"title\": \"Launch <day>\", \"image_url\": \"https://cdn.example.org/cover.png\"}"))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("want: %d, got: %d", http.StatusOK, resp.StatusCode)
+		}
+
+		client := http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+		visit := func(userAgent string) (*http.Response, string) {
+			t.Helper()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, shortAddr, nil)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			req.Header.Set("User-Agent", userAgent)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			return resp, string(body)
+		}
+
+		resp, body := visit("Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("want: %d for a crawler, got: %d", http.StatusOK, resp.StatusCode)
+		}
+		for _, tag := range []string{
+			`<meta property="og:title" content="Launch &lt;day&gt;">`,
+			`<meta property="og:image" content="https://cdn.example.org/cover.png">`,
+			`<meta name="twitter:card" content="summary_large_image">`,
+		} {
+			if !strings.Contains(body, tag) {
+				t.Errorf("want: %s in the preview, got: %s", tag, body)
+			}
+		}
+
+		resp, _ = visit("Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) AppleWebKit/605.1.15 Safari/605.1.15")
+		if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "https://preview.example.org/launch" {
+			t.Errorf("want: a redirect for visitors, got: %d %s", resp.StatusCode, resp.Header.Get("Location"))
+		}
+	})
+
+	t.Run("it should route visitors by device and os", func(t *testing.T) {
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+		created := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://app.example.org/get\"}")
+		rulesAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/"+created.ID+"/rules")
+		shortAddr := tests.BuildRequestUrl(cfg.Server, "/"+created.ShortURLID)
+
+		resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPut, rulesAddr, accessToken, strings.NewReader("{\"rules\": [{\"os\": [\"ios\"], \"url\": \"https://apps.apple.com/app/id1\"}, {\"os\": [\"android\"], \"devices\": [\"mobile\", \"tablet\"], \"url\": \"https://play.google.com/store/apps/details?id=org.example\"}]}"))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("want: %d, got: %d", http.StatusOK, resp.StatusCode)
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodPut, rulesAddr, accessToken, strings.NewReader("{\"rules\": [{\"url\": \"https://example.org\"}]}"))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("want: %d for a rule without conditions, got: %d", http.StatusBadRequest, resp.StatusCode)
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodGet, rulesAddr, accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		var listed struct {
+			Data []struct {
+				OS  []string `json:"os"`
+				URL string   `json:"url"`
+			} `json:"data"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&listed)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("couldn't decode response: %v", err)
+		}
+		if len(listed.Data) != 2 || listed.Data[0].URL != "https://apps.apple.com/app/id1" {
+			t.Fatalf("want: the ios rule first, got: %+v", listed.Data)
+		}
+
+		client := http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+		for userAgent, want := range map[string]string{
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1": "https://apps.apple.com/app/id1",
+			"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36":                   "https://play.google.com/store/apps/details?id=org.example",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36":                         "https://app.example.org/get",
+		} {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, shortAddr, nil)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			req.Header.Set("User-Agent", userAgent)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			resp.Body.Close()
+			if got := resp.Header.Get("Location"); got != want {
+				t.Errorf("want: %s for %s, got: %s", want, userAgent, got)
+			}
+			if resp.Header.Get("Vary") != "User-Agent" {
+				t.Errorf("want: Vary: User-Agent, got: %q", resp.Header.Get("Vary"))
+			}
+		}
+	})
+
+	t.Run("it should route visitors by location", func(t *testing.T) {
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+		created := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://shop.example.org/all\"}")
+		rulesAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/"+created.ID+"/rules")
+		shortAddr := tests.BuildRequestUrl(cfg.Server, "/"+created.ShortURLID)
+
+		resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPut, rulesAddr, accessToken, strings.NewReader("{\"rules\": [{\"countries\": [\"de\"], \"url\": \"https://shop.example.org/de\"}, {\"regions\": [\"US-CA\"], \"url\": \"https://shop.example.org/ca\"}]}"))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("want: %d, got: %d", http.StatusOK, resp.StatusCode)
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodPut, rulesAddr, accessToken, strings.NewReader("{\"rules\": [{\"regions\": [\"California\"], \"url\": \"https://shop.example.org/ca\"}]}"))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("want: %d for an invalid region, got: %d", http.StatusBadRequest, resp.StatusCode)
+		}
+
+		client := http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+		visit := func(ip string) string {
+			t.Helper()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, shortAddr, nil)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			req.Header.Set("X-Forwarded-For", ip)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			resp.Body.Close()
+			return resp.Header.Get("Location")
+		}
+
+		for ip, want := range map[string]string{
+			"198.51.100.7": "https://shop.example.org/de",
+			"203.0.113.9":  "https://shop.example.org/ca",
+			"192.0.2.1":    "https://shop.example.org/all",
+			// Only the hop our proxy appended counts, not what the client sent
+			"203.0.113.9, 198.51.100.7":           "https://shop.example.org/de",
+			"203.0.113.9, 198.51.100.7, 10.0.0.1": "https://shop.example.org/de",
+		} {
+			if got := visit(ip); got != want {
+				t.Errorf("want: %s for %s, got: %s", want, ip, got)
+			}
+		}
+
+		// The database is picked up again when it's replaced
+		err = tests.WriteGeoIPDatabase(geoIPPath, map[string]tests.GeoIPLocation{
+			"192.0.2.0/24": {Country: "DE", City: "Hamburg"},
+		})
+		if err != nil {
+			t.Fatalf("couldn't write geoip database: %v", err)
+		}
+		deadline := time.Now().Add(2 * time.Second)
+		for visit("192.0.2.1") != "https://shop.example.org/de" {
+			if time.Now().After(deadline) {
+				t.Fatalf("want: the new database to be loaded")
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+
+		statsAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/"+created.ID+"/stats")
+		deadline = time.Now().Add(2 * time.Second)
+		for {
+			resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodGet, statsAddr, accessToken, nil)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			var stats struct {
+				Data struct {
+					Countries []struct {
+						Value string `json:"value"`
+					} `json:"countries"`
+					Cities []struct {
+						Value string `json:"value"`
+					} `json:"cities"`
+				} `json:"data"`
+			}
+			err = json.NewDecoder(resp.Body).Decode(&stats)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatalf("couldn't decode response: %v", err)
+			}
+			if len(stats.Data.Countries) == 2 && len(stats.Data.Cities) == 3 {
+				if stats.Data.Countries[0].Value != "DE" {
+					t.Errorf("want: DE as the top country, got: %+v", stats.Data.Countries)
+				}
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("want: clicks from 2 countries and 3 cities, got: %+v", stats.Data)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	})
+
+	t.Run("it should split visitors between weighted variants", func(t *testing.T) {
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+		created := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://ab.example.org/original\"}")
+		variantsAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/"+created.ID+"/variants")
+		shortAddr := tests.BuildRequestUrl(cfg.Server, "/"+created.ShortURLID)
+
+		variantIDs := map[string]string{}
+		for _, name := range []string{"a", "b"} {
+			resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPost, variantsAddr, accessToken, strings.NewReader("{\"url\": \"https://ab.example.org/"+name+"\", \"weight\": 50}"))
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			var variant struct {
+				Data struct {
+					ID  string `json:"id"`
+					URL string `json:"url"`
+				} `json:"data"`
+			}
+			err = json.NewDecoder(resp.Body).Decode(&variant)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatalf("couldn't decode response: %v", err)
+			}
+			if resp.StatusCode != http.StatusCreated {
+				t.Fatalf("want: %d, got: %d", http.StatusCreated, resp.StatusCode)
+			}
+			variantIDs[variant.Data.URL] = variant.Data.ID
+		}
+
+		visit := func(client *http.Client, userAgent string) string {
+			t.Helper()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, shortAddr, nil)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			req.Header.Set("User-Agent", userAgent)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			resp.Body.Close()
+			return resp.Header.Get("Location")
+		}
+		noRedirect := func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+
+		seen := map[string]int{}
+		for i := 0; i < 40; i++ {
+			seen[visit(&http.Client{CheckRedirect: noRedirect}, fmt.Sprintf("visitor-%d", i))]++
+		}
+		if len(seen) != 2 || seen["https://ab.example.org/a"] == 0 || seen["https://ab.example.org/b"] == 0 {
+			t.Errorf("want: visitors split between both variants, got: %v", seen)
+		}
+
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		sticky := &http.Client{Jar: jar, CheckRedirect: noRedirect}
+		first := visit(sticky, "sticky-visitor")
+		for i := 0; i < 10; i++ {
+			// Changing user agents would land elsewhere without the cookie
+			if got := visit(sticky, fmt.Sprintf("sticky-visitor-%d", i)); got != first {
+				t.Fatalf("want: %s on every visit, got: %s", first, got)
+			}
+		}
+
+		// Pausing their variant sends the visitor to the other one
+		resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPatch, variantsAddr+"/"+variantIDs[first], accessToken, strings.NewReader("{\"weight\": 0}"))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("want: %d, got: %d", http.StatusOK, resp.StatusCode)
+		}
+		second := visit(sticky, "sticky-visitor")
+		if second == first || variantIDs[second] == "" {
+			t.Errorf("want: the other variant once %s is paused, got: %s", first, second)
+		}
+
+		statsAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/"+created.ID+"/stats")
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodGet, statsAddr, accessToken, nil)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			var stats struct {
+				Data struct {
+					Variants []struct {
+						Value  string `json:"value"`
+						Clicks int64  `json:"clicks"`
+					} `json:"variants"`
+				} `json:"data"`
+			}
+			err = json.NewDecoder(resp.Body).Decode(&stats)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatalf("couldn't decode response: %v", err)
+			}
+			var total int64
+			for _, variant := range stats.Data.Variants {
+				total += variant.Clicks
+			}
+			if total == 52 {
+				if len(stats.Data.Variants) != 2 {
+					t.Errorf("want: clicks for 2 variants, got: %+v", stats.Data.Variants)
+				}
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("want: 52 clicks across variants, got: %+v", stats.Data.Variants)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodPost, variantsAddr+"/missing/winner", accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("want: %d for an unknown variant, got: %d", http.StatusNotFound, resp.StatusCode)
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodPost, variantsAddr+"/"+variantIDs[first]+"/winner", accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		var updated struct {
+			Data testLink `json:"data"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&updated)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("couldn't decode response: %v", err)
+		}
+		if updated.Data.OriginalURL != first {
+			t.Errorf("want: %s as the destination, got: %s", first, updated.Data.OriginalURL)
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodGet, variantsAddr, accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		var listed struct {
+			Data []any `json:"data"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&listed)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("couldn't decode response: %v", err)
+		}
+		if len(listed.Data) != 0 {
+			t.Errorf("want: no variants after declaring a winner, got: %v", listed.Data)
+		}
+		if got := visit(sticky, "sticky-visitor"); got != first {
+			t.Errorf("want: %s for everyone, got: %s", first, got)
+		}
+	})
+
+	t.Run("it should follow rule schedules and activation times", func(t *testing.T) {
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+		created := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://sale.example.org/regular\", \"redirect_type\": 301}")
+		rulesAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/"+created.ID+"/rules")
+		shortAddr := tests.BuildRequestUrl(cfg.Server, "/"+created.ShortURLID)
+
+		tokyo, err := time.LoadLocation("Asia/Tokyo")
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		now := time.Now()
+		yesterday := strings.ToLower(now.In(tokyo).AddDate(0, 0, -1).Weekday().String()[:3])
+
+		rules := fmt.Sprintf("{\"rules\": ["+
+			"{\"ends_at\": %q, \"url\": \"https://sale.example.org/over\"},"+
+			"{\"timezone\": \"Asia/Tokyo\", \"days\": [%q], \"url\": \"https://sale.example.org/yesterday\"},"+
+			"{\"timezone\": \"Asia/Tokyo\", \"starts_at\": %q, \"ends_at\": %q, \"url\": \"https://sale.example.org/live\"}]}",
+			now.Add(-time.Hour).Format(time.RFC3339),
+			yesterday,
+			now.In(tokyo).Add(-time.Hour).Format("2006-01-02T15:04"),
+			now.In(tokyo).Add(time.Hour).Format("2006-01-02T15:04"),
+		)
+		resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPut, rulesAddr, accessToken, strings.NewReader(rules))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		var listed struct {
+			Data []struct {
+				Timezone string `json:"timezone"`
+				StartsAt string `json:"starts_at"`
+			} `json:"data"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&listed)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("couldn't decode response: %v", err)
+		}
+		if len(listed.Data) != 3 || listed.Data[2].Timezone != "Asia/Tokyo" || !strings.HasSuffix(listed.Data[2].StartsAt, "+09:00") {
+			t.Fatalf("want: the live rule in Asia/Tokyo, got: %+v", listed.Data)
+		}
+
+		resp, err = tests.DoRequestWithoutRedirect(ctx, http.MethodGet, shortAddr)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusFound {
+			t.Errorf("want: %d instead of a permanent redirect, got: %d", http.StatusFound, resp.StatusCode)
+		}
+		if got := resp.Header.Get("Location"); got != "https://sale.example.org/live" {
+			t.Errorf("want: the live rule's destination, got: %s", got)
+		}
+
+		for _, body := range []string{
+			"{\"rules\": [{\"timezone\": \"Mars/Olympus\", \"days\": [\"mon\"], \"url\": \"https://example.org/x\"}]}",
+			"{\"rules\": [{\"starts_at\": \"2030-01-02T00:00\", \"ends_at\": \"2030-01-01T00:00\", \"url\": \"https://example.org/x\"}]}",
+			"{\"rules\": [{\"daily_start\": \"09:00\", \"url\": \"https://example.org/x\"}]}",
+		} {
+			resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPut, rulesAddr, accessToken, strings.NewReader(body))
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("want: %d for %s, got: %d", http.StatusBadRequest, body, resp.StatusCode)
+			}
+		}
+
+		later := createLink(t, ctx, linksAddr, accessToken, fmt.Sprintf("{\"url\": \"https://launch.example.org/product\", \"activates_at\": %q}", now.Add(time.Hour).Format(time.RFC3339)))
+		laterAddr := tests.BuildRequestUrl(cfg.Server, "/"+later.ShortURLID)
+
+		resp, err = tests.DoRequestWithoutRedirect(ctx, http.MethodGet, laterAddr)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("want: %d before the link activates, got: %d", http.StatusNotFound, resp.StatusCode)
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodPost, linksAddr, accessToken, strings.NewReader(fmt.Sprintf("{\"url\": \"https://launch.example.org/past\", \"activates_at\": %q}", now.Add(-time.Hour).Format(time.RFC3339))))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("want: %d for an activation in the past, got: %d", http.StatusBadRequest, resp.StatusCode)
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodPatch, tests.BuildRequestUrl(cfg.Server, "/api/links/"+later.ID), accessToken, strings.NewReader("{\"activates_at\": \"\"}"))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("want: %d, got: %d", http.StatusOK, resp.StatusCode)
+		}
+
+		resp, err = tests.DoRequestWithoutRedirect(ctx, http.MethodGet, laterAddr)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if got := resp.Header.Get("Location"); got != "https://launch.example.org/product" {
+			t.Errorf("want: the destination once activated, got: %s", got)
+		}
+	})
+
+	t.Run("it should add utm parameters and pass visitors' query strings on", func(t *testing.T) {
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+		created := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://shop.example.org/spring?ref=home&utm_source=old#top\"}")
+		queryAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/"+created.ID+"/query")
+		shortAddr := tests.BuildRequestUrl(cfg.Server, "/"+created.ShortURLID)
+
+		setQuery := func(body string) {
+			t.Helper()
+			resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPut, queryAddr, accessToken, strings.NewReader(body))
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("want: %d, got: %d", http.StatusOK, resp.StatusCode)
+			}
+		}
+		location := func(query string) string {
+			t.Helper()
+			resp, err := tests.DoRequestWithoutRedirect(ctx, http.MethodGet, shortAddr+query)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			resp.Body.Close()
+			return resp.Header.Get("Location")
+		}
+
+		setQuery("{\"utm\": {\"source\": \"newsletter\", \"campaign\": \"spring sale\"}}")
+		if got, want := location("?ref=ad"), "https://shop.example.org/spring?ref=home&utm_source=newsletter&utm_campaign=spring+sale#top"; got != want {
+			t.Errorf("want: %s, got: %s", want, got)
+		}
+
+		setQuery("{\"utm\": {\"source\": \"newsletter\"}, \"query_passthrough\": \"merge\"}")
+		if got, want := location("?ref=ad&gclid=a%26b&tag=1&tag=2"), "https://shop.example.org/spring?ref=home&utm_source=newsletter&gclid=a%26b&tag=1&tag=2#top"; got != want {
+			t.Errorf("want: %s, got: %s", want, got)
+		}
+
+		setQuery("{\"utm\": {\"source\": \"newsletter\"}, \"query_passthrough\": \"override\"}")
+		if got, want := location("?ref=ad&utm_source=twitter&q=caf%C3%A9"), "https://shop.example.org/spring?ref=ad&utm_source=twitter&q=caf%C3%A9#top"; got != want {
+			t.Errorf("want: %s, got: %s", want, got)
+		}
+
+		resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPut, queryAddr, accessToken, strings.NewReader("{\"query_passthrough\": \"append\"}"))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("want: %d for an unknown policy, got: %d", http.StatusBadRequest, resp.StatusCode)
+		}
+
+		setQuery("{}")
+		if got, want := location("?ref=ad"), "https://shop.example.org/spring?ref=home&utm_source=old#top"; got != want {
+			t.Errorf("want: the destination as is, got: %s", got)
+		}
+		// Pairs the destination has that don't decode are kept as they are
+		raw := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://shop.example.org/sale?off=50%&x=1\"}")
+		rawQueryAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/"+raw.ID+"/query")
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodPut, rawQueryAddr, accessToken, strings.NewReader("{\"query_passthrough\": \"merge\"}"))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		resp, err = tests.DoRequestWithoutRedirect(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/"+raw.ShortURLID+"?ref=ad&bad=%zz"))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if got, want := resp.Header.Get("Location"), "https://shop.example.org/sale?off=50%&x=1&ref=ad"; got != want {
+			t.Errorf("want: %s, got: %s", want, got)
+		}
+	})
+
+	t.Run("it should group links into campaigns with combined stats", func(t *testing.T) {
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+		campaignsAddr := tests.BuildRequestUrl(cfg.Server, "/api/campaigns")
+		startsAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+
+		resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPost, campaignsAddr, accessToken, strings.NewReader(fmt.Sprintf("{\"name\": \"Spring launch\", \"starts_at\": %q, \"utm\": {\"source\": \"spring\", \"medium\": \"email\", \"campaign\": \"spring-2026\"}}", startsAt.Format(time.RFC3339))))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		var campaign struct {
+			Data struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&campaign)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("couldn't decode response: %v", err)
+		}
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("want: %d, got: %d", http.StatusCreated, resp.StatusCode)
+		}
+		campaignAddr := campaignsAddr + "/" + campaign.Data.ID
+
+		for body, want := range map[string]int{
+			"{\"name\": \"spring LAUNCH\"}": http.StatusConflict,
+			"{\"name\": \"Ends early\", \"starts_at\": \"2030-02-01T00:00:00Z\", \"ends_at\": \"2030-01-01T00:00:00Z\"}": http.StatusBadRequest,
+		} {
+			resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPost, campaignsAddr, accessToken, strings.NewReader(body))
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != want {
+				t.Errorf("want: %d for %s, got: %d", want, body, resp.StatusCode)
+			}
+		}
+
+		email := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://launch.example.org/email\"}")
+		banner := createLink(t, ctx, linksAddr, accessToken, "{\"url\": \"https://launch.example.org/banner\"}")
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodPut, tests.BuildRequestUrl(cfg.Server, "/api/links/"+email.ID+"/query"), accessToken, strings.NewReader("{\"utm\": {\"source\": \"newsletter\"}}"))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodPost, campaignAddr+"/links", accessToken, strings.NewReader(fmt.Sprintf("{\"link_ids\": [%q, %q, \"missing\"]}", email.ID, banner.ID)))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		var added struct {
+			Data struct {
+				Updated  int      `json:"updated"`
+				NotFound []string `json:"not_found"`
+			} `json:"data"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&added)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("couldn't decode response: %v", err)
+		}
+		if added.Data.Updated != 2 || len(added.Data.NotFound) != 1 {
+			t.Fatalf("want: 2 links added and 1 not found, got: %+v", added.Data)
+		}
+
+		location := func(l testLink) string {
+			t.Helper()
+			resp, err := tests.DoRequestWithoutRedirect(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/"+l.ShortURLID))
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			resp.Body.Close()
+			return resp.Header.Get("Location")
+		}
+
+		if got, want := location(email), "https://launch.example.org/email?utm_source=newsletter&utm_medium=email&utm_campaign=spring-2026"; got != want {
+			t.Errorf("want: %s, got: %s", want, got)
+		}
+		location(email)
+		if got, want := location(banner), "https://launch.example.org/banner?utm_source=spring&utm_medium=email&utm_campaign=spring-2026"; got != want {
+			t.Errorf("want: %s, got: %s", want, got)
+		}
+
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodGet, campaignAddr+"/stats", accessToken, nil)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			var stats struct {
+				Data struct {
+					From                string `json:"from"`
+					TotalClicks         int64  `json:"total_clicks"`
+					DailyUniqueVisitors int64  `json:"daily_unique_visitors"`
+					Links               []struct {
+						Value  string `json:"value"`
+						Clicks int64  `json:"clicks"`
+					} `json:"links"`
+				} `json:"data"`
+			}
+			err = json.NewDecoder(resp.Body).Decode(&stats)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatalf("couldn't decode response: %v", err)
+			}
+			if stats.Data.TotalClicks == 3 {
+				if len(stats.Data.Links) != 2 || stats.Data.Links[0].Value != email.ID || stats.Data.Links[0].Clicks != 2 {
+					t.Errorf("want: 2 clicks on the email link first, got: %+v", stats.Data.Links)
+				}
+				// The same visitor clicked both links
+				if stats.Data.DailyUniqueVisitors != 1 {
+					t.Errorf("want: 1 unique visitor across the campaign, got: %d", stats.Data.DailyUniqueVisitors)
+				}
+				if from, err := time.Parse(time.RFC3339, stats.Data.From); err != nil || !from.Equal(startsAt) {
+					t.Errorf("want: stats from the campaign's start %s, got: %s", startsAt, stats.Data.From)
+				}
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("want: 3 clicks, got: %+v", stats.Data)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		// Trashed links leave the stats along with the link list
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodDelete, tests.BuildRequestUrl(cfg.Server, "/api/links/"+banner.ID), accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("want: %d, got: %d", http.StatusOK, resp.StatusCode)
+		}
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodGet, campaignAddr+"/stats", accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		var trashedStats struct {
+			Data struct {
+				TotalClicks int64 `json:"total_clicks"`
+				Links       []struct {
+					Value string `json:"value"`
+				} `json:"links"`
+			} `json:"data"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&trashedStats)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("couldn't decode response: %v", err)
+		}
+		if trashedStats.Data.TotalClicks != 2 || len(trashedStats.Data.Links) != 1 || trashedStats.Data.Links[0].Value != email.ID {
+			t.Errorf("want: only the email link's 2 clicks, got: %+v", trashedStats.Data)
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodDelete, campaignAddr+"/links/"+banner.ID, accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent {
+			t.Errorf("want: %d, got: %d", http.StatusNoContent, resp.StatusCode)
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodGet, campaignsAddr, accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		var listed struct {
+			Data []struct {
+				ID        string `json:"id"`
+				LinkCount int64  `json:"link_count"`
+			} `json:"data"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&listed)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("couldn't decode response: %v", err)
+		}
+		if len(listed.Data) != 1 || listed.Data[0].LinkCount != 1 {
+			t.Errorf("want: the campaign with 1 link, got: %+v", listed.Data)
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodDelete, campaignAddr, accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent {
+			t.Errorf("want: %d, got: %d", http.StatusNoContent, resp.StatusCode)
+		}
+
+		if got, want := location(email), "https://launch.example.org/email?utm_source=newsletter"; got != want {
+			t.Errorf("want: %s once the campaign is gone, got: %s", want, got)
+		}
+	})
+
+	t.Run("it should serve links on verified branded domains", func(t *testing.T) {
+		linksAddr := tests.BuildRequestUrl(cfg.Server, "/api/links/links")
+		domainsAddr := tests.BuildRequestUrl(cfg.Server, "/api/domains")
+		hostname := fmt.Sprintf("go%d.example.com", time.Now().UnixNano())
+
+		resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPost, domainsAddr, accessToken, strings.NewReader(fmt.Sprintf("{\"hostname\": %q}", strings.ToUpper(hostname)+".")))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		var domain struct {
+			Data struct {
+				ID           string `json:"id"`
+				Hostname     string `json:"hostname"`
+				Verified     bool   `json:"verified"`
+				Verification struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"verification"`
+			} `json:"data"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&domain)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("couldn't decode response: %v", err)
+		}
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("want: %d, got: %d", http.StatusCreated, resp.StatusCode)
+		}
+		if domain.Data.Hostname != hostname || domain.Data.Verified || domain.Data.Verification.Name != "_url-shortener."+hostname {
+			t.Fatalf("want: unverified %s, got: %+v", hostname, domain.Data)
+		}
+		domainAddr := domainsAddr + "/" + domain.Data.ID
+
+		for body, want := range map[string]int{
+			fmt.Sprintf("{\"hostname\": %q}", hostname): http.StatusConflict,
+			"{\"hostname\": \"localhost\"}":             http.StatusBadRequest,
+			"{\"hostname\": \"127.0.0.1\"}":             http.StatusBadRequest,
+		} {
+			resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPost, domainsAddr, accessToken, strings.NewReader(body))
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != want {
+				t.Errorf("want: %d for %s, got: %d", want, body, resp.StatusCode)
+			}
+		}
+
+		linkBody := fmt.Sprintf("{\"url\": \"https://brand.example.org/launch\", \"domain_id\": %q}", domain.Data.ID)
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodPost, linksAddr, accessToken, strings.NewReader(linkBody))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("want: %d before the domain is verified, got: %d", http.StatusBadRequest, resp.StatusCode)
+		}
+
+		verify := func(want int) {
+			t.Helper()
+			resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPost, domainAddr+"/verify", accessToken, nil)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != want {
+				t.Fatalf("want: %d, got: %d", want, resp.StatusCode)
+			}
+		}
+
+		verify(http.StatusUnprocessableEntity)
+		resolver.SetTXT(domain.Data.Verification.Name, "v=spf1 -all", "url-shortener-verification=wrong")
+		verify(http.StatusUnprocessableEntity)
+		resolver.SetTXT(domain.Data.Verification.Name, domain.Data.Verification.Value)
+		verify(http.StatusOK)
+
+		branded := createLink(t, ctx, linksAddr, accessToken, linkBody)
+
+		// Each domain has its own aliases
+		alias := fmt.Sprintf("launch-%d", time.Now().UnixNano())
+		bulkBody := fmt.Sprintf("[{\"url\": \"https://brand.example.org/alias\", \"pretty_id\": %q, \"domain_id\": %q}, {\"url\": \"https://shared.example.org/alias\", \"pretty_id\": %q}, {\"url\": \"https://brand.example.org/taken\", \"pretty_id\": %q, \"domain_id\": %q}]", alias, domain.Data.ID, alias, alias, domain.Data.ID)
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodPost, tests.BuildRequestUrl(cfg.Server, "/api/links/bulk"), accessToken, strings.NewReader(bulkBody))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		var bulk struct {
+			Created int `json:"created"`
+			Failed  int `json:"failed"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&bulk)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("couldn't decode response: %v", err)
+		}
+		if bulk.Created != 2 || bulk.Failed != 1 {
+			t.Fatalf("want: the alias once on each domain, got: %+v", bulk)
+		}
+
+		location := func(host string, code string) (int, string) {
+			t.Helper()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/"+code), nil)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			if host != "" {
+				req.Host = host
+			}
+			client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			}}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+			resp.Body.Close()
+			return resp.StatusCode, resp.Header.Get("Location")
+		}
+
+		if _, got := location(hostname, branded.ShortURLID); got != "https://brand.example.org/launch" {
+			t.Errorf("want: the branded link on its domain, got: %q", got)
+		}
+		if status, _ := location("", branded.ShortURLID); status >= 300 && status < 400 {
+			t.Errorf("want: no redirect for the branded link on the shared domain, got: %d", status)
+		}
+		if _, got := location(hostname+":443", alias); got != "https://brand.example.org/alias" {
+			t.Errorf("want: the branded alias, got: %q", got)
+		}
+		if _, got := location("", alias); got != "https://shared.example.org/alias" {
+			t.Errorf("want: the shared alias, got: %q", got)
+		}
+
+		resp, err = tests.DoAuthenticatedRequest(ctx, http.MethodDelete, domainAddr, accessToken, nil)
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusConflict {
+			t.Errorf("want: %d while links are on the domain, got: %d", http.StatusConflict, resp.StatusCode)
+		}
+	})
+
+	t.Run("it should fall through to the web app for unknown codes", func(t *testing.T) {
+		resp, err := tests.DoRequestWithoutRedirect(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/doesnotexist"))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+			t.Fatalf("want: no redirect, got: %d", resp.StatusCode)
+		}
+		// The file server has nothing by that name either
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("want: %d, got: %d", http.StatusNotFound, resp.StatusCode)
+		}
+
+		index, err := tests.DoRequestWithoutRedirect(ctx, http.MethodGet, tests.BuildRequestUrl(cfg.Server, "/"))
+		if err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+		defer index.Body.Close()
+		if index.StatusCode != http.StatusOK {
+			t.Fatalf("want: %d for the web app, got: %d", http.StatusOK, index.StatusCode)
+		}
+		if contentType := index.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+			t.Fatalf("want: text/html, got: %s", contentType)
+		}
+	})
+}
+
+type testLink struct {
+	ID          string `json:"id"`
+	OriginalURL string `json:"original_url"`
+	ShortURLID  string `json:"short_url_id"`
+}
+
+func createLink(t *testing.T, ctx context.Context, addr string, accessToken string, body string) testLink {
+	t.Helper()
+
+	resp, err := tests.DoAuthenticatedRequest(ctx, http.MethodPost, addr, accessToken, bytes.NewReader([]byte(body)))
+	if err != nil {
+		t.Fatalf("failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("want: %d, got: %d", http.StatusCreated, resp.StatusCode)
+	}
+
+	var created struct {
+		Data testLink `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("couldn't decode response: %v", err)
+	}
+
+	return created.Data
+}
+
+// signupAndIssueToken registers a fresh user and mints an access token for
+// them directly, skipping the email verification the login flow requires.
+func signupAndIssueToken(t *testing.T, ctx context.Context, symmetricKey string, signupAddr string) string {
+	t.Helper()
+
+	email := fmt.Sprintf("links-%d@example.com", time.Now().UnixNano())
+	body := bytes.NewReader([]byte(fmt.Sprintf("{\"email\": \"%s\",\"password\": \"PBTsVser1.\",\"first_name\": \"John\",\"last_name\": \"Doe\"}", email)))
+
+	resp, err := tests.DoRequest(ctx, http.MethodPost, signupAddr, body)
+	if err != nil {
+		t.Fatalf("failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("want: %d, got: %d", http.StatusCreated, resp.StatusCode)
+	}
+
+	var signup struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&signup); err != nil {
+		t.Fatalf("couldn't decode signup response: %v", err)
+	}
+
+	tokenMaker, err := token.NewPasetoMaker(symmetricKey)
+	if err != nil {
+		t.Fatalf("couldn't create token maker: %v", err)
+	}
+	accessToken, _, err := tokenMaker.CreateToken(signup.Data.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("couldn't create token: %v", err)
+	}
+
+	return accessToken
+}
@@ -7,12 +7,19 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
+	// Stats accept any IANA time zone, which the runtime image may not ship
+	_ "time/tzdata"
 	db "url-shortener/db/sqlc"
+	"url-shortener/internal/analytics"
 	"url-shortener/internal/config"
+	"url-shortener/internal/link"
 	"url-shortener/internal/server"
 	"url-shortener/internal/token"
 
@@ -37,7 +44,15 @@ func main() {
 		return
 	}
 
-	if err := run(ctx, cfg); err != nil {
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(ctx, cfg, os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := run(ctx, cfg, nil); err != nil {
 		fmt.Fprintf(os.Stderr, "%s\n", err)
 		os.Exit(1)
 	}
@@ -49,13 +64,22 @@ var interruptSignals = []os.Signal{
 	syscall.SIGINT,
 }
 
-func run(ctx context.Context, cfg config.Config) error {
+// run serves the app until ctx is done. resolver verifies branded domains;
+// nil uses the system's DNS resolver.
+func run(ctx context.Context, cfg config.Config, resolver link.TXTResolver) error {
 	ctx, stop := signal.NotifyContext(ctx, interruptSignals...)
 
 	defer stop()
 
 	slog.Info("Run mode:", "Debug", cfg.Debug)
 
+	if err := checkBaseURL(cfg.Server.BaseURL); err != nil {
+		slog.Error("checkBaseURL", "error", err)
+		return err
+	}
+
+	cfg.Export.Directory = exportDirectory(cfg)
+
 	sqliteDB, err := initDB(cfg.Database)
 
 	if err != nil {
@@ -74,7 +98,7 @@ func run(ctx context.Context, cfg config.Config) error {
 		return err
 	}
 
-	queries := db.New(sqliteDB)
+	store := db.NewStore(sqliteDB)
 
 	fs := InitWebServer()
 
@@ -85,7 +109,29 @@ func run(ctx context.Context, cfg config.Config) error {
 		return err
 	}
 
-	srv := server.New(ctx, cfg, fs, queries, tokenMaker)
+	codeGenerator, err := link.NewCodeGenerator(cfg.Link.CodeStrategy, cfg.Link.CodeLength, cfg.Link.CodeSecret)
+
+	if err != nil {
+		slog.Error("link.NewCodeGenerator", "error", err)
+		return err
+	}
+
+	clickRecorder := analytics.NewRecorder(store, analytics.RecorderConfig{
+		BufferSize:    cfg.Analytics.BufferSize,
+		BatchSize:     cfg.Analytics.BatchSize,
+		FlushInterval: cfg.Analytics.FlushInterval,
+	})
+
+	// Closed once buffered clicks are written, which has to happen before
+	// the deferred DB close
+	clickRecorderDone := make(chan struct{})
+
+	go func() {
+		clickRecorder.Run(ctx)
+		close(clickRecorderDone)
+	}()
+
+	srv := server.New(ctx, cfg, fs, store, tokenMaker, codeGenerator, resolver, clickRecorder)
 
 	httpServer := &http.Server{
 		Addr:         cfg.Server.Address,
@@ -102,40 +148,129 @@ func run(ctx context.Context, cfg config.Config) error {
 		serverErr <- httpServer.ListenAndServe()
 	}()
 
+	var runErr error
+
 	select {
 	case err := <-serverErr:
 		if err != nil && err != http.ErrServerClosed {
 			slog.Error("httpServer.ListenAndServe", "error", err)
-			return err
+			runErr = err
 		}
 	case <-ctx.Done():
 		const timeout = 1 * time.Second
-		shutdownCtx, cancel := context.WithTimeout(ctx, timeout)
+		// ctx is already done, and in-flight redirects still have clicks to record
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
 		defer cancel()
 		if err := httpServer.Shutdown(shutdownCtx); err != nil {
 			slog.Error(fmt.Sprintf("server failed to shut down gracefully in %v", timeout), "error", err)
 			if err := httpServer.Close(); err != nil {
 				slog.Error("httpServer.Close", "error", err)
-				return err
+				runErr = err
 			}
 		}
 		slog.Info("server shut down")
 	}
 
+	// The recorder only flushes once ctx is done, which it isn't yet when
+	// the server failed
+	stop()
+	<-clickRecorderDone
+	slog.Info("click recorder flushed")
+
+	return runErr
+}
+
+// checkBaseURL makes sure short links and download links are built on a
+// configured URL, never on whatever Host header a request came with.
+func checkBaseURL(baseURL string) error {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("BASE_URL must be the absolute http(s) URL short links are served from, got %q", baseURL)
+	}
 	return nil
 }
 
+// exportDirectory keeps export archives next to the database file, on the
+// disk that survives restarts, unless EXPORT_DIRECTORY is set. In-memory
+// databases leave it to the export service's temp directory.
+func exportDirectory(cfg config.Config) string {
+	if cfg.Export.Directory != "" || isInMemoryDB(cfg.Database.Uri) {
+		return cfg.Export.Directory
+	}
+	path, _, _ := strings.Cut(strings.TrimPrefix(cfg.Database.Uri, "file:"), "?")
+	return filepath.Join(filepath.Dir(path), "exports")
+}
+
+// fileDBMaxOpenConns caps the pool for database files. In WAL mode readers
+// don't block each other or the writer, and writers queue on the busy
+// timeout.
+const fileDBMaxOpenConns = 8
+
+// fileDBParams are added to the URI of database files unless it sets them
+// itself. Transactions take the write lock up front, since SQLite can't
+// wait for a lock a transaction that read first needs to upgrade to.
+var fileDBParams = []string{
+	"_journal_mode=WAL",
+	"_busy_timeout=5000",
+	"_txlock=immediate",
+}
+
 func initDB(cfg config.Database) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", cfg.Uri)
+	inMemory := isInMemoryDB(cfg.Uri)
+
+	uri := cfg.Uri
+	if !inMemory {
+		uri = withDBParams(uri, fileDBParams)
+	}
+
+	db, err := sql.Open("sqlite3", uri)
 	if err != nil {
 		return nil, err
 	}
 	if err = db.Ping(); err != nil {
 		return nil, err
 	}
+
+	var hasFTS5 bool
+	if err = db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&hasFTS5); err != nil {
+		return nil, err
+	}
+	if !hasFTS5 {
+		db.Close()
+		return nil, fmt.Errorf("link search needs SQLite with FTS5: build with -tags sqlite_fts5")
+	}
+	if inMemory {
+		// Every connection to an in-memory database gets its own empty
+		// database
+		db.SetMaxOpenConns(1)
+	} else {
+		db.SetMaxOpenConns(fileDBMaxOpenConns)
+		db.SetMaxIdleConns(fileDBMaxOpenConns)
+	}
 	return db, nil
 }
 
+func isInMemoryDB(uri string) bool {
+	return strings.Contains(uri, ":memory:") || strings.Contains(uri, "mode=memory")
+}
+
+// withDBParams adds the query parameters in params whose names uri doesn't
+// have yet.
+func withDBParams(uri string, params []string) string {
+	for _, param := range params {
+		name, _, _ := strings.Cut(param, "=")
+		if strings.Contains(uri, name+"=") {
+			continue
+		}
+		separator := "?"
+		if strings.Contains(uri, "?") {
+			separator = "&"
+		}
+		uri += separator + param
+	}
+	return uri
+}
+
 func runMigration(db *sql.DB) error {
 	// Will wrap each migration in an implicit transaction by default
 	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
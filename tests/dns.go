@@ -0,0 +1,36 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// Resolver answers TXT lookups from records set by the test instead of
+// DNS, so domain verification can be tested without owning a domain.
+type Resolver struct {
+	mu      sync.Mutex
+	records map[string][]string
+}
+
+func NewResolver() *Resolver {
+	return &Resolver{records: map[string][]string{}}
+}
+
+// SetTXT publishes the TXT records of name, replacing any it had.
+func (r *Resolver) SetTXT(name string, values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[name] = values
+}
+
+func (r *Resolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	values, ok := r.records[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return values, nil
+}
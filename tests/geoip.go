@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// GeoIPLocation is what WriteGeoIPDatabase stores for a network. Region is
+// the subdivision code without the country, like "CA".
+type GeoIPLocation struct {
+	Country string
+	Region  string
+	City    string
+}
+
+// WriteGeoIPDatabase writes a small IPv4 GeoLite2-City style .mmdb file
+// mapping each network, like "203.0.113.0/24", to a location. The file is
+// written next to path and moved over it, the way database updates should
+// be installed.
+func WriteGeoIPDatabase(path string, networks map[string]GeoIPLocation) error {
+	const recordSize = 24
+
+	// Each node has two records: the left one for a 0 bit, the right one
+	// for a 1. Records point to another node, to data, or nowhere.
+	type record struct {
+		node int
+		data int
+	}
+	empty := record{node: -1, data: -1}
+	nodes := [][2]record{{empty, empty}}
+
+	var data bytes.Buffer
+
+	for network, location := range networks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			return err
+		}
+		if !prefix.Addr().Is4() || prefix.Bits() == 0 {
+			return fmt.Errorf("%s isn't an ipv4 network", network)
+		}
+
+		offset := data.Len()
+		data.Write(encodeMMDB(geoIPRecord(location)))
+
+		ip := prefix.Masked().Addr().As4()
+		node := 0
+		for i := 0; i < prefix.Bits(); i++ {
+			bit := (ip[i/8] >> (7 - i%8)) & 1
+			if i == prefix.Bits()-1 {
+				nodes[node][bit] = record{node: -1, data: offset}
+				break
+			}
+			if nodes[node][bit].node < 0 {
+				nodes = append(nodes, [2]record{empty, empty})
+				nodes[node][bit] = record{node: len(nodes) - 1, data: -1}
+			}
+			node = nodes[node][bit].node
+		}
+	}
+
+	nodeCount := len(nodes)
+
+	var file bytes.Buffer
+	for _, node := range nodes {
+		for _, r := range node {
+			value := nodeCount
+			switch {
+			case r.node >= 0:
+				value = r.node
+			case r.data >= 0:
+				// Data pointers skip the 16 byte separator after the tree
+				value = nodeCount + 16 + r.data
+			}
+			file.Write([]byte{byte(value >> 16), byte(value >> 8), byte(value)})
+		}
+	}
+	file.Write(make([]byte, 16))
+	file.Write(data.Bytes())
+	file.WriteString("\xab\xcd\xefMaxMind.com")
+	file.Write(encodeMMDB(map[string]any{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(time.Now().Unix()),
+		"database_type":               "GeoLite2-City",
+		"description":                 map[string]any{"en": "test database"},
+		"ip_version":                  uint16(4),
+		"languages":                   []any{"en"},
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(recordSize),
+	}))
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".geoip-*.mmdb")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(file.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func geoIPRecord(location GeoIPLocation) map[string]any {
+	record := map[string]any{
+		"country": map[string]any{"iso_code": location.Country},
+	}
+	if location.Region != "" {
+		record["subdivisions"] = []any{map[string]any{"iso_code": location.Region}}
+	}
+	if location.City != "" {
+		record["city"] = map[string]any{"names": map[string]any{"en": location.City}}
+	}
+	return record
+}
+
+// encodeMMDB encodes a value in the MaxMind DB data section format. Only
+// the types the test databases need are supported.
+func encodeMMDB(value any) []byte {
+	var out bytes.Buffer
+
+	switch v := value.(type) {
+	case string:
+		writeMMDBControl(&out, 2, len(v))
+		out.WriteString(v)
+	case uint16:
+		writeMMDBUint(&out, 5, uint64(v))
+	case uint32:
+		writeMMDBUint(&out, 6, uint64(v))
+	case uint64:
+		writeMMDBUint(&out, 9, v)
+	case map[string]any:
+		writeMMDBControl(&out, 7, len(v))
+		for key, item := range v {
+			out.Write(encodeMMDB(key))
+			out.Write(encodeMMDB(item))
+		}
+	case []any:
+		writeMMDBControl(&out, 11, len(v))
+		for _, item := range v {
+			out.Write(encodeMMDB(item))
+		}
+	default:
+		panic(fmt.Sprintf("tests: can't encode %T in an mmdb file", value))
+	}
+
+	return out.Bytes()
+}
+
+func writeMMDBUint(out *bytes.Buffer, dataType int, value uint64) {
+	raw := binary.BigEndian.AppendUint64(nil, value)
+	raw = bytes.TrimLeft(raw, "\x00")
+	writeMMDBControl(out, dataType, len(raw))
+	out.Write(raw)
+}
+
+// writeMMDBControl writes the control byte of a field: its type in the top
+// three bits, or in a second byte for extended types, and its size.
+func writeMMDBControl(out *bytes.Buffer, dataType int, size int) {
+	if size >= 29 {
+		// Longer sizes need more bytes, which test data never does
+		panic(fmt.Sprintf("tests: mmdb field too long: %d", size))
+	}
+
+	if dataType <= 7 {
+		out.WriteByte(byte(dataType<<5 | size))
+		return
+	}
+	out.WriteByte(byte(size))
+	out.WriteByte(byte(dataType - 7))
+}
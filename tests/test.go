@@ -10,9 +10,11 @@ import (
 	"url-shortener/internal/config"
 )
 
-func WaitForReady(ctx context.Context, timeout time.Duration, endpoint string) error {
-	client := http.Client{}
-	start := time.Now()
+// WaitForReady polls endpoint until it answers 200, the timeout passes or the
+// server sends on serverErr, which it does when it stops.
+func WaitForReady(ctx context.Context, timeout time.Duration, endpoint string, serverErr <-chan error) error {
+	client := http.Client{Timeout: time.Second}
+	deadline := time.After(timeout)
 
 	for {
 		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
@@ -20,25 +22,24 @@ func WaitForReady(ctx context.Context, timeout time.Duration, endpoint string) e
 		if err != nil {
 			return fmt.Errorf("failed to create request: %w", err)
 		}
+		// The server may not be listening yet
 		resp, err := client.Do(req)
-		if err != nil {
-			continue
-		}
-		if resp.StatusCode == http.StatusOK {
-			slog.Info("Endpoint is ready!")
+		if err == nil {
 			resp.Body.Close()
-			return nil
+			if resp.StatusCode == http.StatusOK {
+				slog.Info("Endpoint is ready!")
+				return nil
+			}
 		}
-		resp.Body.Close()
 
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		default:
-			if time.Since(start) >= timeout {
-				return fmt.Errorf("timeout reached while waiting for endpoint")
-			}
-			time.Sleep(250 * time.Millisecond)
+		case err := <-serverErr:
+			return fmt.Errorf("server stopped before it was ready: %w", err)
+		case <-deadline:
+			return fmt.Errorf("timeout reached while waiting for endpoint")
+		case <-time.After(250 * time.Millisecond):
 		}
 	}
 }
@@ -68,6 +69,7 @@ func BuildTestConfig() config.Config {
 			Address:           fmt.Sprintf("0.0.0.0:%d", port),
 			Port:              port,
 			TokenSymmetricKey: "bB34U3baPLuXWmBsol15g0aeV5VxF43f",
+			BaseURL:           fmt.Sprintf("http://127.0.0.1:%d", port),
 		},
 		Log: config.Log{
 			Level:  "debug",
@@ -77,6 +79,10 @@ func BuildTestConfig() config.Config {
 			Uri:                ":memory:",
 			MigrationSourceURL: "file://db/migrations",
 		},
+		Analytics: config.Analytics{
+			FlushInterval:  50 * time.Millisecond,
+			RollupInterval: 50 * time.Millisecond,
+		},
 		SMTP: config.SMTP{
 			Host:     "smtp.gmail.com",
 			Port:     587,
@@ -86,3 +92,32 @@ func BuildTestConfig() config.Config {
 		},
 	}
 }
+
+func DoAuthenticatedRequest(ctx context.Context, method string, addr string, accessToken string, body io.Reader) (*http.Response, error) {
+	client := http.Client{}
+	req, err := http.NewRequestWithContext(ctx, method, addr, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DoRequestWithoutRedirect returns redirect responses as they are instead of
+// following them.
+func DoRequestWithoutRedirect(ctx context.Context, method string, addr string) (*http.Response, error) {
+	client := http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	req, err := http.NewRequestWithContext(ctx, method, addr, nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
@@ -20,13 +20,16 @@ func TestUserSignup(t *testing.T) {
 
 	cfg := tests.BuildTestConfig()
 
-	go run(ctx, cfg)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- run(ctx, cfg, nil)
+	}()
 
 	timeout := 5 * time.Second
-	err := tests.WaitForReady(ctx, timeout, fmt.Sprintf("http://127.0.0.1:%d/health", cfg.Server.Port))
+	err := tests.WaitForReady(ctx, timeout, fmt.Sprintf("http://127.0.0.1:%d/health", cfg.Server.Port), serverErr)
 
 	if err != nil {
-		log.Fatalf("couldn't start the server in %fs", timeout.Seconds())
+		log.Fatalf("couldn't start the server in %fs: %v", timeout.Seconds(), err)
 	}
 
 	t.Run("it should return 400 for bad requests", func(t *testing.T) {